@@ -6,26 +6,27 @@ import (
 	"stormdragon/k8s-deployer/pkg/ui"
 )
 
-// PreDownloadAll 预下载所有需要的二进制文件
-func PreDownloadAll(manager *Manager, k8sVersion string) error {
+// PreDownloadAll 预下载所有需要的二进制文件（每个架构一份）
+func PreDownloadAll(manager *Manager, k8sVersion string, archs []string) error {
 	ui.Header("下载必需的二进制文件")
 	
 	allBinaries := []BinaryInfo{}
-	
-	// Kubernetes 组件
-	k8sBinaries := GetKubernetesVersion(k8sVersion)
-	allBinaries = append(allBinaries, k8sBinaries...)
-	
-	// containerd
-	allBinaries = append(allBinaries, GetContainerdInfo("1.7.10"))
-	
-	// Helm
-	allBinaries = append(allBinaries, GetHelmInfo("3.13.3"))
+	for _, arch := range archs {
+		// Kubernetes 组件
+		k8sBinaries := GetKubernetesVersion(k8sVersion, arch)
+		allBinaries = append(allBinaries, k8sBinaries...)
+		
+		// containerd
+		allBinaries = append(allBinaries, GetContainerdInfo("1.7.10", arch))
+		
+		// Helm
+		allBinaries = append(allBinaries, GetHelmInfo("3.13.3", arch))
+	}
 	
 	ui.Info("需要下载 %d 个文件", len(allBinaries))
 	
 	for i, binary := range allBinaries {
-		ui.Step(i+1, len(allBinaries), fmt.Sprintf("下载 %s %s", binary.Name, binary.Version))
+		ui.Step(i+1, len(allBinaries), fmt.Sprintf("下载 %s %s (%s)", binary.Name, binary.Version, binary.Arch))
 		
 		_, err := manager.GetBinaryPath(binary)
 		if err != nil {
@@ -38,8 +39,8 @@ func PreDownloadAll(manager *Manager, k8sVersion string) error {
 }
 
 // DownloadKubernetesComponents 下载 Kubernetes 组件
-func DownloadKubernetesComponents(manager *Manager, version string) (map[string]string, error) {
-	binaries := GetKubernetesVersion(version)
+func DownloadKubernetesComponents(manager *Manager, version, arch string) (map[string]string, error) {
+	binaries := GetKubernetesVersion(version, arch)
 	paths := make(map[string]string)
 	
 	for _, binary := range binaries {
@@ -54,14 +55,14 @@ func DownloadKubernetesComponents(manager *Manager, version string) (map[string]
 }
 
 // DownloadContainerd 下载 containerd
-func DownloadContainerd(manager *Manager, version string) (string, error) {
-	binary := GetContainerdInfo(version)
+func DownloadContainerd(manager *Manager, version, arch string) (string, error) {
+	binary := GetContainerdInfo(version, arch)
 	return manager.GetBinaryPath(binary)
 }
 
 // DownloadHelm 下载 Helm
-func DownloadHelm(manager *Manager, version string) (string, error) {
-	binary := GetHelmInfo(version)
+func DownloadHelm(manager *Manager, version, arch string) (string, error) {
+	binary := GetHelmInfo(version, arch)
 	return manager.GetBinaryPath(binary)
 }
 
@@ -16,6 +16,7 @@ import (
 type BinaryInfo struct {
 	Name    string
 	Version string
+	Arch    string // 架构（amd64 / arm64）
 	URL     string
 	SHA256  string // 可选的校验和
 }
@@ -39,17 +40,17 @@ func NewManager(cacheDir string) (*Manager, error) {
 
 // GetBinaryPath 获取二进制文件路径（如果不存在则下载）
 func (m *Manager) GetBinaryPath(info BinaryInfo) (string, error) {
-	// 构建缓存路径
-	cachePath := filepath.Join(m.CacheDir, info.Name, info.Version, filepath.Base(info.URL))
+	// 构建缓存路径（按架构区分，不同架构的 kubelet 等文件名相同）
+	cachePath := filepath.Join(m.CacheDir, info.Name, info.Version, info.Arch, filepath.Base(info.URL))
 	
 	// 检查是否已缓存
 	if _, err := os.Stat(cachePath); err == nil {
-		ui.Info("使用缓存的 %s %s", info.Name, info.Version)
+		ui.Info("使用缓存的 %s %s (%s)", info.Name, info.Version, info.Arch)
 		return cachePath, nil
 	}
 	
 	// 下载文件
-	ui.Info("下载 %s %s...", info.Name, info.Version)
+	ui.Info("下载 %s %s (%s)...", info.Name, info.Version, info.Arch)
 	if err := m.downloadBinary(info, cachePath); err != nil {
 		return "", err
 	}
@@ -130,43 +131,48 @@ func (m *Manager) downloadBinary(info BinaryInfo, destPath string) error {
 }
 
 // GetKubernetesVersion 获取 Kubernetes 版本的下载信息
-func GetKubernetesVersion(version string) []BinaryInfo {
-	baseURL := fmt.Sprintf("https://dl.k8s.io/release/%s/bin/linux/amd64", version)
+func GetKubernetesVersion(version, arch string) []BinaryInfo {
+	baseURL := fmt.Sprintf("https://dl.k8s.io/release/%s/bin/linux/%s", version, arch)
 	
 	return []BinaryInfo{
 		{
 			Name:    "kubectl",
 			Version: version,
+			Arch:    arch,
 			URL:     baseURL + "/kubectl",
 		},
 		{
 			Name:    "kubeadm",
 			Version: version,
+			Arch:    arch,
 			URL:     baseURL + "/kubeadm",
 		},
 		{
 			Name:    "kubelet",
 			Version: version,
+			Arch:    arch,
 			URL:     baseURL + "/kubelet",
 		},
 	}
 }
 
 // GetContainerdInfo 获取 containerd 下载信息
-func GetContainerdInfo(version string) BinaryInfo {
+func GetContainerdInfo(version, arch string) BinaryInfo {
 	return BinaryInfo{
 		Name:    "containerd",
 		Version: version,
-		URL:     fmt.Sprintf("https://github.com/containerd/containerd/releases/download/v%s/containerd-%s-linux-amd64.tar.gz", version, version),
+		Arch:    arch,
+		URL:     fmt.Sprintf("https://github.com/containerd/containerd/releases/download/v%s/containerd-%s-linux-%s.tar.gz", version, version, arch),
 	}
 }
 
 // GetHelmInfo 获取 Helm 下载信息
-func GetHelmInfo(version string) BinaryInfo {
+func GetHelmInfo(version, arch string) BinaryInfo {
 	return BinaryInfo{
 		Name:    "helm",
 		Version: version,
-		URL:     fmt.Sprintf("https://get.helm.sh/helm-v%s-linux-amd64.tar.gz", version),
+		Arch:    arch,
+		URL:     fmt.Sprintf("https://get.helm.sh/helm-v%s-linux-%s.tar.gz", version, arch),
 	}
 }
 
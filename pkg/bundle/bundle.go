@@ -0,0 +1,134 @@
+package bundle
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// 离线安装包（bundle）为 zstd 压缩的 tar 包，结构：
+//
+//	packages/...       离线包，路径与 packages 目录一致（包含只列出包内文件的 manifest.yaml）
+//	bundle.yaml        包清单：包内所有文件的大小和 sha256
+//	bundle.yaml.sig    包清单的 ed25519 签名（base64）
+//	bundle.pub         签名公钥（PEM）
+const (
+	ManifestName  = "bundle.yaml"
+	SignatureName = "bundle.yaml.sig"
+	PublicKeyName = "bundle.pub"
+
+	packagesPrefix = "packages/"
+)
+
+// Manifest 离线安装包清单
+type Manifest struct {
+	APIVersion string    `yaml:"apiVersion"`
+	Kind       string    `yaml:"kind"`
+	CreatedAt  time.Time `yaml:"createdAt"`
+	K8sVersion string    `yaml:"k8sVersion"`
+	Archs      []string  `yaml:"archs"`
+	Files      []File    `yaml:"files"`
+}
+
+// File 包内的一个文件
+type File struct {
+	Path   string `yaml:"path"` // 包内路径（packages/...）
+	Size   int64  `yaml:"size"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// TotalSize 返回包内文件的总大小
+func (m *Manifest) TotalSize() int64 {
+	var total int64
+	for _, f := range m.Files {
+		total += f.Size
+	}
+	return total
+}
+
+// hashWriter 写入时计算 sha256 和大小
+type hashWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func newHashWriter(w io.Writer) *hashWriter {
+	return &hashWriter{w: w, hash: sha256.New()}
+}
+
+func (h *hashWriter) Write(p []byte) (int, error) {
+	n, err := h.w.Write(p)
+	h.hash.Write(p[:n])
+	h.size += int64(n)
+	return n, err
+}
+
+func (h *hashWriter) sum() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// addFile 将本地文件写入 tar 包
+func addFile(tw *tar.Writer, name, src string) (File, error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return File{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return File{}, err
+	}
+	header := &tar.Header{
+		Name:     name,
+		Mode:     int64(info.Mode().Perm()),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return File{}, err
+	}
+
+	hw := newHashWriter(tw)
+	if _, err := io.Copy(hw, file); err != nil {
+		return File{}, fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	return File{Path: name, Size: hw.size, SHA256: hw.sum()}, nil
+}
+
+// addBytes 将内存中的内容写入 tar 包
+func addBytes(tw *tar.Writer, name string, data []byte, mode int64) (File, error) {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     mode,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return File{}, err
+	}
+	hw := newHashWriter(tw)
+	if _, err := hw.Write(data); err != nil {
+		return File{}, err
+	}
+	return File{Path: name, Size: hw.size, SHA256: hw.sum()}, nil
+}
+
+// cleanEntryName 检查 tar 包中的路径，拒绝绝对路径和 ".."
+func cleanEntryName(name string) (string, error) {
+	cleaned := path.Clean(name)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("离线安装包中包含非法路径: %s", name)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,284 @@
+package bundle
+
+import (
+	"archive/tar"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/yaml.v3"
+	"stormdragon/k8s-deployer/pkg/binary"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// CreateOptions 创建离线安装包的参数
+type CreateOptions struct {
+	PackageDir     string   // packages 目录
+	K8sVersion     string   // Kubernetes 版本
+	Archs          []string // 节点架构（为空时为 amd64）
+	Output         string   // 输出文件（.tar.zst）
+	KeyPath        string   // 签名私钥，不存在时自动生成
+	BinaryCacheDir string   // 二进制缓存目录（packages 目录中缺少 Kubernetes 组件和 containerd 时从这里获取）
+}
+
+// entry 需要打包的文件
+type entry struct {
+	name     string             // 包内路径
+	src      string             // 本地文件
+	artifact *packages.Artifact // 对应的清单条目（镜像、GPU 包为 nil）
+}
+
+// Create 创建离线安装包
+// 包含当前 Kubernetes 版本使用的离线包、镜像 tar 包（packages/images）、GPU 包（packages/gpu）和系统软件包离线源（packages/system）
+func Create(opts CreateOptions) (*Manifest, error) {
+	pkgMgr, err := packages.NewManagerForDir(opts.PackageDir, opts.K8sVersion)
+	if err != nil {
+		return nil, err
+	}
+	archs := opts.Archs
+	if len(archs) == 0 {
+		archs = []string{packages.DefaultArch}
+	}
+	for _, arch := range archs {
+		if pkgMgr.ForArch(arch).Artifact("kubeadm") == nil {
+			return nil, fmt.Errorf("离线包清单中没有 Kubernetes %s (%s)（cd scripts && ./download-all.sh --k8s-version %s --arch %s）",
+				opts.K8sVersion, arch, opts.K8sVersion, arch)
+		}
+	}
+
+	ui.SubStep("收集离线包...")
+	entries, err := collectEntries(pkgMgr, archs, opts.BinaryCacheDir)
+	if err != nil {
+		ui.SubStepFailed()
+		return nil, err
+	}
+	ui.SubStepDone()
+
+	key, created, err := LoadOrCreateKey(opts.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		ui.Info("已生成签名密钥: %s（公钥 %s.pub）", opts.KeyPath, opts.KeyPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.Output), 0755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	tmpFile := opts.Output + ".tmp"
+	out, err := os.Create(tmpFile)
+	if err != nil {
+		return nil, fmt.Errorf("创建离线安装包失败: %w", err)
+	}
+	defer os.Remove(tmpFile)
+	defer out.Close()
+
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(zw)
+
+	manifest := &Manifest{
+		APIVersion: "k8s-deployer/v1",
+		Kind:       "Bundle",
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		K8sVersion: opts.K8sVersion,
+		Archs:      archs,
+	}
+	pkgManifest := &packages.Manifest{APIVersion: pkgMgr.Manifest.APIVersion, Kind: pkgMgr.Manifest.Kind}
+
+	for _, e := range entries {
+		ui.Info("  + %s", e.name)
+		file, err := addFile(tw, e.name, e.src)
+		if err != nil {
+			return nil, err
+		}
+		if e.artifact != nil {
+			if e.artifact.SHA256 != "" && !strings.EqualFold(e.artifact.SHA256, file.SHA256) {
+				return nil, fmt.Errorf("%s 的 sha256 与离线包清单不一致（期望 %s，实际 %s）", e.src, e.artifact.SHA256, file.SHA256)
+			}
+			a := *e.artifact
+			a.SHA256 = file.SHA256
+			pkgManifest.Artifacts = append(pkgManifest.Artifacts, a)
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	// 包内的离线包清单只列出打包的文件，并记录 sha256
+	pkgManifestData, err := yaml.Marshal(pkgManifest)
+	if err != nil {
+		return nil, err
+	}
+	file, err := addBytes(tw, packagesPrefix+packages.ManifestFile, pkgManifestData, 0644)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Files = append(manifest.Files, file)
+
+	// 包清单、签名和公钥
+	manifestData, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	pubPEM, err := encodePublicKey(key.Public().(ed25519.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{ManifestName, manifestData},
+		{SignatureName, sign(key, manifestData)},
+		{PublicKeyName, pubPEM},
+	} {
+		if _, err := addBytes(tw, f.name, f.data, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("写入离线安装包失败: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("写入离线安装包失败: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("写入离线安装包失败: %w", err)
+	}
+	if err := os.Rename(tmpFile, opts.Output); err != nil {
+		return nil, fmt.Errorf("保存离线安装包失败: %w", err)
+	}
+	return manifest, nil
+}
+
+// collectEntries 收集需要打包的文件（与架构无关的文件只打包一次）
+func collectEntries(pkgMgr *packages.Manager, archs []string, binaryCacheDir string) ([]entry, error) {
+	var entries []entry
+	var images []packages.Image
+
+	var artifacts []packages.Artifact
+	seenPaths := make(map[string]bool)
+	for _, arch := range archs {
+		for _, a := range pkgMgr.ForArch(arch).Artifacts() {
+			if !seenPaths[a.Path] {
+				seenPaths[a.Path] = true
+				artifacts = append(artifacts, a)
+			}
+		}
+	}
+	seenImages := make(map[string]bool)
+	for i := range artifacts {
+		a := &artifacts[i]
+		for _, image := range a.Images {
+			if !seenImages[image.Ref()] {
+				seenImages[image.Ref()] = true
+				images = append(images, image)
+			}
+		}
+
+		src := filepath.Join(pkgMgr.PackageDir, a.Path)
+		if _, err := os.Stat(src); err != nil {
+			cached, err := cachedBinary(a, binaryCacheDir)
+			switch {
+			case err != nil:
+				return nil, err
+			case cached != "":
+				src = cached
+			case a.Optional:
+				ui.Warning("可选的离线包 %s 不存在，跳过", a.Path)
+				continue
+			default:
+				return nil, fmt.Errorf("缺少离线包 %s（%s）", a.Name, a.Path)
+			}
+		}
+		entries = append(entries, entry{name: packagesPrefix + filepath.ToSlash(a.Path), src: src, artifact: a})
+	}
+
+	// 镜像 tar 包（镜像已经同步到镜像仓库时可以没有）
+	var missingImages []string
+	for _, image := range images {
+		src := filepath.Join(pkgMgr.PackageDir, image.ArchivePath())
+		if _, err := os.Stat(src); err != nil {
+			missingImages = append(missingImages, image.Ref())
+			continue
+		}
+		entries = append(entries, entry{name: packagesPrefix + filepath.ToSlash(image.ArchivePath()), src: src})
+	}
+	if len(missingImages) > 0 {
+		ui.Warning("%d 个镜像没有 tar 包（%s），离线环境需要提前同步到镜像仓库: %s",
+			len(missingImages), filepath.Join(pkgMgr.PackageDir, packages.ImagesDir), strings.Join(missingImages, ", "))
+	}
+
+	// GPU 包和系统软件包离线源（清单中已列出的文件不重复打包）
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		seen[e.name] = true
+	}
+	for _, dir := range []string{"gpu", packages.SystemDir} {
+		root := filepath.Join(pkgMgr.PackageDir, dir)
+		if _, err := os.Stat(root); err != nil {
+			continue
+		}
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(pkgMgr.PackageDir, p)
+			if err != nil {
+				return err
+			}
+			if name := packagesPrefix + filepath.ToSlash(rel); !seen[name] {
+				entries = append(entries, entry{name: name, src: p})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", root, err)
+		}
+	}
+	return entries, nil
+}
+
+// cachedBinary 从二进制缓存获取 packages 目录中缺少的 Kubernetes 组件和 containerd（缓存中没有时下载）
+// 其他组件返回空字符串
+func cachedBinary(a *packages.Artifact, cacheDir string) (string, error) {
+	if cacheDir == "" {
+		return "", nil
+	}
+	arch := a.Arch
+	if arch == "" {
+		arch = packages.DefaultArch
+	}
+
+	var info binary.BinaryInfo
+	switch a.Name {
+	case "kubectl", "kubeadm", "kubelet":
+		for _, b := range binary.GetKubernetesVersion(a.Version, arch) {
+			if b.Name == a.Name {
+				info = b
+			}
+		}
+	case "containerd":
+		info = binary.GetContainerdInfo(a.Version, arch)
+	default:
+		return "", nil
+	}
+	info.SHA256 = a.SHA256
+
+	binMgr, err := binary.NewManager(cacheDir)
+	if err != nil {
+		return "", err
+	}
+	cachePath, err := binMgr.GetBinaryPath(info)
+	if err != nil {
+		return "", fmt.Errorf("获取 %s %s 失败: %w", a.Name, a.Version, err)
+	}
+	return cachePath, nil
+}
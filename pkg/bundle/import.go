@@ -0,0 +1,257 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/yaml.v3"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// 包清单、签名和公钥的最大大小
+const maxMetadataSize = 16 << 20
+
+// ImportOptions 导入离线安装包的参数
+type ImportOptions struct {
+	Path          string // 离线安装包
+	PackageDir    string // 导入到的 packages 目录
+	PublicKeyPath string // 签名公钥
+
+	// TrustEmbeddedKey 未指定 PublicKeyPath 时使用包内的公钥校验签名
+	// 篡改离线安装包的人可以用自己的密钥重新签名，这种情况下只能校验完整性，需要调用方明确选择
+	TrustEmbeddedKey bool
+}
+
+// Import 解压离线安装包到 packages 目录
+// 先解压到 packages 同级的临时目录，签名和所有文件的 sha256 校验通过后再移动到 packages 目录
+func Import(opts ImportOptions) (*Manifest, error) {
+	if opts.PublicKeyPath == "" && !opts.TrustEmbeddedKey {
+		return nil, errors.New("未指定签名公钥，无法确认离线安装包的签名者（使用 --public-key 指定公钥；确认来源可信时可以使用 --trust-embedded-key 信任包内的公钥）")
+	}
+
+	packageDir, err := filepath.Abs(opts.PackageDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(packageDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建 packages 目录失败: %w", err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(packageDir), ".bundle-import-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	ui.SubStep("解压离线安装包...")
+	files, metadata, err := extract(opts.Path, staging)
+	if err != nil {
+		ui.SubStepFailed()
+		return nil, err
+	}
+	ui.SubStepDone()
+
+	ui.SubStep("校验签名和文件...")
+	manifest, err := verifyBundle(files, metadata, opts.PublicKeyPath)
+	if err != nil {
+		ui.SubStepFailed()
+		return nil, err
+	}
+	ui.SubStepDone()
+	if opts.PublicKeyPath == "" {
+		ui.Warning("使用包内的公钥校验签名（--trust-embedded-key），只校验了离线安装包的完整性，没有校验签名者")
+	}
+
+	ui.SubStep("安装到 %s...", packageDir)
+	if err := install(manifest, staging, packageDir); err != nil {
+		ui.SubStepFailed()
+		return nil, err
+	}
+	ui.SubStepDone()
+	return manifest, nil
+}
+
+// extract 解压离线安装包，packages/ 下的文件写入 staging，返回实际的文件列表和包清单等元数据
+func extract(bundlePath, staging string) (map[string]File, map[string][]byte, error) {
+	in, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开离线安装包失败: %w", err)
+	}
+	defer in.Close()
+
+	zr, err := zstd.NewReader(in)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取离线安装包失败: %w", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]File)
+	metadata := make(map[string][]byte)
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取离线安装包失败: %w", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, nil, fmt.Errorf("离线安装包中包含不支持的文件类型: %s", header.Name)
+		}
+		name, err := cleanEntryName(header.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case name == ManifestName || name == SignatureName || name == PublicKeyName:
+			data, err := io.ReadAll(io.LimitReader(tr, maxMetadataSize))
+			if err != nil {
+				return nil, nil, fmt.Errorf("读取 %s 失败: %w", name, err)
+			}
+			metadata[name] = data
+		case strings.HasPrefix(name, packagesPrefix):
+			file, err := extractFile(tr, name, filepath.Join(staging, filepath.FromSlash(name)), os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return nil, nil, err
+			}
+			files[name] = file
+		default:
+			return nil, nil, fmt.Errorf("离线安装包中包含未知文件: %s", name)
+		}
+	}
+	return files, metadata, nil
+}
+
+// extractFile 解压单个文件并计算 sha256
+func extractFile(r io.Reader, name, dest string, mode os.FileMode) (File, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return File{}, err
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode|0600)
+	if err != nil {
+		return File{}, err
+	}
+	defer out.Close()
+
+	hw := newHashWriter(out)
+	if _, err := io.Copy(hw, r); err != nil {
+		return File{}, fmt.Errorf("解压 %s 失败: %w", name, err)
+	}
+	return File{Path: name, Size: hw.size, SHA256: hw.sum()}, out.Close()
+}
+
+// verifyBundle 校验包清单的签名，以及实际文件与包清单是否完全一致
+func verifyBundle(files map[string]File, metadata map[string][]byte, publicKeyPath string) (*Manifest, error) {
+	manifestData, signature := metadata[ManifestName], metadata[SignatureName]
+	if manifestData == nil || signature == nil {
+		return nil, errors.New("不是有效的离线安装包：缺少包清单或签名")
+	}
+
+	var pub ed25519.PublicKey
+	var err error
+	if publicKeyPath != "" {
+		pub, err = LoadPublicKey(publicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		if embedded := metadata[PublicKeyName]; embedded != nil {
+			if embeddedPub, err := parsePublicKey(embedded); err == nil && !bytes.Equal(embeddedPub, pub) {
+				return nil, errors.New("离线安装包不是由指定的公钥对应的密钥签名")
+			}
+		}
+	} else {
+		if metadata[PublicKeyName] == nil {
+			return nil, errors.New("离线安装包中没有签名公钥，请使用 --public-key 指定")
+		}
+		if pub, err = parsePublicKey(metadata[PublicKeyName]); err != nil {
+			return nil, fmt.Errorf("解析离线安装包中的公钥失败: %w", err)
+		}
+	}
+	if err := verify(pub, manifestData, signature); err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("解析包清单失败: %w", err)
+	}
+
+	listed := make(map[string]bool)
+	for _, f := range manifest.Files {
+		listed[f.Path] = true
+		actual, ok := files[f.Path]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("离线安装包缺少文件: %s", f.Path)
+		case actual.Size != f.Size || !strings.EqualFold(actual.SHA256, f.SHA256):
+			return nil, fmt.Errorf("文件 %s 的 sha256 与包清单不一致", f.Path)
+		}
+	}
+	for name := range files {
+		if !listed[name] {
+			return nil, fmt.Errorf("离线安装包中包含包清单以外的文件: %s", name)
+		}
+	}
+	return &manifest, nil
+}
+
+// install 将校验通过的文件移动到 packages 目录
+// 包内的离线包清单合并到已有的 manifest.yaml（同名、同版本、同架构的条目以包内为准），原文件备份为 manifest.yaml.bak
+func install(manifest *Manifest, staging, packageDir string) error {
+	manifestPath := filepath.Join(packageDir, packages.ManifestFile)
+	if _, err := os.Stat(manifestPath); err == nil {
+		if err := mergePackageManifest(manifestPath, filepath.Join(staging, filepath.FromSlash(packagesPrefix+packages.ManifestFile))); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range manifest.Files {
+		rel := filepath.FromSlash(strings.TrimPrefix(f.Path, packagesPrefix))
+		dest := filepath.Join(packageDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(staging, filepath.FromSlash(f.Path)), dest); err != nil {
+			return fmt.Errorf("安装 %s 失败: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// mergePackageManifest 将已有的离线包清单与包内的清单合并，结果写入 staged（随后和其他文件一起移动到 packages 目录）
+func mergePackageManifest(existingPath, staged string) error {
+	existing, err := packages.LoadManifest(existingPath)
+	if err != nil {
+		return fmt.Errorf("已有的离线包清单无法合并: %w", err)
+	}
+	bundled, err := packages.LoadManifest(staged)
+	if err != nil {
+		return err
+	}
+	existing.Merge(bundled)
+	if err := existing.Save(staged); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(existingPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(existingPath+".bak", data, 0644); err != nil {
+		return fmt.Errorf("备份离线包清单失败: %w", err)
+	}
+	return nil
+}
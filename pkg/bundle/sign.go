@@ -0,0 +1,134 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"stormdragon/k8s-deployer/pkg/config"
+)
+
+// DefaultKeyPath 返回默认的签名私钥路径（~/.k8s-deployer/bundle/signing.key）
+// 公钥保存在同目录的 signing.key.pub
+func DefaultKeyPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "bundle", "signing.key"), nil
+}
+
+// LoadOrCreateKey 读取签名私钥，不存在时生成新的密钥对（created 为 true）
+func LoadOrCreateKey(path string) (key ed25519.PrivateKey, created bool, err error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("解析签名私钥 %s 失败: %w", path, err)
+		}
+		return key, false, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("读取签名私钥失败: %w", err)
+	}
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false, fmt.Errorf("创建密钥目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, false, fmt.Errorf("保存签名私钥失败: %w", err)
+	}
+	pubPEM, err := encodePublicKey(pub)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.WriteFile(path+".pub", pubPEM, 0644); err != nil {
+		return nil, false, fmt.Errorf("保存签名公钥失败: %w", err)
+	}
+	return key, true, nil
+}
+
+// LoadPublicKey 读取签名公钥（PEM）
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取签名公钥失败: %w", err)
+	}
+	pub, err := parsePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析签名公钥 %s 失败: %w", path, err)
+	}
+	return pub, nil
+}
+
+func parsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("不是 PEM 格式")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("不是 ed25519 私钥")
+	}
+	return edKey, nil
+}
+
+func parsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("不是 PEM 格式")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("不是 ed25519 公钥")
+	}
+	return edKey, nil
+}
+
+func encodePublicKey(pub ed25519.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// sign 对包清单签名，返回 base64 编码的签名
+func sign(key ed25519.PrivateKey, manifest []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)) + "\n")
+}
+
+// verify 校验包清单的签名
+func verify(pub ed25519.PublicKey, manifest, signature []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("签名格式不正确: %w", err)
+	}
+	if !ed25519.Verify(pub, manifest, sig) {
+		return errors.New("包清单签名校验失败，离线安装包可能被篡改或不是由指定的密钥签名")
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"stormdragon/k8s-deployer/pkg/binary"
 	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/packages"
 	"stormdragon/k8s-deployer/pkg/ui"
 )
 
@@ -22,6 +23,7 @@ var binaryDownloadCmd = &cobra.Command{
 	Long:  `预下载 Kubernetes、containerd、Helm 等二进制文件到本地缓存`,
 	Run: func(cmd *cobra.Command, args []string) {
 		k8sVersion, _ := cmd.Flags().GetString("k8s-version")
+		archs, _ := cmd.Flags().GetStringSlice("arch")
 		
 		// 获取配置目录
 		configDir, err := config.GetConfigDir()
@@ -38,7 +40,7 @@ var binaryDownloadCmd = &cobra.Command{
 		}
 		
 		// 下载所有文件
-		if err := binary.PreDownloadAll(manager, k8sVersion); err != nil {
+		if err := binary.PreDownloadAll(manager, k8sVersion, archs); err != nil {
 			ui.Error("下载失败: %v", err)
 			return
 		}
@@ -129,5 +131,6 @@ func init() {
 	
 	// binary download 的 flags
 	binaryDownloadCmd.Flags().String("k8s-version", "v1.34.2", "Kubernetes 版本")
+	binaryDownloadCmd.Flags().StringSlice("arch", []string{packages.DefaultArch}, "架构（amd64、arm64，多个用逗号分隔）")
 }
 
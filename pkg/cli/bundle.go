@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stormdragon/k8s-deployer/pkg/bundle"
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+var (
+	bundleDir              string
+	bundleK8sVersion       string
+	bundleArchs            []string
+	bundleOutput           string
+	bundleSignKey          string
+	bundlePublicKey        string
+	bundleTrustEmbeddedKey bool
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "创建和导入离线安装包",
+	Long: `将离线包、Helm chart、GPU 包、系统软件包和镜像 tar 包打成一个离线安装包（.tar.zst），
+在离线环境中导入到 packages 目录`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "创建离线安装包",
+	Long: `将 packages 目录中指定 Kubernetes 版本和架构（--arch）使用的文件打包为 zstd 压缩的 tar 包
+
+包含：
+  - 离线包清单（packages/manifest.yaml）中的二进制文件和 Helm chart
+    （packages 目录中缺少 Kubernetes 组件和 containerd 时从二进制缓存获取，缓存中没有时下载）
+  - 镜像 tar 包：packages/images/<名称>_<tag>.tar（docker-archive 格式，可选）
+  - GPU 包：packages/gpu/ 下的所有文件（可选）
+  - 系统软件包离线源：packages/system/ 下的所有文件（可选）
+
+包内的 bundle.yaml 记录所有文件的 sha256，并使用 ed25519 私钥签名。
+私钥默认为 ~/.k8s-deployer/bundle/signing.key，不存在时自动生成，公钥为同目录的 signing.key.pub，
+导入时使用 --public-key 指定公钥校验签名者。`,
+	Example: `  k8s-deployer bundle create --k8s-version v1.34.2 -o k8s-bundle-v1.34.2.tar.zst
+
+  # amd64 + arm64 混合架构集群
+  k8s-deployer bundle create --k8s-version v1.34.2 --arch amd64,arm64`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configDir, err := config.GetConfigDir()
+		if err != nil {
+			return fmt.Errorf("获取配置目录失败: %w", err)
+		}
+		keyPath := bundleSignKey
+		if keyPath == "" {
+			if keyPath, err = bundle.DefaultKeyPath(); err != nil {
+				return err
+			}
+		}
+		output := bundleOutput
+		if output == "" {
+			output = fmt.Sprintf("k8s-bundle-%s.tar.zst", bundleK8sVersion)
+		}
+
+		ui.Header("创建离线安装包: " + bundleK8sVersion)
+		manifest, err := bundle.Create(bundle.CreateOptions{
+			PackageDir:     bundleDir,
+			K8sVersion:     bundleK8sVersion,
+			Archs:          bundleArchs,
+			Output:         output,
+			KeyPath:        keyPath,
+			BinaryCacheDir: filepath.Join(configDir, "binaries"),
+		})
+		if err != nil {
+			return err
+		}
+
+		ui.Success("离线安装包已保存到 %s（%d 个文件，%.1f MB）", output, len(manifest.Files), float64(manifest.TotalSize())/1024/1024)
+		ui.Info("签名公钥: %s.pub", keyPath)
+		ui.Info("导入: k8s-deployer bundle import %s --public-key signing.key.pub", filepath.Base(output))
+		return nil
+	},
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:   "import <bundle.tar.zst>",
+	Short: "导入离线安装包",
+	Long: `校验离线安装包的签名和所有文件的 sha256，然后解压到 packages 目录
+
+文件先解压到临时目录，校验全部通过后才会写入 packages 目录；
+包内的条目合并到已有的 packages/manifest.yaml（原文件备份为 manifest.yaml.bak）。
+必须使用 --public-key 指定签名公钥；包内的公钥可以被篡改者替换，
+只有明确指定 --trust-embedded-key 时才使用包内的公钥（只能校验完整性，不能确认签名者）。`,
+	Example: `  k8s-deployer bundle import k8s-bundle-v1.34.2.tar.zst --public-key signing.key.pub`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ui.Header("导入离线安装包")
+		manifest, err := bundle.Import(bundle.ImportOptions{
+			Path:             args[0],
+			PackageDir:       bundleDir,
+			PublicKeyPath:    bundlePublicKey,
+			TrustEmbeddedKey: bundleTrustEmbeddedKey,
+		})
+		if err != nil {
+			return err
+		}
+
+		ui.Success("已导入 Kubernetes %s（%s）的离线包: %d 个文件，创建于 %s",
+			manifest.K8sVersion, strings.Join(manifest.Archs, ", "), len(manifest.Files), manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCmd.AddCommand(bundleImportCmd)
+
+	bundleCmd.PersistentFlags().StringVar(&bundleDir, "dir", "packages", "离线包目录")
+
+	bundleCreateCmd.Flags().StringVar(&bundleK8sVersion, "k8s-version", "v1.34.2", "Kubernetes 版本")
+	bundleCreateCmd.Flags().StringSliceVar(&bundleArchs, "arch", []string{packages.DefaultArch}, "节点架构（amd64、arm64，混合架构集群用逗号分隔）")
+	bundleCreateCmd.Flags().StringVarP(&bundleOutput, "output-file", "o", "", "输出文件（默认 k8s-bundle-<版本>.tar.zst）")
+	bundleCreateCmd.Flags().StringVar(&bundleSignKey, "sign-key", "", "签名私钥（默认 ~/.k8s-deployer/bundle/signing.key）")
+
+	bundleImportCmd.Flags().StringVar(&bundlePublicKey, "public-key", "", "签名公钥（PEM）")
+	bundleImportCmd.Flags().BoolVar(&bundleTrustEmbeddedKey, "trust-embedded-key", false, "未指定 --public-key 时信任包内的公钥（只校验完整性，不校验签名者）")
+}
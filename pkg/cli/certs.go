@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"stormdragon/k8s-deployer/pkg/cluster"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+var certsSkipKubelet bool
+
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "检查和续期集群证书",
+	Long: `检查和续期集群证书
+
+证书有效期由配置文件中的 spec.certificates 控制：
+  caValidity   CA 证书有效期（只在创建集群时生效）
+  validity     其他证书有效期（创建集群和 certs renew 时生效）`,
+}
+
+var certsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "查看所有节点的证书到期时间",
+	Long: `并发读取所有节点的证书到期时间：
+
+  - Master: /etc/kubernetes/pki 下的证书、etcd 证书，
+    admin.conf / controller-manager.conf / scheduler.conf 中的客户端证书
+  - 所有节点: kubelet 客户端证书和服务端证书
+
+有证书已过期时命令返回失败，30 天内过期的证书会给出警告。`,
+	Example: `  k8s-deployer certs check -f cluster.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadClusterConfig()
+		if err != nil {
+			return err
+		}
+
+		ui.Header("证书到期时间: " + cfg.Metadata.Name)
+		if _, err := cluster.CheckCertificates(cfg); err != nil {
+			ui.Error("%v", err)
+			return err
+		}
+		return nil
+	},
+}
+
+var certsRenewCmd = &cobra.Command{
+	Use:   "renew",
+	Short: "续期集群证书",
+	Long: `续期集群证书
+
+续期流程：
+  1. 所有 Master 执行 kubeadm certs renew all（有效期使用 spec.certificates.validity）
+  2. 逐个 Master 重启 etcd、kube-apiserver、controller-manager、scheduler
+  3. 所有节点重新签发 kubelet 客户端证书并重启 kubelet（--skip-kubelet 跳过）
+  4. 更新本地 ~/.kube/config（仅当其指向本集群时）
+
+CA 证书不会续期。`,
+	Example: `  # 续期所有证书
+  k8s-deployer certs renew -f cluster.yaml
+
+  # 只续期控制平面证书
+  k8s-deployer certs renew -f cluster.yaml --skip-kubelet -y`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadClusterConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cluster.RenewCertificates(cfg, cluster.CertRenewOptions{
+			AutoConfirm: autoConfirm,
+			SkipKubelet: certsSkipKubelet,
+		}); err != nil {
+			ui.Error("证书续期失败: %v", err)
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(certsCmd)
+	certsCmd.AddCommand(certsCheckCmd)
+	certsCmd.AddCommand(certsRenewCmd)
+
+	// certs check 的 flags
+	certsCheckCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
+	certsCheckCmd.MarkFlagRequired("config")
+
+	// certs renew 的 flags
+	certsRenewCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
+	certsRenewCmd.Flags().BoolVar(&certsSkipKubelet, "skip-kubelet", false, "不刷新 kubelet 客户端证书")
+	certsRenewCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "自动确认所有提示")
+	certsRenewCmd.MarkFlagRequired("config")
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"stormdragon/k8s-deployer/pkg/cluster"
@@ -15,6 +16,13 @@ var (
 	forceSSHSetup   bool
 	autoConfirm     bool
 	updateOnlyBGP   bool
+	resumeDeploy    bool
+	fromPhase       string
+	dryRun          bool
+	skipPreflight   bool
+	planFile        string
+
+	removeContainerdData bool
 )
 
 var clusterCmd = &cobra.Command{
@@ -29,6 +37,7 @@ var clusterCreateCmd = &cobra.Command{
 	Long: `根据配置文件创建一个新的 Kubernetes 集群
 
 部署流程：
+  0. 部署前预检（系统、资源、端口、时间同步、镜像仓库、VIP / 外部负载均衡器、离线包）
   1. 检查配置文件
   2. 自动配置 SSH 密钥（root 用户免密登录）
   3. 配置集群 Hosts 文件（节点互通）
@@ -38,7 +47,13 @@ var clusterCreateCmd = &cobra.Command{
   7. 安装网络插件（Cilium）
   8. 加入 Worker 节点
   9. 配置 GPU 节点（如果有）
-  10. 验证集群状态`,
+  10. 验证集群状态
+
+部署进度记录在 ~/.k8s-deployer/clusters/<name>/state.json，
+部署中断后可使用 --resume 从第一个未完成的阶段继续。
+
+使用 --dry-run 可以在不连接任何主机的情况下，按节点输出将要执行的命令、
+上传的文件和渲染的配置（kubeadm、containerd、Cilium、keepalived/HAProxy）。`,
 	Example: `  # 创建集群（推荐）
   k8s-deployer cluster create -f cluster.yaml
 
@@ -46,7 +61,16 @@ var clusterCreateCmd = &cobra.Command{
   k8s-deployer cluster create -f cluster.yaml --skip-ssh-setup
 
   # 强制重新配置 SSH 密钥
-  k8s-deployer cluster create -f cluster.yaml --force-ssh-setup`,
+  k8s-deployer cluster create -f cluster.yaml --force-ssh-setup
+
+  # 从上次中断的阶段继续部署
+  k8s-deployer cluster create -f cluster.yaml --resume
+
+  # 重新执行 Cilium 安装阶段，然后继续后续未完成的阶段（部署已完成时只执行该阶段）
+  k8s-deployer cluster create -f cluster.yaml --from-phase cilium
+
+  # 查看执行计划，并保存为 JSON
+  k8s-deployer cluster create -f cluster.yaml --dry-run --plan-file plan.json`,
 	RunE: runClusterCreate,
 }
 
@@ -55,9 +79,8 @@ func runClusterCreate(cmd *cobra.Command, args []string) error {
 	
 	// 步骤 1: 加载配置
 	ui.Info("加载配置文件: %s", configFile)
-	cfg, err := config.LoadConfig(configFile)
+	cfg, err := loadClusterConfig()
 	if err != nil {
-		ui.Error("加载配置文件失败: %v", err)
 		return err
 	}
 	
@@ -74,56 +97,24 @@ func runClusterCreate(cmd *cobra.Command, args []string) error {
 	ui.Info("  - Kubernetes 版本: %s", cfg.Spec.Version)
 	ui.Info("")
 	
-	// 步骤 2: SSH 密钥配置（自动执行）
-	if !skipSSHSetup {
-		needsSetup, _ := checkSSHSetup(cfg)
-		
-		if needsSetup || forceSSHSetup {
-			ui.Header("配置 SSH 密钥认证")
-			ui.Info("检测到使用密码认证，自动配置 root 用户密钥登录...")
-			ui.Info("")
-			
-			// 直接执行 SSH 密钥配置
-			if err := cluster.SetupSSHKeys(cfg, forceSSHSetup); err != nil {
-				ui.Error("SSH 密钥配置失败: %v", err)
-				ui.Warn("您可以：")
-				ui.Warn("  1. 使用 --skip-ssh-setup 跳过此步骤")
-				ui.Warn("  2. 检查节点密码是否正确")
-				ui.Warn("  3. 手动配置 SSH 密钥后重试")
-				return err
-			}
-			
-			ui.Success("SSH 密钥配置完成！")
-			ui.Info("后续操作将使用 root 用户免密执行")
-			ui.Info("")
-			
-			// 更新内存中的配置，使用 root + 密钥
-			updateConfigToUseKeys(cfg)
-		} else {
-			ui.Info("SSH 密钥已配置，跳过")
-		}
-	}
-	
-	ui.Info("")
-	
-	// 步骤 3: 配置 Hosts 文件（节点互通）
-	ui.Header("配置集群 Hosts 文件")
-	ui.Info("Kubernetes 节点需要通过主机名互相解析...")
-	ui.Info("")
-	
-	if err := cluster.SetupHostsFile(cfg); err != nil {
-		ui.Error("配置 Hosts 文件失败: %v", err)
-		ui.Warn("您可以手动配置 /etc/hosts 后重试")
-		return err
+	// 步骤 2: 开始部署集群
+	opts := cluster.DeployOptions{
+		AutoConfirm:   autoConfirm,
+		SkipSSHSetup:  skipSSHSetup,
+		ForceSSHSetup: forceSSHSetup,
+		Resume:        resumeDeploy,
+		FromPhase:     fromPhase,
+		SkipPreflight: skipPreflight,
+		DryRun:        dryRun,
+		PlanFile:      planFile,
 	}
-	
-	ui.Info("")
-	
-	// 步骤 4: 开始部署集群
-	if err := cluster.DeployCluster(cfg, autoConfirm); err != nil {
+	if err := cluster.DeployCluster(cfg, opts); err != nil {
 		ui.Error("集群部署失败: %v", err)
 		return err
 	}
+	if dryRun {
+		return nil
+	}
 	
 	ui.Header("✓ 集群部署完成！")
 	ui.Info("")
@@ -136,6 +127,21 @@ func runClusterCreate(cmd *cobra.Command, args []string) error {
 }
 
 // 辅助函数
+
+// loadClusterConfig 加载集群配置并设置 SSH
+func loadClusterConfig() (*config.ClusterConfig, error) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		ui.Error("加载配置文件失败: %v", err)
+		return nil, err
+	}
+	if err := cluster.ConfigureSSH(cfg); err != nil {
+		ui.Error("%v", err)
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func countMasterNodes(cfg *config.ClusterConfig) int {
 	count := 0
 	for _, node := range cfg.Spec.Nodes {
@@ -166,30 +172,14 @@ func countGPUNodes(cfg *config.ClusterConfig) int {
 	return count
 }
 
-func checkSSHSetup(cfg *config.ClusterConfig) (needsSetup bool, usingPassword bool) {
-	for _, node := range cfg.Spec.Nodes {
-		if node.SSH.Password != "" {
-			return true, true
-		}
-	}
-	return false, false
-}
-
-func updateConfigToUseKeys(cfg *config.ClusterConfig) {
-	keyFile := "/root/.ssh/id_rsa"
-	for i := range cfg.Spec.Nodes {
-		if cfg.Spec.Nodes[i].SSH.Password != "" {
-			cfg.Spec.Nodes[i].SSH.User = "root"
-			cfg.Spec.Nodes[i].SSH.KeyFile = keyFile
-			cfg.Spec.Nodes[i].SSH.Password = "" // 清除密码
-		}
-	}
-}
-
 var clusterUpdateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "更新已部署的集群配置",
-	Long:  `更新集群配置，支持增量更新（如添加 BGP、修改 Harbor 认证等）`,
+	Long: `更新集群配置，支持增量更新（如添加 BGP、修改 Harbor 认证等）
+
+与集群保存的配置相比，spec.nodes 中新增的节点会被准备并加入集群，
+删除的节点会被驱逐 Pod、kubeadm reset 后从集群删除，
+同时更新所有节点的 /etc/hosts 和 HAProxy 后端。执行前会显示变更计划。`,
 	Example: `  # 更新集群配置
   k8s-deployer cluster update -f cluster.yaml
 
@@ -204,10 +194,9 @@ var clusterUpdateCmd = &cobra.Command{
 func runClusterUpdate(cmd *cobra.Command, args []string) error {
 	ui.Header("更新集群配置")
 
-	// 加载新配置
-	newCfg, err := config.LoadConfig(configFile)
+	// 加载新配置（新增和删除节点需要 SSH 连接）
+	newCfg, err := loadClusterConfig()
 	if err != nil {
-		ui.Error("加载配置失败: %v", err)
 		return fmt.Errorf("加载配置失败: %w", err)
 	}
 
@@ -215,16 +204,128 @@ func runClusterUpdate(cmd *cobra.Command, args []string) error {
 	return cluster.UpdateCluster(newCfg, updateOnlyBGP, autoConfirm)
 }
 
+var clusterUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "滚动升级集群 Kubernetes 版本",
+	Long: `将集群升级到配置文件中 spec.version 指定的 Kubernetes 版本
+
+升级流程：
+  1. 第一个 Master 节点执行 kubeadm upgrade apply
+  2. 其他 Master 节点执行 kubeadm upgrade node
+  3. Worker 节点逐个 cordon → drain → 升级 → uncordon
+  4. 更新 k8s-deployer-config 中保存的集群配置
+
+kubeadm 只支持升级到下一个 minor 版本，跨多个版本需要分多次升级。`,
+	Example: `  # 修改 spec.version 后执行升级
+  k8s-deployer cluster upgrade -f cluster.yaml
+
+  # 自动确认
+  k8s-deployer cluster upgrade -f cluster.yaml -y`,
+	RunE: runClusterUpgrade,
+}
+
+func runClusterUpgrade(cmd *cobra.Command, args []string) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := cluster.UpgradeCluster(cfg, autoConfirm); err != nil {
+		ui.Error("集群升级失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+var clusterDestroyCmd = &cobra.Command{
+	Use:     "destroy",
+	Aliases: []string{"reset"},
+	Short:   "销毁集群并清理所有节点",
+	Long: `销毁集群，清理所有节点上的 Kubernetes 数据
+
+清理内容：
+  1. 所有节点并发执行 kubeadm reset
+  2. 清理 CNI 配置、Cilium 网络接口和 BPF 状态
+  3. 停止 keepalived / HAProxy
+  4. 删除 /etc/hosts 中由 k8s-deployer 管理的集群条目
+  5. 清理本地 kubeconfig（恢复部署前的备份）和部署进度
+  6. 可选：删除 containerd 数据（--remove-containerd-data）
+
+此操作不可恢复，执行前需要输入 yes 确认。`,
+	Example: `  # 销毁集群
+  k8s-deployer cluster destroy -f cluster.yaml
+
+  # 同时删除 containerd 中的镜像和容器数据
+  k8s-deployer cluster destroy -f cluster.yaml --remove-containerd-data`,
+	RunE: runClusterDestroy,
+}
+
+var clusterPreflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "部署前检查所有节点",
+	Long: `在所有节点上并发执行部署前检查：
+
+  - 操作系统和内核版本
+  - CPU / 内存、/var/lib 可用空间、swap
+  - Kubernetes 端口是否空闲（6443、2379-2380、10250 等）
+  - 主机名、product_uuid、MAC 地址唯一
+  - 时间同步
+  - 镜像仓库（imageRepository）是否可达
+  - VIP 是否已被占用（高可用模式）
+  - 外部负载均衡器是否将 TCP 6443 转发到第一个 Master（ha.mode: external）
+  - 本地离线包是否齐全
+
+cluster create 会在部署前自动执行预检。`,
+	Example: `  k8s-deployer cluster preflight -f cluster.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadClusterConfig()
+		if err != nil {
+			return err
+		}
+
+		ui.Header(fmt.Sprintf("部署前预检: %s", cfg.Metadata.Name))
+		if _, err := cluster.RunPreflight(cfg); err != nil {
+			ui.Error("%v", err)
+			return err
+		}
+		return nil
+	},
+}
+
+func runClusterDestroy(cmd *cobra.Command, args []string) error {
+	cfg, err := loadClusterConfig()
+	if err != nil {
+		return err
+	}
+
+	opts := cluster.DestroyOptions{
+		RemoveContainerdData: removeContainerdData,
+	}
+	if err := cluster.DestroyCluster(cfg, opts); err != nil {
+		ui.Error("销毁集群失败: %v", err)
+		return err
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(clusterCmd)
 	clusterCmd.AddCommand(clusterCreateCmd)
 	clusterCmd.AddCommand(clusterUpdateCmd)
+	clusterCmd.AddCommand(clusterUpgradeCmd)
+	clusterCmd.AddCommand(clusterDestroyCmd)
+	clusterCmd.AddCommand(clusterPreflightCmd)
 
 	// cluster create 的 flags
 	clusterCreateCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
 	clusterCreateCmd.Flags().BoolVar(&skipSSHSetup, "skip-ssh-setup", false, "跳过 SSH 密钥配置")
 	clusterCreateCmd.Flags().BoolVar(&forceSSHSetup, "force-ssh-setup", false, "强制重新配置 SSH 密钥")
 	clusterCreateCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "自动确认所有提示")
+	clusterCreateCmd.Flags().BoolVar(&resumeDeploy, "resume", false, "从上次中断的阶段继续部署")
+	clusterCreateCmd.Flags().BoolVar(&skipPreflight, "skip-preflight", false, "跳过部署前的预检")
+	clusterCreateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出执行计划（每个节点的命令、上传和配置文件），不修改任何主机")
+	clusterCreateCmd.Flags().StringVar(&planFile, "plan-file", "", "dry-run 时将执行计划保存为 JSON 文件")
+	clusterCreateCmd.Flags().StringVar(&fromPhase, "from-phase", "", fmt.Sprintf("强制重新执行指定阶段 (%s)", strings.Join(cluster.DeployPhaseNames(), ", ")))
 	clusterCreateCmd.MarkFlagRequired("config")
 
 	// cluster update 的 flags
@@ -232,5 +333,18 @@ func init() {
 	clusterUpdateCmd.Flags().BoolVar(&updateOnlyBGP, "only-bgp", false, "仅更新 BGP 配置")
 	clusterUpdateCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "自动确认所有提示")
 	clusterUpdateCmd.MarkFlagRequired("config")
-}
 
+	// cluster upgrade 的 flags
+	clusterUpgradeCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
+	clusterUpgradeCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "自动确认所有提示")
+	clusterUpgradeCmd.MarkFlagRequired("config")
+
+	// cluster destroy 的 flags
+	clusterDestroyCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
+	clusterDestroyCmd.Flags().BoolVar(&removeContainerdData, "remove-containerd-data", false, "同时删除 containerd 数据（镜像、容器、快照）")
+	clusterDestroyCmd.MarkFlagRequired("config")
+
+	// cluster preflight 的 flags
+	clusterPreflightCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
+	clusterPreflightCmd.MarkFlagRequired("config")
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"stormdragon/k8s-deployer/pkg/cluster"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+var (
+	etcdBackupNode   string
+	etcdBackupOutput string
+	etcdBackupKeep   int
+	etcdBackupMaxAge time.Duration
+	etcdSnapshotFile string
+)
+
+var etcdCmd = &cobra.Command{
+	Use:   "etcd",
+	Short: "备份和恢复 etcd",
+	Long:  `备份和恢复集群的 etcd 数据（kubeadm 部署的 stacked etcd）`,
+}
+
+var etcdBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "备份 etcd 快照和证书",
+	Long: `在 Master 节点上执行 etcdctl snapshot save，将快照连同 /etc/kubernetes/pki 打包下载到本地
+
+备份文件默认保存在 ~/.k8s-deployer/clusters/<name>/backups/etcd-<时间>.tar.gz，
+包含 snapshot.db、pki/ 和 backup-info.json。备份中包含集群私钥，文件权限为 0600。
+
+etcdctl/etcdutl 使用节点上 etcd 静态 Pod 的镜像通过 ctr 运行，节点上无需安装。
+
+保留规则：
+  --keep      只保留最近 N 个备份（默认 7，0 表示不限制）
+  --max-age   删除超过指定时间的备份（如 168h，默认不限制）`,
+	Example: `  # 备份到默认目录，保留最近 7 个
+  k8s-deployer etcd backup -f cluster.yaml
+
+  # 备份到指定目录，保留 30 个且不超过 30 天
+  k8s-deployer etcd backup -f cluster.yaml -o /backup/etcd --keep 30 --max-age 720h`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadClusterConfig()
+		if err != nil {
+			return err
+		}
+
+		ui.Header("备份 etcd: " + cfg.Metadata.Name)
+		path, err := cluster.BackupEtcd(cfg, cluster.EtcdBackupOptions{
+			Node:      etcdBackupNode,
+			OutputDir: etcdBackupOutput,
+			Keep:      etcdBackupKeep,
+			MaxAge:    etcdBackupMaxAge,
+		})
+		if err != nil {
+			ui.Error("备份失败: %v", err)
+			return err
+		}
+		ui.Success("备份已保存到 %s", path)
+		return nil
+	},
+}
+
+var etcdRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "从快照恢复 etcd",
+	Long: `将 etcd backup 生成的备份恢复到所有 Master 节点
+
+恢复流程：
+  1. 上传快照到所有 Master 节点
+  2. 停止所有 Master 的 kube-apiserver、controller-manager、scheduler，然后停止 etcd
+  3. 在每个 Master 上使用 etcdutl snapshot restore 重建 /var/lib/etcd
+     （原数据目录保留为 /var/lib/etcd.before-restore-<时间>）
+  4. 启动所有 etcd，再启动其他控制平面组件
+  5. 等待 API Server 就绪
+
+备份之后的集群变更都会丢失，执行前需要输入 yes 确认。
+--snapshot 也可以直接指定 etcdctl snapshot save 生成的 .db 文件。`,
+	Example: `  k8s-deployer etcd restore -f cluster.yaml --snapshot ~/.k8s-deployer/clusters/prod/backups/etcd-20250101-020000.tar.gz`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadClusterConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cluster.RestoreEtcd(cfg, cluster.EtcdRestoreOptions{
+			Archive:     etcdSnapshotFile,
+			AutoConfirm: autoConfirm,
+		}); err != nil {
+			ui.Error("恢复失败: %v", err)
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(etcdCmd)
+	etcdCmd.AddCommand(etcdBackupCmd)
+	etcdCmd.AddCommand(etcdRestoreCmd)
+
+	// etcd backup 的 flags
+	etcdBackupCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
+	etcdBackupCmd.Flags().StringVar(&etcdBackupNode, "node", "", "执行快照的 Master 节点主机名（默认第一个 Master）")
+	etcdBackupCmd.Flags().StringVarP(&etcdBackupOutput, "output-dir", "o", "", "本地备份目录 (默认: ~/.k8s-deployer/clusters/<name>/backups)")
+	etcdBackupCmd.Flags().IntVar(&etcdBackupKeep, "keep", 7, "保留最近的备份个数（0 表示不限制）")
+	etcdBackupCmd.Flags().DurationVar(&etcdBackupMaxAge, "max-age", 0, "删除超过该时间的备份，如 168h（0 表示不限制）")
+	etcdBackupCmd.MarkFlagRequired("config")
+
+	// etcd restore 的 flags
+	etcdRestoreCmd.Flags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径 (必需)")
+	etcdRestoreCmd.Flags().StringVar(&etcdSnapshotFile, "snapshot", "", "备份文件（etcd backup 生成的 .tar.gz 或快照 .db）(必需)")
+	etcdRestoreCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "跳过确认")
+	etcdRestoreCmd.MarkFlagRequired("config")
+	etcdRestoreCmd.MarkFlagRequired("snapshot")
+}
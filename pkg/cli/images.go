@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/images"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+var imagesFrom string
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "管理集群镜像",
+	Long:  `列出集群需要的镜像，并推送到 spec.imageRepository（不依赖 skopeo/docker）`,
+}
+
+var imagesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出集群需要的镜像",
+	Long: `按集群配置列出需要推送到 spec.imageRepository 的镜像
+
+镜像版本来自 packages/manifest.yaml，按配置筛选：
+  - kubeadm 控制平面镜像（不含 kube-proxy，由 Cilium 替代）
+  - Cilium，启用时包括 Hubble Relay/UI 和 Envoy
+  - MetalLB（启用 BGP 或 loadBalancer.mode: l2 时）
+  - NVIDIA device plugin（有 GPU 节点时）
+  - kube-vip（ha.provider: kube-vip 时）`,
+	Example: `  k8s-deployer images list -f cluster.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, pkgMgr, err := loadImagesConfig()
+		if err != nil {
+			return err
+		}
+		list, err := images.List(cfg, pkgMgr)
+		if err != nil {
+			return err
+		}
+
+		table := ui.NewTable([]string{"镜像", "组件", "上游镜像"})
+		for _, img := range list {
+			table.Append([]string{img.Ref(), img.Component, img.Source})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var imagesPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "推送镜像到镜像仓库",
+	Long: `将集群需要的镜像从本地目录推送到 spec.imageRepository，使用 spec.harbor 中的账号和 TLS 配置
+
+本地目录中可以包含：
+  - 镜像 tar 包 <名称>_<tag>.tar（docker-archive 格式，如 docker save 或
+    skopeo copy docker://<上游镜像> docker-archive:<名称>_<tag>.tar）
+  - OCI layout（如 skopeo copy docker://<上游镜像> oci:<目录>:<名称>:<tag>），
+    按 ref.name 匹配 <名称>:<tag> 或上游镜像，支持多架构镜像
+
+镜像仓库中已有相同 digest 的镜像会跳过。`,
+	Example: `  # 从 packages/images 推送
+  k8s-deployer images push -f cluster.yaml
+
+  # 从 OCI layout 推送
+  k8s-deployer images push -f cluster.yaml --from /data/oci`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, pkgMgr, err := loadImagesConfig()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		ui.Header("推送镜像到 " + cfg.Spec.ImageRepository)
+		if err := images.PushAll(ctx, cfg, pkgMgr, imagesFrom); err != nil {
+			return err
+		}
+		ui.Success("镜像推送完成")
+		return nil
+	},
+}
+
+// loadImagesConfig 加载集群配置和离线包清单（不需要连接节点）
+func loadImagesConfig() (*config.ClusterConfig, *packages.Manager, error) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		ui.Error("加载配置文件失败: %v", err)
+		return nil, nil, err
+	}
+	executor.AddSecret(cfg.Spec.Harbor.Password)
+
+	pkgMgr, err := packages.NewManagerWithVersion(cfg.Spec.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, pkgMgr, nil
+}
+
+func init() {
+	rootCmd.AddCommand(imagesCmd)
+	imagesCmd.AddCommand(imagesListCmd)
+	imagesCmd.AddCommand(imagesPushCmd)
+
+	imagesCmd.PersistentFlags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径")
+	imagesCmd.MarkPersistentFlagRequired("config")
+
+	imagesPushCmd.Flags().StringVar(&imagesFrom, "from", filepath.Join("packages", packages.ImagesDir), "本地镜像目录（镜像 tar 包或 OCI layout）")
+}
@@ -57,9 +57,8 @@ func init() {
 
 func runInitSSHKey(cmd *cobra.Command, args []string) error {
 	// 加载配置
-	cfg, err := config.LoadConfig(configFile)
+	cfg, err := loadClusterConfig()
 	if err != nil {
-		ui.Error("加载配置文件失败: %v", err)
 		return err
 	}
 
@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"stormdragon/k8s-deployer/pkg/cluster"
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+var (
+	nodeMasterIP    string
+	nodeSSHUser     string
+	nodeSSHPort     int
+	nodeSSHKeyFile  string
+	nodeSSHPassword string
+
+	nodeClusterName     string
+	nodeBastionHost     string
+	nodeBastionPort     int
+	nodeBastionUser     string
+	nodeBastionKeyFile  string
+	nodeBastionPassword string
+
+	nodeAddIP       string
+	nodeAddHostname string
+	nodeAddRole     string
+	nodeAddGPU      bool
+	nodeRemoveReset bool
+)
+
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "管理集群节点",
+	Long: `管理已部署集群的节点
+
+集群信息的来源（二选一）：
+  -f, --config   使用集群配置文件
+  --master       连接指定的 Master 节点，读取集群保存的配置（k8s-deployer-config）
+                 需要同时通过 --cluster 指定集群名称（使用集群独立的 known_hosts）
+
+--ssh-* 参数用于连接 Master 节点，node add 时也作为新节点的 SSH 配置。
+--bastion-* 参数指定跳板机（使用 --master 时，集群保存的配置在连接之后才能读取）。`,
+}
+
+var nodeAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "添加节点到集群",
+	Long: `添加节点到集群
+
+执行流程：
+  1. 更新所有节点的 /etc/hosts
+  2. 准备新节点环境（系统优化、containerd、K8s 组件）
+  3. 获取 join 信息并加入集群
+  4. 将新节点记录到集群保存的配置中
+
+如果配置文件中已包含该主机名的节点，则直接使用配置文件中的节点定义。`,
+	Example: `  # 使用配置文件中已定义的节点
+  k8s-deployer node add -f cluster.yaml --hostname node-03
+
+  # 通过 Master 节点读取集群配置，添加新 Worker
+  k8s-deployer node add --master 192.168.1.11 --cluster prod --ip 192.168.1.23 --hostname node-03
+
+  # 添加 GPU 节点（使用密码登录）
+  k8s-deployer node add -f cluster.yaml --ip 192.168.1.31 --hostname gpu-01 --gpu --ssh-user admin --ssh-password xxx`,
+	RunE: runNodeAdd,
+}
+
+var nodeRemoveCmd = &cobra.Command{
+	Use:   "remove <node-name>",
+	Short: "从集群删除节点",
+	Example: `  # 删除节点
+  k8s-deployer node remove node-03 -f cluster.yaml
+
+  # 删除节点并在节点上执行 kubeadm reset
+  k8s-deployer node remove node-03 -f cluster.yaml --reset`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNodeRemove,
+}
+
+var nodeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出集群节点",
+	Example: `  k8s-deployer node list -f cluster.yaml
+  k8s-deployer node list --master 192.168.1.11 --cluster prod`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadNodeClusterConfig()
+		if err != nil {
+			return err
+		}
+		return cluster.ListNodes(cfg)
+	},
+}
+
+var nodeInfoCmd = &cobra.Command{
+	Use:   "info <node-name>",
+	Short: "查看节点详细信息",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadNodeClusterConfig()
+		if err != nil {
+			return err
+		}
+		return cluster.GetNodeInfo(cfg, args[0])
+	},
+}
+
+var nodeCordonCmd = &cobra.Command{
+	Use:   "cordon <node-name>",
+	Short: "标记节点为不可调度",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadNodeClusterConfig()
+		if err != nil {
+			return err
+		}
+		return cluster.CordonNode(cfg, args[0])
+	},
+}
+
+var nodeUncordonCmd = &cobra.Command{
+	Use:   "uncordon <node-name>",
+	Short: "恢复节点调度",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadNodeClusterConfig()
+		if err != nil {
+			return err
+		}
+		return cluster.UncordonNode(cfg, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nodeCmd)
+	nodeCmd.AddCommand(nodeAddCmd)
+	nodeCmd.AddCommand(nodeRemoveCmd)
+	nodeCmd.AddCommand(nodeListCmd)
+	nodeCmd.AddCommand(nodeInfoCmd)
+	nodeCmd.AddCommand(nodeCordonCmd)
+	nodeCmd.AddCommand(nodeUncordonCmd)
+
+	// node 子命令共用的 flags
+	nodeCmd.PersistentFlags().StringVarP(&configFile, "config", "f", "", "集群配置文件路径")
+	nodeCmd.PersistentFlags().StringVar(&nodeMasterIP, "master", "", "Master 节点 IP（未指定配置文件时从集群读取配置）")
+	nodeCmd.PersistentFlags().StringVar(&nodeSSHUser, "ssh-user", "root", "SSH 用户名")
+	nodeCmd.PersistentFlags().IntVar(&nodeSSHPort, "ssh-port", 22, "SSH 端口")
+	nodeCmd.PersistentFlags().StringVar(&nodeSSHKeyFile, "ssh-key", "", "SSH 私钥路径 (未指定密码时默认 ~/.ssh/id_rsa)")
+	nodeCmd.PersistentFlags().StringVar(&nodeSSHPassword, "ssh-password", "", "SSH 密码")
+	nodeCmd.PersistentFlags().StringVar(&nodeClusterName, "cluster", "", "集群名称（使用 --master 时必需）")
+	nodeCmd.PersistentFlags().StringVar(&nodeBastionHost, "bastion-host", "", "跳板机地址（使用 --master 时）")
+	nodeCmd.PersistentFlags().IntVar(&nodeBastionPort, "bastion-port", 22, "跳板机 SSH 端口")
+	nodeCmd.PersistentFlags().StringVar(&nodeBastionUser, "bastion-user", "root", "跳板机 SSH 用户名")
+	nodeCmd.PersistentFlags().StringVar(&nodeBastionKeyFile, "bastion-key", "", "跳板机 SSH 私钥路径")
+	nodeCmd.PersistentFlags().StringVar(&nodeBastionPassword, "bastion-password", "", "跳板机 SSH 密码")
+
+	// node add 的 flags
+	nodeAddCmd.Flags().StringVar(&nodeAddIP, "ip", "", "新节点 IP")
+	nodeAddCmd.Flags().StringVar(&nodeAddHostname, "hostname", "", "新节点主机名 (必需)")
+	nodeAddCmd.Flags().StringVar(&nodeAddRole, "role", "worker", "节点角色 (master/worker)")
+	nodeAddCmd.Flags().BoolVar(&nodeAddGPU, "gpu", false, "是否为 GPU 节点")
+	nodeAddCmd.MarkFlagRequired("hostname")
+
+	// node remove 的 flags
+	nodeRemoveCmd.Flags().BoolVar(&nodeRemoveReset, "reset", false, "删除后在节点上执行 kubeadm reset")
+	nodeRemoveCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "自动确认所有提示")
+}
+
+func runNodeAdd(cmd *cobra.Command, args []string) error {
+	cfg, err := loadNodeClusterConfig()
+	if err != nil {
+		return err
+	}
+
+	newNode, err := resolveNewNode(cfg)
+	if err != nil {
+		ui.Error("%v", err)
+		return err
+	}
+
+	if err := cluster.AddNode(cfg, newNode); err != nil {
+		ui.Error("添加节点失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+func runNodeRemove(cmd *cobra.Command, args []string) error {
+	cfg, err := loadNodeClusterConfig()
+	if err != nil {
+		return err
+	}
+
+	if !autoConfirm && !ui.WaitForConfirmation(fmt.Sprintf("确认从集群删除节点 %s？", args[0])) {
+		ui.Warning("操作已取消")
+		return nil
+	}
+
+	if err := cluster.RemoveNode(cfg, args[0], nodeRemoveReset); err != nil {
+		ui.Error("删除节点失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// loadNodeClusterConfig 加载集群配置：优先使用配置文件，否则通过 Master 节点读取 ConfigMap
+func loadNodeClusterConfig() (*config.ClusterConfig, error) {
+	if configFile != "" {
+		return loadClusterConfig()
+	}
+
+	if nodeMasterIP == "" {
+		return nil, fmt.Errorf("请通过 -f 指定配置文件或通过 --master 指定 Master 节点")
+	}
+	if nodeClusterName == "" {
+		return nil, fmt.Errorf("使用 --master 时需要通过 --cluster 指定集群名称")
+	}
+
+	ui.Info("从 Master 节点 %s 读取集群配置...", nodeMasterIP)
+	master := &config.NodeConfig{
+		Role: "master",
+		IP:   nodeMasterIP,
+		SSH:  nodeSSHConfig(),
+	}
+	bastion := nodeBastionConfig()
+
+	// 连接 Master 之前先使用集群的 known_hosts 和跳板机
+	bootstrap := &config.ClusterConfig{
+		Metadata: config.MetadataConfig{Name: nodeClusterName},
+		Spec: config.ClusterSpec{
+			Bastion: bastion,
+			Nodes:   []config.NodeConfig{*master},
+		},
+	}
+	if err := cluster.ConfigureSSH(bootstrap); err != nil {
+		return nil, err
+	}
+
+	cfg, err := cluster.LoadClusterConfigFromMaster(master, bastion)
+	if err != nil {
+		ui.Error("读取集群配置失败: %v", err)
+		return nil, err
+	}
+	if cfg.Metadata.Name != nodeClusterName {
+		return nil, fmt.Errorf("Master 节点 %s 属于集群 %s，与 --cluster %s 不一致", nodeMasterIP, cfg.Metadata.Name, nodeClusterName)
+	}
+	return cfg, cluster.ConfigureSSH(cfg)
+}
+
+// nodeBastionConfig 根据命令行参数生成跳板机配置，未指定 --bastion-host 时返回 nil
+func nodeBastionConfig() *config.BastionConfig {
+	if nodeBastionHost == "" {
+		return nil
+	}
+	return &config.BastionConfig{
+		Host:     nodeBastionHost,
+		Port:     nodeBastionPort,
+		User:     nodeBastionUser,
+		KeyFile:  config.ExpandHomePath(nodeBastionKeyFile),
+		Password: nodeBastionPassword,
+	}
+}
+
+// resolveNewNode 确定要添加的节点：配置文件中已定义则直接使用，否则根据命令行参数构造
+func resolveNewNode(cfg *config.ClusterConfig) (*config.NodeConfig, error) {
+	for i := range cfg.Spec.Nodes {
+		if cfg.Spec.Nodes[i].Hostname == nodeAddHostname {
+			if configFile == "" {
+				return nil, fmt.Errorf("节点 %s 已存在于集群配置中", nodeAddHostname)
+			}
+			return &cfg.Spec.Nodes[i], nil
+		}
+	}
+
+	if nodeAddIP == "" {
+		return nil, fmt.Errorf("配置中没有节点 %s，请通过 --ip 指定新节点 IP", nodeAddHostname)
+	}
+
+	node := &config.NodeConfig{
+		Role:     nodeAddRole,
+		IP:       nodeAddIP,
+		Hostname: nodeAddHostname,
+		GPU:      nodeAddGPU,
+		SSH:      nodeSSHConfig(),
+	}
+	// 与配置文件中的节点做相同的检查，避免地址或主机名写错时执行到 kubeadm join 才失败
+	if err := config.ValidateNewNode(cfg, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// nodeSSHConfig 根据命令行参数生成 SSH 配置
+func nodeSSHConfig() config.SSHConfig {
+	keyFile := nodeSSHKeyFile
+	if keyFile == "" && nodeSSHPassword == "" {
+		keyFile = "~/.ssh/id_rsa"
+	}
+
+	return config.SSHConfig{
+		User:     nodeSSHUser,
+		Port:     nodeSSHPort,
+		KeyFile:  config.ExpandHomePath(keyFile),
+		Password: nodeSSHPassword,
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+var (
+	packagesDir            string
+	packagesWriteChecksums bool
+)
+
+var packagesCmd = &cobra.Command{
+	Use:   "packages",
+	Short: "管理离线包",
+	Long:  `管理 packages 目录中的离线包（清单为 packages/manifest.yaml）`,
+}
+
+var packagesVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "校验离线包",
+	Long: `按 packages/manifest.yaml 检查离线包是否存在，并校验 sha256
+
+状态说明：
+  ok         文件存在且 sha256 与清单一致
+  unchecked  文件存在，清单中没有记录 sha256
+  missing    文件不存在
+  mismatch   sha256 与清单不一致
+
+必需的包缺失或 sha256 不一致时返回错误。
+--write-checksums 将文件的 sha256 写入清单中没有记录 sha256 的条目（已记录的不会覆盖）。`,
+	Example: `  # 校验当前目录下的 packages
+  k8s-deployer packages verify
+
+  # 下载完成后记录 sha256
+  k8s-deployer packages verify --write-checksums`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkgMgr, err := packages.NewManagerForDir(packagesDir, "")
+		if err != nil {
+			return err
+		}
+
+		results := pkgMgr.Verify()
+		table := ui.NewTable([]string{"组件", "版本", "架构", "文件", "大小", "状态"})
+		sums := make(map[string]string)
+		var failed, optionalFailed int
+		for _, r := range results {
+			a := r.Artifact
+			size := "-"
+			if r.Status != packages.VerifyMissing {
+				size = fmt.Sprintf("%.1f MB", float64(r.Size)/1024/1024)
+			}
+			table.Append([]string{a.Name, a.Version, a.Arch, a.Path, size, r.Status})
+
+			switch r.Status {
+			case packages.VerifyUnchecked:
+				sums[a.Path] = r.SHA256
+			case packages.VerifyMissing, packages.VerifyMismatch:
+				if a.Optional {
+					optionalFailed++
+				} else {
+					failed++
+				}
+			}
+		}
+		table.Render()
+
+		if packagesWriteChecksums && len(sums) > 0 {
+			manifestPath := filepath.Join(packagesDir, packages.ManifestFile)
+			n, err := packages.WriteChecksums(manifestPath, sums)
+			if err != nil {
+				return fmt.Errorf("写入 sha256 失败: %w", err)
+			}
+			ui.Success("已将 %d 个文件的 sha256 写入 %s", n, manifestPath)
+		} else if len(sums) > 0 {
+			ui.Warning("以下 %d 个文件没有记录 sha256，未校验完整性：", len(sums))
+			for _, r := range results {
+				if r.Status == packages.VerifyUnchecked {
+					ui.Warning("  %s", r.Artifact.Path)
+				}
+			}
+			ui.Warning("确认文件来源可信后，使用 --write-checksums 将 sha256 写入清单")
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d 个离线包缺失或 sha256 不一致（cd scripts && ./download-all.sh）", failed)
+		}
+		if optionalFailed > 0 {
+			ui.Warning("%d 个可选的离线包缺失或 sha256 不一致", optionalFailed)
+		}
+		ui.Success("离线包校验通过")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packagesCmd)
+	packagesCmd.AddCommand(packagesVerifyCmd)
+
+	packagesVerifyCmd.Flags().StringVar(&packagesDir, "dir", "packages", "离线包目录")
+	packagesVerifyCmd.Flags().BoolVar(&packagesWriteChecksums, "write-checksums", false, "将 sha256 写入清单中没有记录 sha256 的条目")
+}
@@ -1,7 +1,19 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/events"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/logger"
+	"stormdragon/k8s-deployer/pkg/ui"
 )
 
 var rootCmd = &cobra.Command{
@@ -17,15 +29,157 @@ var rootCmd = &cobra.Command{
   - 系统优化和性能调优
   - 节点动态管理`,
 	Version: "0.1.0",
+	// 错误在 Execute 中作为 error 事件输出
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 配置目录（集群数据、二进制缓存和日志）
+		if configDir, _ := cmd.Flags().GetString("config-dir"); configDir != "" {
+			config.SetConfigDir(configDir)
+			executor.SetKnownHostsFile(filepath.Join(config.ExpandHomePath(configDir), "known_hosts"))
+		}
+
+		if err := setupOutput(cmd); err != nil {
+			return err
+		}
+
+		// 全局主机密钥校验模式（覆盖配置文件中的 ssh.hostKeyCheck）
+		hostKeyCheck, _ := cmd.Flags().GetString("host-key-check")
+		mode, err := executor.ParseHostKeyMode(hostKeyCheck)
+		if err != nil {
+			return err
+		}
+		executor.SetHostKeyModeOverride(mode)
+		return nil
+	},
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	// 命令结束后关闭共享的跳板机连接
+	defer executor.CloseBastions()
+	// 关闭事件文件、日志文件和命令日志
+	defer events.Close()
+	defer executor.CloseCommandLogs()
+
+	err := rootCmd.Execute()
+	if err != nil {
+		node, step := events.ErrorLocation(err)
+		events.Publish(events.Event{
+			Type:    events.Error,
+			Node:    node,
+			Step:    step,
+			Message: err.Error(),
+			Error:   err.Error(),
+		})
+		if path := failedCommandLog(node); path != "" {
+			ui.Info("命令的完整输出见: %s", path)
+		}
+	}
+	return err
+}
+
+// failedCommandLog 返回失败时提示用户查看的命令日志
+// 优先使用错误所在节点的日志，其次是最后一条失败命令所在节点的日志
+func failedCommandLog(node string) string {
+	if node != "" {
+		if path := executor.CommandLogPath(node); path != "" {
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	return executor.FailedCommandLog()
+}
+
+// setupOutput 根据 --output、--event-file、--log-file 和 --verbose 设置事件输出和日志
+//   - text: 彩色文本输出到终端（默认），--verbose 时同时显示每条命令的执行结果
+//   - json: 每行一个 JSON 事件输出到 stdout，确认提示和进度条输出到 stderr
+//
+// 每次执行的日志保存在 <配置目录>/logs/<时间>-<命令>/ 下：
+// k8s-deployer.log 为事件日志（--log-file 可指定其他路径），<节点>.log 为该节点上执行的所有命令及其输出
+func setupOutput(cmd *cobra.Command) error {
+	output, _ := cmd.Flags().GetString("output")
+	eventFile, _ := cmd.Flags().GetString("event-file")
+	logFile, _ := cmd.Flags().GetString("log-file")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	var sinks []events.Sink
+	switch output {
+	case "", "text":
+		sinks = append(sinks, ui.NewConsoleSink(verbose))
+	case "json":
+		sinks = append(sinks, events.NewJSONSink(os.Stdout))
+		ui.SetInteractiveOutput(os.Stderr)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s（可选 text/json）", output)
+	}
+
+	if eventFile != "" {
+		sink, err := events.OpenJSONFile(eventFile)
+		if err != nil {
+			return fmt.Errorf("打开事件文件失败: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	runDir, err := newRunLogDir(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 创建日志目录失败，不记录命令日志: %v\n", err)
+	} else {
+		executor.SetCommandLogDir(runDir)
+		if logFile == "" {
+			logFile = filepath.Join(runDir, "k8s-deployer.log")
+		}
+	}
+
+	if logFile != "" {
+		if err := logger.InitFileLogger(logFile, verbose); err != nil {
+			return fmt.Errorf("打开日志文件失败: %w", err)
+		}
+		sinks = append(sinks, logger.NewEventSink())
+	}
+
+	events.SetSinks(sinks...)
+	return nil
+}
+
+// keepRunLogs 保留的执行日志目录数量
+const keepRunLogs = 50
+
+// newRunLogDir 返回本次执行的日志目录，并清理较早的日志目录
+func newRunLogDir(cmd *cobra.Command) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	logsDir := filepath.Join(configDir, "logs")
+
+	if entries, err := os.ReadDir(logsDir); err == nil {
+		var runs []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				runs = append(runs, entry.Name())
+			}
+		}
+		// 目录名以时间开头，按名称排序即按时间排序
+		sort.Strings(runs)
+		for len(runs) >= keepRunLogs {
+			os.RemoveAll(filepath.Join(logsDir, runs[0]))
+			runs = runs[1:]
+		}
+	}
+
+	// 如 20250101-020000-cluster-create
+	command := strings.ReplaceAll(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "), " ", "-")
+	name := fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), command)
+	return filepath.Join(logsDir, name), nil
 }
 
 func init() {
 	// 添加全局 flags
-	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "详细输出模式")
-	rootCmd.PersistentFlags().String("config-dir", "", "配置目录 (默认: ~/.k8s-deployer)")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "详细输出模式（显示每条命令的执行结果，日志中记录 DEBUG 级别）")
+	rootCmd.PersistentFlags().String("config-dir", "", "配置目录，包含集群数据、二进制缓存和日志 (默认: ~/.k8s-deployer)")
+	rootCmd.PersistentFlags().String("host-key-check", "", "SSH 主机密钥校验模式: strict/tofu/insecure (覆盖配置文件，默认 tofu)")
+	rootCmd.PersistentFlags().String("output", "text", "输出格式: text/json (json 时每行输出一个 JSON 事件)")
+	rootCmd.PersistentFlags().String("event-file", "", "同时将 JSON 事件追加写入该文件")
+	rootCmd.PersistentFlags().String("log-file", "", "事件日志文件 (默认: <配置目录>/logs/<时间>-<命令>/k8s-deployer.log)")
 }
-
@@ -0,0 +1,102 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// nodeBinaryPackages 按节点架构上传的离线包
+var nodeBinaryPackages = []string{"containerd", "runc", "cni-plugins", "kubectl", "kubeadm", "kubelet"}
+
+var (
+	nodeArchsMu sync.Mutex
+	nodeArchs   = make(map[string]string) // 按节点地址缓存
+)
+
+// archLabel 节点架构标签，dry-run 时用于模拟节点的 uname -m
+const archLabel = "kubernetes.io/arch"
+
+// dryRunUnknownArch dry-run 时没有 archLabel 标签的节点 uname -m 的模拟结果
+const dryRunUnknownArch = "unknown"
+
+// nodeKey 节点的缓存键（地址和端口）
+func nodeKey(client *executor.SSHClient) string {
+	return net.JoinHostPort(client.Host, strconv.Itoa(client.Port))
+}
+
+// detectArch 检测节点架构，返回离线包使用的架构名（amd64 / arm64），每个节点只检测一次
+func detectArch(client *executor.SSHClient) (string, error) {
+	key := nodeKey(client)
+	nodeArchsMu.Lock()
+	arch, ok := nodeArchs[key]
+	nodeArchsMu.Unlock()
+	if ok {
+		return arch, nil
+	}
+
+	output, err := client.Execute("uname -m")
+	if err != nil {
+		return "", fmt.Errorf("检测节点架构失败: %w", err)
+	}
+	if output == dryRunUnknownArch && executor.ActiveRecorder() != nil {
+		// dry-run 不连接节点，执行计划按默认架构的离线包生成
+		ui.Warning("节点 %s 的架构未知（dry-run 不连接节点），执行计划中按 %s 生成；可以用节点标签 %s 指定",
+			client.Host, packages.DefaultArch, archLabel)
+		arch = packages.DefaultArch
+	} else if arch, err = packages.ArchFromMachine(output); err != nil {
+		return "", err
+	}
+
+	nodeArchsMu.Lock()
+	nodeArchs[key] = arch
+	nodeArchsMu.Unlock()
+	return arch, nil
+}
+
+// nodePackageManager 返回节点架构的包管理器，并检查 required 中的离线包是否齐全
+func nodePackageManager(client *executor.SSHClient, pkgMgr *packages.Manager, required ...string) (*packages.Manager, error) {
+	arch, err := detectArch(client)
+	if err != nil {
+		return nil, err
+	}
+	nodeMgr := pkgMgr.ForArch(arch)
+	if missing := nodeMgr.CheckRequiredPackages(required); len(missing) > 0 {
+		return nil, fmt.Errorf("缺少 %s 的离线包: %s，请先运行: cd scripts && ./download-all.sh --arch %s",
+			arch, strings.Join(missing, ", "), arch)
+	}
+	return nodeMgr, nil
+}
+
+// clusterArchs 连接所有节点检测架构，返回使用的架构（已排序）
+func clusterArchs(cfg *config.ClusterConfig) ([]string, error) {
+	seen := make(map[string]bool)
+	for i := range cfg.Spec.Nodes {
+		node := &cfg.Spec.Nodes[i]
+		client, err := connectNode(node)
+		if err != nil {
+			return nil, fmt.Errorf("连接节点 %s 失败: %w", node.Hostname, err)
+		}
+		arch, err := detectArch(client)
+		client.Close()
+		if err != nil {
+			return nil, fmt.Errorf("节点 %s: %w", node.Hostname, err)
+		}
+		seen[arch] = true
+	}
+
+	var archs []string
+	for arch := range seen {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	return archs, nil
+}
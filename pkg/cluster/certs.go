@@ -0,0 +1,393 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/kubeadm"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+const (
+	// certExpiryWarning 剩余有效期少于该时间时提示续期
+	certExpiryWarning = 30 * 24 * time.Hour
+
+	kubeletConf        = "/etc/kubernetes/kubelet.conf"
+	kubeletClientCert  = "/var/lib/kubelet/pki/kubelet-client-current.pem"
+	certsRenewConfig   = "/tmp/kubeadm-certs.yaml"
+	kubeletRenewBackup = kubeletConf + ".k8s-deployer.bak"
+
+	certsRenewTimeout = 5 * time.Minute
+)
+
+// certExpiryScript 输出节点上所有证书的 "路径|到期时间"
+// kubeconfig 中的证书以 base64 内嵌，需要先解码
+const certExpiryScript = `
+for f in /etc/kubernetes/pki/*.crt /etc/kubernetes/pki/etcd/*.crt /var/lib/kubelet/pki/kubelet-client-current.pem /var/lib/kubelet/pki/kubelet.crt; do
+	[ -f "$f" ] || continue
+	echo "$f|$(openssl x509 -noout -enddate -in "$f" | cut -d= -f2)"
+done
+for f in /etc/kubernetes/admin.conf /etc/kubernetes/super-admin.conf /etc/kubernetes/controller-manager.conf /etc/kubernetes/scheduler.conf /etc/kubernetes/kubelet.conf; do
+	[ -f "$f" ] || continue
+	data=$(awk '/client-certificate-data:/ {print $2}' "$f")
+	[ -n "$data" ] || continue
+	echo "$f|$(echo "$data" | base64 -d | openssl x509 -noout -enddate | cut -d= -f2)"
+done
+`
+
+// CertificateExpiry 单个证书的到期信息
+type CertificateExpiry struct {
+	Node     string
+	Name     string
+	NotAfter time.Time
+	CA       bool // CA 证书不会被 kubeadm certs renew 续期
+}
+
+// CertRenewOptions 证书续期选项
+type CertRenewOptions struct {
+	AutoConfirm bool
+	SkipKubelet bool // 不刷新 kubelet 客户端证书
+}
+
+// CheckCertificates 并发读取所有节点的证书到期时间并以表格输出
+// 有证书已过期时返回错误
+func CheckCertificates(cfg *config.ClusterConfig) ([]CertificateExpiry, error) {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		byNode = make(map[string][]CertificateExpiry)
+		failed []string
+	)
+
+	for i := range cfg.Spec.Nodes {
+		wg.Add(1)
+		go func(node *config.NodeConfig) {
+			defer wg.Done()
+			certs, err := nodeCertificates(node)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", node.Hostname, err))
+				return
+			}
+			byNode[node.Hostname] = certs
+		}(&cfg.Spec.Nodes[i])
+	}
+	wg.Wait()
+
+	// 按配置中的节点顺序汇总
+	var certs []CertificateExpiry
+	for _, node := range cfg.Spec.Nodes {
+		certs = append(certs, byNode[node.Hostname]...)
+	}
+
+	now := time.Now()
+	expired, expiring := 0, 0
+	table := ui.NewTable([]string{"节点", "证书", "到期时间", "剩余", "状态"})
+	for _, cert := range certs {
+		remaining := cert.NotAfter.Sub(now)
+		status := "✓ 正常"
+		switch {
+		case remaining <= 0:
+			status = "✗ 已过期"
+			expired++
+		case remaining < certExpiryWarning:
+			status = "! 即将过期"
+			expiring++
+		}
+		name := cert.Name
+		if cert.CA {
+			name += " (CA)"
+		}
+		table.Append([]string{cert.Node, name, cert.NotAfter.Local().Format("2006-01-02 15:04"), formatRemaining(remaining), status})
+	}
+	table.Render()
+
+	for _, msg := range failed {
+		ui.Warning("读取证书失败: %s", msg)
+	}
+	if expired > 0 {
+		return certs, fmt.Errorf("%d 个证书已过期，请执行 k8s-deployer certs renew", expired)
+	}
+	if expiring > 0 {
+		ui.Warning("%d 个证书将在 %d 天内过期，请执行 k8s-deployer certs renew", expiring, int(certExpiryWarning.Hours()/24))
+	}
+	if len(failed) > 0 {
+		return certs, fmt.Errorf("%d 个节点读取证书失败", len(failed))
+	}
+	return certs, nil
+}
+
+// nodeCertificates 读取单个节点上的证书到期时间
+func nodeCertificates(node *config.NodeConfig) ([]CertificateExpiry, error) {
+	client, err := connectNode(node)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	output, err := client.Execute(certExpiryScript)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []CertificateExpiry
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, "|", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		notAfter, err := time.Parse("Jan _2 15:04:05 2006 MST", strings.TrimSpace(parts[1]))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimPrefix(parts[0], "/etc/kubernetes/pki/")
+		name = strings.TrimPrefix(name, "/etc/kubernetes/")
+		name = strings.Replace(name, "/var/lib/kubelet/pki/", "kubelet/", 1)
+		certs = append(certs, CertificateExpiry{
+			Node:     node.Hostname,
+			Name:     name,
+			NotAfter: notAfter,
+			CA:       strings.HasSuffix(name, "ca.crt"),
+		})
+	}
+	sort.SliceStable(certs, func(i, j int) bool { return certs[i].Name < certs[j].Name })
+	return certs, nil
+}
+
+// formatRemaining 格式化剩余有效期
+func formatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	days := int(d.Hours() / 24)
+	if days >= 365 {
+		return fmt.Sprintf("%d 年 %d 天", days/365, days%365)
+	}
+	if days > 0 {
+		return fmt.Sprintf("%d 天", days)
+	}
+	return fmt.Sprintf("%d 小时", int(d.Hours()))
+}
+
+// RenewCertificates 续期集群证书
+// 流程：所有 Master 执行 kubeadm certs renew all（使用 spec.certificates.validity）→
+// 逐个 Master 重启控制平面静态 Pod → 刷新各节点的 kubelet 客户端证书 → 更新本地 kubeconfig
+func RenewCertificates(cfg *config.ClusterConfig, opts CertRenewOptions) error {
+	masters := getMasterNodes(cfg)
+	if len(masters) == 0 {
+		return fmt.Errorf("配置中没有 Master 节点")
+	}
+
+	ui.Header(fmt.Sprintf("续期集群证书: %s", cfg.Metadata.Name))
+	ui.Info("证书有效期: %s", cfg.Spec.Certificates.Validity)
+	ui.Info("")
+	ui.Info("续期计划：")
+	for _, node := range masters {
+		ui.Info("  - %s: kubeadm certs renew all，重启 etcd、kube-apiserver、controller-manager、scheduler", node.Hostname)
+	}
+	if !opts.SkipKubelet {
+		ui.Info("  - 所有节点: 重新签发 kubelet 客户端证书并重启 kubelet")
+	}
+	ui.Info("  - 本地: 更新 ~/.kube/config")
+	ui.Info("")
+	ui.Warning("CA 证书不会续期；重启期间各 Master 的 API Server 会短暂不可用")
+	ui.Info("")
+
+	if !opts.AutoConfirm && !ui.WaitForConfirmation("确认续期证书？") {
+		ui.Warning("操作已取消")
+		return nil
+	}
+
+	total := 4
+	if opts.SkipKubelet {
+		total = 3
+	}
+
+	// 1. 所有 Master 续期证书
+	ui.Step(1, total, "续期 Master 节点证书")
+	clients := make([]*executor.SSHClient, len(masters))
+	defer func() {
+		for _, client := range clients {
+			if client != nil {
+				client.Close()
+			}
+		}
+	}()
+	for i := range masters {
+		node := &masters[i]
+		client, err := connectNode(node)
+		if err != nil {
+			return fmt.Errorf("连接节点 %s 失败: %w", node.Hostname, err)
+		}
+		clients[i] = client
+		defer client.Execute("rm -f " + certsRenewConfig)
+
+		if err := renewMasterCertificates(client, cfg, node); err != nil {
+			return fmt.Errorf("节点 %s 续期证书失败: %w", node.Hostname, err)
+		}
+		ui.Success("  %s", node.Hostname)
+	}
+
+	// 2. 逐个 Master 重启控制平面，保证同一时间只有一个 Master 不可用
+	ui.Step(2, total, "重启控制平面静态 Pod")
+	for i, node := range masters {
+		if err := restartControlPlane(clients[i]); err != nil {
+			ui.Warning("暂存的静态 Pod 清单位于 %s，移回 %s 即可启动", staticPodHoldDir, staticPodDir)
+			return fmt.Errorf("重启节点 %s 的控制平面失败: %w", node.Hostname, err)
+		}
+		ui.Success("  %s", node.Hostname)
+	}
+	if err := waitForAPIServer(clients[0]); err != nil {
+		return err
+	}
+
+	// 3. 刷新 kubelet 客户端证书
+	step := 3
+	if !opts.SkipKubelet {
+		ui.Step(step, total, "刷新 kubelet 客户端证书")
+		for i := range cfg.Spec.Nodes {
+			node := &cfg.Spec.Nodes[i]
+			if err := renewKubeletClientCert(clients[0], node); err != nil {
+				ui.Warning("原 kubelet.conf 已备份为 %s:%s", node.Hostname, kubeletRenewBackup)
+				return fmt.Errorf("刷新节点 %s 的 kubelet 证书失败: %w", node.Hostname, err)
+			}
+			ui.Success("  %s", node.Hostname)
+		}
+		step++
+	}
+
+	// 4. 更新本地 kubeconfig
+	ui.Step(step, total, "更新本地 kubeconfig")
+	if err := refreshLocalKubeconfig(clients[0], cfg); err != nil {
+		ui.Warning("更新本地 kubeconfig 失败: %v", err)
+		ui.Info("您可以手动获取 kubeconfig：")
+		ui.Info("  scp root@%s:/etc/kubernetes/admin.conf ~/.kube/config", bracketIP(masters[0].IP))
+	}
+
+	ui.Success("证书续期完成")
+	return nil
+}
+
+// renewMasterCertificates 使用集群配置中的有效期续期 Master 节点上的所有证书
+// kubeadm 配置保留在 certsRenewConfig，刷新 kubelet 证书时还会用到
+func renewMasterCertificates(client *executor.SSHClient, cfg *config.ClusterConfig, node *config.NodeConfig) error {
+	kubeadmConfig, err := kubeadm.GenerateInitConfig(cfg, node.IP)
+	if err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf("cat > %s << 'EOF'\n%s\nEOF", certsRenewConfig, kubeadmConfig)
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("上传 kubeadm 配置失败: %w", err)
+	}
+
+	if _, err := client.ExecuteWithTimeout(kubeadm.GetCertsRenewCommand(certsRenewConfig), certsRenewTimeout); err != nil {
+		return err
+	}
+	return nil
+}
+
+// restartControlPlane 重启控制平面静态 Pod 使新证书生效（先停 apiserver 等组件再停 etcd，启动顺序相反）
+func restartControlPlane(client *executor.SSHClient) error {
+	if err := holdStaticPods(client, controlPlaneManifests); err != nil {
+		return err
+	}
+	if err := holdStaticPods(client, []string{"etcd.yaml"}); err != nil {
+		return err
+	}
+	if err := releaseStaticPods(client, []string{"etcd.yaml"}); err != nil {
+		return err
+	}
+	if err := waitForPort(client, "2379"); err != nil {
+		return err
+	}
+	if err := releaseStaticPods(client, controlPlaneManifests); err != nil {
+		return err
+	}
+	return waitForStaticPods(client, controlPlaneManifests, true)
+}
+
+// renewKubeletClientCert 重新签发节点的 kubelet 客户端证书
+// 按 kubeadm 文档的方法：在第一个 Master 上（使用 certsRenewConfig）生成 system:node:<主机名> 的 kubeconfig，kubelet 重启后用它申请新证书，
+// 新证书生成后 kubelet.conf 改为引用 kubelet-client-current.pem，此后由 kubelet 自动轮换
+func renewKubeletClientCert(masterClient *executor.SSHClient, node *config.NodeConfig) error {
+	kubeconfig, err := masterClient.Execute(kubeadm.GetKubeletKubeconfigCommand(certsRenewConfig, node.Hostname))
+	if err != nil {
+		return fmt.Errorf("生成 kubelet kubeconfig 失败: %w", err)
+	}
+
+	client, err := connectNode(node)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	script := fmt.Sprintf(`set -e
+cp -f %[1]s %[2]s
+cat > %[1]s << 'EOF'
+%[3]s
+EOF
+chmod 600 %[1]s
+rm -f /var/lib/kubelet/pki/kubelet-client-*
+systemctl restart kubelet
+for i in $(seq 1 60); do [ -f %[4]s ] && break; sleep 2; done
+[ -f %[4]s ]
+sed -i -e 's#^\(\s*\)client-certificate-data:.*#\1client-certificate: %[4]s#' -e 's#^\(\s*\)client-key-data:.*#\1client-key: %[4]s#' %[1]s
+systemctl restart kubelet`, kubeletConf, kubeletRenewBackup, strings.TrimSpace(kubeconfig), kubeletClientCert)
+	if _, err := client.ExecuteWithTimeout(script, 5*time.Minute); err != nil {
+		return err
+	}
+	return nil
+}
+
+// refreshLocalKubeconfig 用续期后的 admin.conf 更新本地 kubeconfig
+// 只在本地 kubeconfig 指向同一个 API Server 时覆盖（即由 setupLocalKubectl 写入），避免覆盖其他集群的配置
+func refreshLocalKubeconfig(client *executor.SSHClient, cfg *config.ClusterConfig) error {
+	adminConf, err := client.Execute("cat /etc/kubernetes/admin.conf")
+	if err != nil {
+		return fmt.Errorf("读取 kubeconfig 失败: %w", err)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("获取 home 目录失败: %w", err)
+	}
+	kubeconfigPath := filepath.Join(homeDir, ".kube", "config")
+
+	existing, err := os.ReadFile(kubeconfigPath)
+	if os.IsNotExist(err) {
+		return setupLocalKubectl(client, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("读取本地 kubeconfig 失败: %w", err)
+	}
+
+	server := kubeconfigServer(adminConf)
+	if server == "" || kubeconfigServer(string(existing)) != server {
+		return fmt.Errorf("本地 kubeconfig 不属于集群 %s（API Server %s），未修改", cfg.Metadata.Name, server)
+	}
+
+	if err := os.WriteFile(kubeconfigPath, []byte(adminConf), 0600); err != nil {
+		return fmt.Errorf("写入 kubeconfig 失败: %w", err)
+	}
+	ui.Info("  kubeconfig 已更新: %s", kubeconfigPath)
+	return nil
+}
+
+// kubeconfigServer 读取 kubeconfig 中第一个 server 地址
+func kubeconfigServer(kubeconfig string) string {
+	for _, line := range strings.Split(kubeconfig, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "server:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "server:"))
+		}
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"net"
 	"text/template"
 	"time"
 
@@ -22,9 +23,11 @@ var defaultGatewayTemplate string
 // CiliumValuesConfig Cilium values 模板参数
 type CiliumValuesConfig struct {
 	ImageRegistry        string
+	Images               map[string]string // 镜像名称 -> 名称:tag
 	K8sServiceHost       string
 	K8sServicePort       string
-	PodSubnet            string
+	IPv4PodSubnet        string // 为空表示未启用 IPv4
+	IPv6PodSubnet        string // 为空表示未启用 IPv6
 	HubbleEnabled        bool
 	HubbleUIEnabled      bool
 	HubbleUINodePort     int
@@ -35,7 +38,7 @@ type CiliumValuesConfig struct {
 	EnvoyEnabled         bool
 }
 
-// InstallCilium 安装 Cilium 网络插件（离线）
+// InstallCilium 安装 Cilium 网络插件（离线），controlPlaneEndpoint 为 API Server 地址（host:port）
 func InstallCilium(client *executor.SSHClient, cfg *config.ClusterConfig, controlPlaneEndpoint string) error {
 	ui.Header("安装 Cilium 网络插件")
 
@@ -67,7 +70,7 @@ func InstallCilium(client *executor.SSHClient, cfg *config.ClusterConfig, contro
 	}
 
 	ui.Success("Cilium 安装完成！")
-	ui.Info("  网络插件: Cilium v1.18.4")
+	ui.Info("  网络插件: Cilium")
 	ui.Info("  模式: kube-proxy replacement (eBPF)")
 	if cfg.Spec.Hubble.Enabled {
 		ui.Info("  Hubble: 已启用")
@@ -86,15 +89,19 @@ func InstallCilium(client *executor.SSHClient, cfg *config.ClusterConfig, contro
 // installHelmOffline 离线安装 Helm
 func installHelmOffline(client *executor.SSHClient) error {
 	// 初始化包管理器
-	pkgMgr := packages.NewManager()
+	pkgMgr, err := packages.NewManager()
+	if err != nil {
+		return err
+	}
 
-	// 检查本地离线包
+	// 检查本地离线包（按节点架构）
 	ui.SubStep("检查 Helm 离线包...")
-	helmPath := pkgMgr.GetPackagePath("helm")
-	if !pkgMgr.Exists("helm") {
+	pkgMgr, err = nodePackageManager(client, pkgMgr, "helm")
+	if err != nil {
 		ui.SubStepFailed()
-		return fmt.Errorf("缺少离线包: %s，请先运行: cd scripts && ./download-all.sh", helmPath)
+		return err
 	}
+	helmPath := pkgMgr.GetPackagePath("helm")
 	ui.SubStepDone()
 
 	// 检查是否已安装（用于提示）
@@ -125,7 +132,11 @@ func deployCiliumOffline(client *executor.SSHClient, cfg *config.ClusterConfig,
 	ui.SubStep("检查 Cilium Chart 离线包...")
 
 	// 初始化包管理器
-	pkgMgr := packages.NewManager()
+	pkgMgr, err := packages.NewManager()
+	if err != nil {
+		ui.SubStepFailed()
+		return err
+	}
 
 	// 检查本地 Cilium chart
 	chartPath := pkgMgr.GetPackagePath("cilium-chart")
@@ -149,7 +160,7 @@ func deployCiliumOffline(client *executor.SSHClient, cfg *config.ClusterConfig,
 
 	// 生成 Cilium values 文件
 	ui.SubStep("生成 Cilium 配置...")
-	valuesContent, err := generateCiliumValues(cfg, controlPlaneEndpoint, registry)
+	valuesContent, err := generateCiliumValues(cfg, controlPlaneEndpoint, registry, pkgMgr.Images("cilium-chart"))
 	if err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("生成 Cilium 配置失败: %w", err)
@@ -163,6 +174,7 @@ func deployCiliumOffline(client *executor.SSHClient, cfg *config.ClusterConfig,
 		return fmt.Errorf("上传 Cilium 配置失败: %w", err)
 	}
 	ui.SubStepDone()
+	ui.Info("  Cilium 版本: %s", pkgMgr.Version("cilium-chart"))
 	ui.Info("  使用镜像仓库: %s", registry)
 	if cfg.Spec.BGP.Enabled {
 		ui.Info("  BGP 模式: 已启用")
@@ -189,19 +201,45 @@ func deployCiliumOffline(client *executor.SSHClient, cfg *config.ClusterConfig,
 	return nil
 }
 
+// ciliumImages Cilium values 模板使用的镜像（名称需要与离线包清单中 cilium-chart 的 images 一致）
+// 启用 Envoy 时还需要 cilium-envoy
+var ciliumImages = []string{"cilium", "operator-generic", "hubble-relay", "hubble-ui", "hubble-ui-backend"}
+
 // generateCiliumValues 生成 Cilium values 配置
-func generateCiliumValues(cfg *config.ClusterConfig, controlPlaneEndpoint, imageRegistry string) (string, error) {
+// images 为离线包清单中 cilium-chart 需要的镜像（名称 -> 名称:tag）
+func generateCiliumValues(cfg *config.ClusterConfig, controlPlaneEndpoint, imageRegistry string, images map[string]string) (string, error) {
+	required := append([]string{}, ciliumImages...)
+	if cfg.Spec.GatewayAPI.Enabled && cfg.Spec.Envoy.Enabled {
+		required = append(required, "cilium-envoy")
+	}
+	for _, name := range required {
+		if images[name] == "" {
+			return "", fmt.Errorf("离线包清单中 cilium-chart 缺少镜像 %s", name)
+		}
+	}
+
 	// 默认 LoadBalancer 模式为 DSR
 	lbMode := "dsr"
 	if cfg.Spec.LoadBalancer.Mode != "" {
 		lbMode = cfg.Spec.LoadBalancer.Mode
 	}
 
+	// 双栈时按地址族拆分 Pod 网段
+	podIPv4, podIPv6 := config.CIDRsByFamily(cfg.Spec.Networking.PodSubnet)
+
+	// kube-proxy 替代模式下 Cilium 直接连接 API Server（VIP、外部负载均衡器或第一个 Master）
+	k8sServiceHost, k8sServicePort, err := net.SplitHostPort(controlPlaneEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("解析控制平面地址失败: %w", err)
+	}
+
 	params := CiliumValuesConfig{
 		ImageRegistry:        imageRegistry,
-		K8sServiceHost:       controlPlaneEndpoint,
-		K8sServicePort:       "6443",
-		PodSubnet:            cfg.Spec.Networking.PodSubnet,
+		Images:               images,
+		K8sServiceHost:       k8sServiceHost,
+		K8sServicePort:       k8sServicePort,
+		IPv4PodSubnet:        podIPv4,
+		IPv6PodSubnet:        podIPv6,
 		HubbleEnabled:        cfg.Spec.Hubble.Enabled,
 		HubbleUIEnabled:      cfg.Spec.Hubble.UI.Enabled,
 		HubbleUINodePort:     cfg.Spec.Hubble.UI.NodePort,
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -54,7 +55,7 @@ func SaveClusterConfig(client *executor.SSHClient, cfg *config.ClusterConfig) er
 	return nil
 }
 
-// labelClusterNodes 为所有节点打上 k8s-deployer 标签
+// labelClusterNodes 为所有节点打上 k8s-deployer 标签，并设置配置中的标签、污点和注解
 func labelClusterNodes(client *executor.SSHClient, cfg *config.ClusterConfig) error {
 	for _, node := range cfg.Spec.Nodes {
 		labels := fmt.Sprintf("%s=true,%s=%s", DeployerLabel, DeployerVersion, DeployerToolVersion)
@@ -64,6 +65,12 @@ func labelClusterNodes(client *executor.SSHClient, cfg *config.ClusterConfig) er
 			return fmt.Errorf("标记节点 %s 失败: %w", node.Hostname, err)
 		}
 
+		// 节点（及其节点组）配置的标签、污点和注解，kubelet 无权设置的标签也在这里补上
+		meta := config.ResolveNodeMetadata(cfg, &node)
+		if err := applyNodeMetadata(client, node.Hostname, &meta, nil); err != nil {
+			return err
+		}
+
 		ui.SubStep("✓ 节点 %s 已标记", node.Hostname)
 	}
 	return nil
@@ -71,16 +78,8 @@ func labelClusterNodes(client *executor.SSHClient, cfg *config.ClusterConfig) er
 
 // saveConfigToConfigMap 保存配置到 ConfigMap（不含敏感信息）
 func saveConfigToConfigMap(client *executor.SSHClient, cfg *config.ClusterConfig) error {
-	// 创建配置副本，清除敏感信息
-	cfgCopy := *cfg
-	cfgCopy.Spec.Harbor.Username = ""
-	cfgCopy.Spec.Harbor.Password = ""
-	for i := range cfgCopy.Spec.Nodes {
-		cfgCopy.Spec.Nodes[i].SSH.Password = ""
-	}
-
-	// 序列化为 YAML
-	data, err := yaml.Marshal(&cfgCopy)
+	// 序列化清除敏感信息后的配置副本
+	data, err := yaml.Marshal(sanitizeClusterConfig(cfg))
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
@@ -231,16 +230,8 @@ func loadSensitiveInfo(client executor.CommandExecutor, cfg *config.ClusterConfi
 
 // UpdateClusterConfigMap 更新 ConfigMap 中的配置
 func UpdateClusterConfigMap(client executor.CommandExecutor, cfg *config.ClusterConfig) error {
-	// 创建配置副本，清除敏感信息
-	cfgCopy := *cfg
-	cfgCopy.Spec.Harbor.Username = ""
-	cfgCopy.Spec.Harbor.Password = ""
-	for i := range cfgCopy.Spec.Nodes {
-		cfgCopy.Spec.Nodes[i].SSH.Password = ""
-	}
-
-	// 序列化为 YAML
-	data, err := yaml.Marshal(&cfgCopy)
+	// 序列化清除敏感信息后的配置副本
+	data, err := yaml.Marshal(sanitizeClusterConfig(cfg))
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
@@ -284,6 +275,72 @@ func UpdateClusterConfigMap(client executor.CommandExecutor, cfg *config.Cluster
 	return nil
 }
 
+// UpdateClusterConfigMapRemote 通过 Master 节点的 kubectl 更新 ConfigMap 中的配置
+// 与 UpdateClusterConfigMap 不同，该函数在 Linux 节点上执行，用于 upgrade/node 等基于 SSH 的命令
+func UpdateClusterConfigMapRemote(client *executor.SSHClient, cfg *config.ClusterConfig) error {
+	data, err := yaml.Marshal(sanitizeClusterConfig(cfg))
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	// 使用 JSON 编码生成 patch，避免手工转义
+	patch := map[string]interface{}{
+		"data": map[string]string{
+			"cluster.yaml": string(data),
+		},
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				"k8s-deployer.stormdragon.io/updated-at": time.Now().Format(time.RFC3339),
+			},
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("生成 patch 失败: %w", err)
+	}
+
+	tmpFile := "/tmp/k8s-deployer-patch.json"
+	cmd := fmt.Sprintf("cat > %s << 'EOF'\n%s\nEOF", tmpFile, string(patchData))
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer client.Execute(fmt.Sprintf("rm -f %s", tmpFile))
+
+	patchCmd := fmt.Sprintf("kubectl patch configmap %s -n %s --type=merge --patch-file=%s",
+		DeployerConfigMap, DeployerNamespace, tmpFile)
+	if _, err := client.Execute(patchCmd); err != nil {
+		return fmt.Errorf("更新 ConfigMap 失败: %w", err)
+	}
+
+	return nil
+}
+
+// sanitizeClusterConfig 返回清除敏感信息（Harbor 认证、SSH 和跳板机密码）后的配置副本
+// 节点列表和跳板机深拷贝，不影响原配置
+func sanitizeClusterConfig(cfg *config.ClusterConfig) *config.ClusterConfig {
+	cfgCopy := *cfg
+	cfgCopy.Spec.Harbor.Username = ""
+	cfgCopy.Spec.Harbor.Password = ""
+	cfgCopy.Spec.Bastion = sanitizeBastion(cfg.Spec.Bastion)
+	cfgCopy.Spec.Nodes = make([]config.NodeConfig, len(cfg.Spec.Nodes))
+	copy(cfgCopy.Spec.Nodes, cfg.Spec.Nodes)
+	for i := range cfgCopy.Spec.Nodes {
+		cfgCopy.Spec.Nodes[i].SSH.Password = ""
+		cfgCopy.Spec.Nodes[i].SSH.Bastion = sanitizeBastion(cfg.Spec.Nodes[i].SSH.Bastion)
+	}
+	return &cfgCopy
+}
+
+// sanitizeBastion 返回清除密码后的跳板机配置副本
+func sanitizeBastion(bastion *config.BastionConfig) *config.BastionConfig {
+	if bastion == nil {
+		return nil
+	}
+	bastionCopy := *bastion
+	bastionCopy.Password = ""
+	return &bastionCopy
+}
+
 // indentYAML 缩进 YAML 内容
 func indentYAML(content string, spaces int) string {
 	lines := strings.Split(content, "\n")
@@ -1,22 +1,284 @@
 package cluster
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/events"
 	"stormdragon/k8s-deployer/pkg/executor"
 	"stormdragon/k8s-deployer/pkg/kubeadm"
 	"stormdragon/k8s-deployer/pkg/packages"
 	"stormdragon/k8s-deployer/pkg/ui"
 )
 
+// DeployOptions 部署选项
+type DeployOptions struct {
+	AutoConfirm   bool   // 自动确认所有提示
+	SkipSSHSetup  bool   // 跳过 SSH 密钥配置
+	ForceSSHSetup bool   // 强制重新配置 SSH 密钥
+	Resume        bool   // 从上次中断的阶段继续
+	FromPhase     string // 强制重新执行指定阶段
+	SkipPreflight bool   // 跳过部署前的预检
+	DryRun        bool   // 只输出执行计划，不连接或修改任何主机
+	PlanFile      string // dry-run 时将执行计划保存为 JSON 文件（可选）
+}
+
+// 耗时步骤的超时时间，超时后中断远程命令
+const (
+	kubeadmInitTimeout  = 15 * time.Minute
+	kubeadmJoinTimeout  = 10 * time.Minute
+	kubeadmResetTimeout = 5 * time.Minute
+)
+
+// deployPhase 部署阶段
+type deployPhase struct {
+	name     string                    // 阶段名称（用于 --from-phase）
+	title    string                    // 显示标题
+	nodeStep string                    // 阶段内按节点记录的步骤（可选）
+	skip     func(d *deployment) bool  // 返回 true 时跳过该阶段（可选）
+	restore  func(d *deployment)       // 阶段已完成而被跳过时，恢复内存中的状态（可选）
+	run      func(d *deployment) error // 执行阶段
+}
+
+// deployment 一次部署过程的上下文
+type deployment struct {
+	cfg      *config.ClusterConfig
+	opts     DeployOptions
+	state    *DeployState
+	client   *executor.SSHClient  // 第一个 Master 的连接（按需建立）
+	joinInfo *kubeadm.JoinCommand // join 信息（按需获取）
+}
+
+// deployPhases 部署阶段列表（按执行顺序）
+var deployPhases = []deployPhase{
+	{
+		name:  "preflight",
+		title: "部署前预检",
+		skip:  func(d *deployment) bool { return d.opts.SkipPreflight || d.opts.DryRun },
+		run: func(d *deployment) error {
+			if _, err := RunPreflight(d.cfg); err != nil {
+				ui.Warn("请修复失败的检查项后重试，或使用 --skip-preflight 跳过预检")
+				return err
+			}
+			return nil
+		},
+	},
+	{
+		name:  "ssh-keys",
+		title: "配置 SSH 密钥认证",
+		skip:  func(d *deployment) bool { return d.opts.SkipSSHSetup },
+		restore: func(d *deployment) {
+			useRootSSHKeys(d.cfg)
+		},
+		run: func(d *deployment) error {
+			if !needsSSHSetup(d.cfg) && !d.opts.ForceSSHSetup {
+				ui.Info("SSH 密钥已配置，跳过")
+				return nil
+			}
+			ui.Info("检测到使用密码认证，自动配置 root 用户密钥登录...")
+			if err := SetupSSHKeys(d.cfg, d.opts.ForceSSHSetup); err != nil {
+				ui.Warn("您可以：")
+				ui.Warn("  1. 使用 --skip-ssh-setup 跳过此步骤")
+				ui.Warn("  2. 检查节点密码是否正确")
+				ui.Warn("  3. 手动配置 SSH 密钥后重试")
+				return err
+			}
+			// 更新内存中的配置，使用 root + 密钥
+			useRootSSHKeys(d.cfg)
+			ui.Info("后续操作将使用 root 用户免密执行")
+			return nil
+		},
+	},
+	{
+		name:  "hosts",
+		title: "配置集群 Hosts 文件",
+		run: func(d *deployment) error {
+			ui.Info("Kubernetes 节点需要通过主机名互相解析...")
+			if err := SetupHostsFile(d.cfg); err != nil {
+				ui.Warn("您可以手动配置 /etc/hosts 后重试")
+				return err
+			}
+			return nil
+		},
+	},
+	{
+		name:  "ssh-check",
+		title: "检查 SSH 连接",
+		run: func(d *deployment) error {
+			return checkSSHConnections(d.cfg)
+		},
+	},
+	{
+		name:     "prepare",
+		title:    "系统优化和节点准备",
+		nodeStep: nodeStepPrepared,
+		run: func(d *deployment) error {
+			return prepareAllNodes(d.cfg, d.state)
+		},
+	},
+	{
+		name:  "ha",
+		title: "配置高可用负载均衡器",
+		skip:  func(d *deployment) bool { return !d.cfg.Spec.HA.UsesHAProxy() },
+		run: func(d *deployment) error {
+			return setupHAProxy(d.cfg, getFirstMasterIP(d.cfg))
+		},
+	},
+	{
+		name:  "init",
+		title: "初始化第一个 Master 节点",
+		run: func(d *deployment) error {
+			joinInfo, err := initFirstMaster(d.cfg, getFirstMasterIP(d.cfg))
+			if err != nil {
+				return err
+			}
+			d.joinInfo = joinInfo
+			return nil
+		},
+	},
+	{
+		name:     "join-masters",
+		title:    "加入其他 Master 节点",
+		nodeStep: nodeStepJoined,
+		skip: func(d *deployment) bool {
+			return len(getOtherMasters(d.cfg, getFirstMasterIP(d.cfg))) == 0
+		},
+		run: func(d *deployment) error {
+			joinInfo, err := d.getJoinInfo()
+			if err != nil {
+				return err
+			}
+			return joinMasters(d.cfg, getOtherMasters(d.cfg, getFirstMasterIP(d.cfg)), joinInfo, d.state)
+		},
+	},
+	{
+		name:  "kubectl",
+		title: "配置本地 kubectl",
+		run: func(d *deployment) error {
+			client, err := d.masterClient()
+			if err != nil {
+				return err
+			}
+			if err := setupLocalKubectl(client, d.cfg); err != nil {
+				ui.Warning("配置本地 kubectl 失败: %v", err)
+				ui.Info("您可以手动获取 kubeconfig：")
+				ui.Info("  scp root@%s:/etc/kubernetes/admin.conf ~/.kube/config", bracketIP(getFirstMasterIP(d.cfg)))
+			} else {
+				ui.Success("本地 kubectl 配置完成！")
+			}
+			return nil
+		},
+	},
+	{
+		name:  "cilium",
+		title: "安装 Cilium 网络插件",
+		run: func(d *deployment) error {
+			client, err := d.masterClient()
+			if err != nil {
+				return err
+			}
+			return InstallCilium(client, d.cfg, getControlPlaneEndpoint(d.cfg))
+		},
+	},
+	{
+		name:  "metallb",
+		title: "安装 MetalLB LoadBalancer",
+		skip: func(d *deployment) bool {
+			return d.cfg.Spec.LoadBalancer.Provider != "metallb" && !d.cfg.Spec.BGP.Enabled
+		},
+		run: func(d *deployment) error {
+			// 使用本地 kubectl 执行器
+			localClient := executor.NewLocalExecutor()
+			if err := InstallMetalLB(localClient, d.cfg); err != nil {
+				return fmt.Errorf("安装 MetalLB 失败: %w", err)
+			}
+			return nil
+		},
+	},
+	{
+		name:     "join-workers",
+		title:    "加入 Worker 节点",
+		nodeStep: nodeStepJoined,
+		skip:     func(d *deployment) bool { return len(getWorkers(d.cfg)) == 0 },
+		run: func(d *deployment) error {
+			joinInfo, err := d.getJoinInfo()
+			if err != nil {
+				return err
+			}
+			return joinWorkers(d.cfg, getWorkers(d.cfg), joinInfo, d.state)
+		},
+	},
+	{
+		name:  "gpu",
+		title: "配置 GPU 节点",
+		skip:  func(d *deployment) bool { return len(getGPUNodes(d.cfg)) == 0 },
+		run: func(d *deployment) error {
+			client, err := d.masterClient()
+			if err != nil {
+				return err
+			}
+			gpuNodes := getGPUNodes(d.cfg)
+			ui.Info("标记 %d 个 GPU 节点", len(gpuNodes))
+			for _, node := range gpuNodes {
+				if err := LabelGPUNode(client, node.Hostname); err != nil {
+					ui.Warning("标记 GPU 节点 %s 失败: %v", node.Hostname, err)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		name:  "validate",
+		title: "集群验证",
+		run: func(d *deployment) error {
+			client, err := d.masterClient()
+			if err != nil {
+				return err
+			}
+			return validateCluster(client)
+		},
+	},
+	{
+		name:  "save-config",
+		title: "保存集群配置",
+		run: func(d *deployment) error {
+			client, err := d.masterClient()
+			if err != nil {
+				return err
+			}
+			if err := SaveClusterConfig(client, d.cfg); err != nil {
+				ui.Warning("保存集群配置失败: %v", err)
+				ui.Warning("这不影响集群使用，但可能影响后续的 update 命令")
+			}
+			return nil
+		},
+	},
+}
+
+// DeployPhaseNames 返回所有部署阶段名称（按执行顺序）
+func DeployPhaseNames() []string {
+	names := make([]string, len(deployPhases))
+	for i, phase := range deployPhases {
+		names[i] = phase.name
+	}
+	return names
+}
+
 // DeployCluster 部署集群
-func DeployCluster(cfg *config.ClusterConfig, autoConfirm bool) error {
+// 每完成一个阶段（以及阶段内的每个节点）都会记录到 ~/.k8s-deployer/clusters/<name>/state.json，
+// 使用 Resume 时从第一个未完成的阶段继续
+func DeployCluster(cfg *config.ClusterConfig, opts DeployOptions) error {
 	ui.Header(fmt.Sprintf("部署集群: %s (v%s)", cfg.Metadata.Name, cfg.Spec.Version))
 	
 	// 显示集群信息
@@ -36,154 +298,272 @@ func DeployCluster(cfg *config.ClusterConfig, autoConfirm bool) error {
 	}
 	ui.PrintClusterInfo(cfg.Metadata.Name, cfg.Spec.Version, masterCount, workerCount, gpuCount)
 	
+	if opts.DryRun {
+		return dryRunDeploy(cfg, opts)
+	}
+	
+	// 加载或创建部署进度
+	state, err := loadOrCreateDeployState(cfg, opts)
+	if err != nil {
+		return err
+	}
+	
 	// 确认部署
-	if !autoConfirm && !ui.WaitForConfirmation("确认开始部署？") {
+	if !opts.AutoConfirm && !ui.WaitForConfirmation("确认开始部署？") {
 		ui.Warning("部署已取消")
 		return nil
 	}
 	
-	// ========================================
-	// 阶段 1: 基础环境检查和准备
-	// ========================================
-	ui.Header("阶段 1: 基础环境检查和准备")
+	d := &deployment{cfg: cfg, opts: opts, state: state}
+	defer d.close()
 	
-	// 1.1 检查 SSH 连接
-	ui.Step(1, 4, "检查 SSH 连接")
-	if err := checkSSHConnections(cfg); err != nil {
-		return err
+	// Ctrl-C 时取消所有正在执行的远程命令
+	ctx, stop := notifyInterrupt()
+	defer stop()
+	
+	for i, phase := range deployPhases {
+		title := fmt.Sprintf("阶段 %d/%d: %s", i+1, len(deployPhases), phase.title)
+		
+		if state.PhaseDone(phase.name) {
+			ui.Info("✓ %s（已完成，跳过）", title)
+			events.SkipPhase(phase.name, "已完成")
+			if phase.restore != nil {
+				phase.restore(d)
+			}
+			continue
+		}
+		if phase.skip != nil && phase.skip(d) {
+			ui.Info("- %s（不需要，跳过）", title)
+			events.SkipPhase(phase.name, "不需要")
+			continue
+		}
+		
+		// 阶段内忽略了失败的命令时，在进入下一阶段前检查是否已中断
+		if ctx.Err() != nil {
+			printInterrupted(phase.name, state.Path())
+			return fmt.Errorf("部署被中断（阶段 %s）", phase.name)
+		}
+		
+		start := events.StartPhase(phase.name, title)
+		ui.Header(title)
+		err := phase.run(d)
+		events.FinishPhase(phase.name, start, err)
+		if err != nil {
+			if ctx.Err() != nil {
+				printInterrupted(phase.name, state.Path())
+				return fmt.Errorf("部署被中断（阶段 %s）", phase.name)
+			}
+			ui.Warning("部署中断于阶段 %s，进度已保存到 %s", phase.name, state.Path())
+			ui.Warning("修复问题后使用 --resume 参数重新执行 cluster create 即可继续")
+			return fmt.Errorf("阶段 %s 失败: %w", phase.name, err)
+		}
+		
+		if err := state.MarkPhaseDone(phase.name); err != nil {
+			ui.Warning("保存部署进度失败: %v", err)
+		}
 	}
 	
-	// 1.2 系统优化和节点准备
-	ui.Step(2, 4, "系统优化和节点准备")
-	if err := prepareAllNodes(cfg); err != nil {
-		return err
+	// 部署完成后不再需要进度文件和节点上缓存的离线包，避免下次部署时误报未完成
+	if err := state.Remove(); err != nil {
+		ui.Warning("%v", err)
 	}
+	cleanupNodesPackageCache(cfg)
+
+	// 显示完成信息
+	ui.Header("✓ 集群部署完成！")
+	printClusterSummary(cfg, getFirstMasterIP(cfg))
 	
-	// 1.3 配置负载均衡器（如果是 HA）
-	var firstMasterIP string
-	if cfg.Spec.HA.Enabled {
-		ui.Step(3, 4, "配置高可用负载均衡器")
-		firstMasterIP = getFirstMasterIP(cfg)
-		if err := setupHAProxy(cfg, firstMasterIP); err != nil {
-			return err
+	return nil
+}
+
+// notifyInterrupt 捕获 SIGINT/SIGTERM 并取消 executor 使用的 context
+// 第一次 Ctrl-C 取消正在执行的命令，之后恢复默认行为，再次 Ctrl-C 立即退出
+func notifyInterrupt() (context.Context, func()) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	executor.SetContext(ctx)
+	
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+	
+	return ctx, func() {
+		cancel()
+		executor.SetContext(nil)
+	}
+}
+
+// printInterrupted 打印被中断的阶段和节点
+func printInterrupted(phase, statePath string) {
+	ui.Info("")
+	ui.Warning("收到中断信号，部署在阶段 %s 被中断", phase)
+	for _, e := range executor.InterruptedCommands() {
+		if e.Node != "" {
+			ui.Warning("  - 节点 %s: %s", e.Node, e.Command)
+		} else {
+			ui.Warning("  - 本地: %s", e.Command)
 		}
-	} else {
-		firstMasterIP = getFirstMasterIP(cfg)
-		ui.Step(3, 4, "跳过负载均衡器配置（非 HA 模式）")
 	}
-	
-	// ========================================
-	// 阶段 2: 部署 Master 节点和创建集群
-	// ========================================
-	ui.Header("阶段 2: 部署 Master 节点和创建集群")
-	
-	// 2.1 初始化第一个 Master
-	ui.Step(1, 3, "初始化第一个 Master 节点")
-	joinInfo, err := initFirstMaster(cfg, firstMasterIP)
-	if err != nil {
-		return err
+	ui.Warning("进度已保存到 %s", statePath)
+	ui.Warning("使用 --resume 参数重新执行 cluster create 即可从阶段 %s 继续", phase)
+}
+
+// loadOrCreateDeployState 根据选项加载已有进度或创建新的进度
+func loadOrCreateDeployState(cfg *config.ClusterConfig, opts DeployOptions) (*DeployState, error) {
+	if !opts.Resume && opts.FromPhase == "" {
+		if _, err := LoadDeployState(cfg.Metadata.Name); err == nil {
+			ui.Warning("检测到集群 %s 有未完成的部署进度，本次将重新开始部署", cfg.Metadata.Name)
+			ui.Info("如需从中断处继续，请使用 --resume")
+		}
+		return NewDeployState(cfg)
 	}
 	
-	// 2.2 加入其他 Master 节点（如果有）
-	otherMasters := getOtherMasters(cfg, firstMasterIP)
-	if len(otherMasters) > 0 {
-		ui.Step(2, 3, "加入其他 %d 个 Master 节点", len(otherMasters))
-		if err := joinMasters(otherMasters, joinInfo); err != nil {
-			return err
+	var phase *deployPhase
+	if opts.FromPhase != "" {
+		if phase = findDeployPhase(opts.FromPhase); phase == nil {
+			return nil, fmt.Errorf("未知的部署阶段: %s（可选: %s）", opts.FromPhase, strings.Join(DeployPhaseNames(), ", "))
 		}
 	}
-	
-	// ========================================
-	// 阶段 2.5: 配置本地 kubectl
-	// ========================================
-	ui.Header("配置本地 kubectl")
-	client, _ := executor.NewSSHClient(firstMasterIP, 22, "root", cfg.Spec.Nodes[0].SSH.KeyFile)
-	defer client.Close()
-	
-	if err := setupLocalKubectl(client, cfg); err != nil {
-		ui.Warning("配置本地 kubectl 失败: %v", err)
-		ui.Info("您可以手动获取 kubeconfig：")
-		ui.Info("  scp root@%s:/etc/kubernetes/admin.conf ~/.kube/config", firstMasterIP)
+
+	var state *DeployState
+	var err error
+	if !opts.Resume && !deployStateExists(cfg.Metadata.Name) {
+		// 部署完成后进度文件已删除，--from-phase 按所有阶段都已完成处理，只重新执行指定的阶段
+		ui.Info("没有集群 %s 的部署进度（部署已完成），按所有阶段都已完成处理", cfg.Metadata.Name)
+		if state, err = newCompletedDeployState(cfg); err != nil {
+			return nil, err
+		}
 	} else {
-		ui.Success("本地 kubectl 配置完成！")
+		state, err = LoadDeployState(cfg.Metadata.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n提示: 去掉 --resume/--from-phase 参数重新开始部署", err)
+		}
+		if state.Version != cfg.Spec.Version {
+			return nil, fmt.Errorf("配置的版本 %s 与未完成部署的版本 %s 不一致", cfg.Spec.Version, state.Version)
+		}
+		if state.NodesHash != NodesHash(cfg) {
+			return nil, fmt.Errorf("配置中的节点列表与未完成部署时不一致，无法继续\n提示: 恢复原节点列表后继续，或去掉 --resume/--from-phase 参数重新开始部署")
+		}
 	}
 	
-	// ========================================
-	// 阶段 3: 安装 Cilium（替代 kube-proxy）
-	// ========================================
-	ui.Header("阶段 3: 安装 Cilium 网络插件")
+	if phase != nil {
+		if err := state.ResetPhase(phase.name, phase.nodeStep); err != nil {
+			return nil, err
+		}
+		ui.Info("将重新执行阶段: %s", phase.name)
+	}
 	
-	controlPlaneEndpoint := firstMasterIP
-	if cfg.Spec.HA.Enabled {
-		controlPlaneEndpoint = cfg.Spec.HA.VIP
+	ui.Info("从部署进度继续: %s", state.Path())
+	for _, phase := range deployPhases {
+		if state.PhaseDone(phase.name) {
+			ui.Info("  ✓ %s", phase.title)
+		} else {
+			ui.Info("  ○ %s", phase.title)
+		}
 	}
+	ui.Info("")
 	
-	if err := InstallCilium(client, cfg, controlPlaneEndpoint); err != nil {
-		return err
+	return state, nil
+}
+
+// newCompletedDeployState 创建所有阶段和节点步骤都已完成的部署进度
+func newCompletedDeployState(cfg *config.ClusterConfig) (*DeployState, error) {
+	state, err := NewDeployState(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, phase := range deployPhases {
+		state.Phases[phase.name] = state.StartedAt
+	}
+	for _, node := range state.Nodes {
+		node.Steps[nodeStepPrepared] = state.StartedAt
+		node.Steps[nodeStepJoined] = state.StartedAt
 	}
+	return state, state.Save()
+}
 
-	// ========================================
-	// 阶段 3.5: 安装 MetalLB LoadBalancer（如果启用）
-	// ========================================
-	if cfg.Spec.LoadBalancer.Provider == "metallb" || cfg.Spec.BGP.Enabled {
-		ui.Header("阶段 3.5: 安装 MetalLB LoadBalancer")
-		
-		// 使用本地 kubectl 执行器
-		localClient := executor.NewLocalExecutor()
-		if err := InstallMetalLB(localClient, cfg); err != nil {
-			return fmt.Errorf("安装 MetalLB 失败: %w", err)
-		}
+// deployStateExists 检查集群是否有部署进度文件
+func deployStateExists(clusterName string) bool {
+	path, err := GetDeployStatePath(clusterName)
+	if err != nil {
+		return false
 	}
+	_, err = os.Stat(path)
+	return err == nil
+}
 
-	// ========================================
-	// 阶段 4: 加入 Worker 节点
-	// ========================================
-	ui.Header("阶段 4: 加入 Worker 节点")
-	
-	workers := getWorkers(cfg)
-	if len(workers) > 0 {
-		ui.Step(1, 1, "加入 %d 个 Worker 节点", len(workers))
-		if err := joinWorkers(workers, joinInfo); err != nil {
-			return err
+// findDeployPhase 按名称查找部署阶段
+func findDeployPhase(name string) *deployPhase {
+	for i := range deployPhases {
+		if deployPhases[i].name == name {
+			return &deployPhases[i]
 		}
 	}
+	return nil
+}
 
-	// ========================================
-	// 阶段 5: GPU 节点配置
-	// ========================================
-	gpuNodes := getGPUNodes(cfg)
-	if len(gpuNodes) > 0 {
-		ui.Header("阶段 5: 配置 GPU 节点")
-		ui.Step(1, 1, "标记 %d 个 GPU 节点", len(gpuNodes))
-		
-		for _, node := range gpuNodes {
-			if err := LabelGPUNode(client, node.Hostname); err != nil {
-				ui.Warning("标记 GPU 节点 %s 失败: %v", node.Hostname, err)
-			}
-		}
+// masterClient 获取第一个 Master 的连接（首次调用时建立）
+func (d *deployment) masterClient() (*executor.SSHClient, error) {
+	if d.client != nil {
+		return d.client, nil
+	}
+	client, err := connectFirstMaster(d.cfg)
+	if err != nil {
+		return nil, err
 	}
+	client.SetUploadProgress(true)
+	d.client = client
+	return client, nil
+}
 
-	// ========================================
-	// 阶段 6: 验证集群
-	// ========================================
-	ui.Header("阶段 6: 集群验证")
-	if err := validateCluster(client); err != nil {
-		return err
+// getJoinInfo 获取 join 信息；从中断处继续时 init 阶段已跳过，需要重新生成
+func (d *deployment) getJoinInfo() (*kubeadm.JoinCommand, error) {
+	if d.joinInfo != nil {
+		return d.joinInfo, nil
+	}
+	client, err := d.masterClient()
+	if err != nil {
+		return nil, err
+	}
+	
+	ui.SubStep("获取 join 信息...")
+	joinInfo, err := kubeadm.GetJoinInfo(client, getControlPlaneEndpoint(d.cfg), true)
+	if err != nil {
+		ui.SubStepFailed()
+		return nil, err
 	}
+	ui.SubStepDone()
+	
+	d.joinInfo = joinInfo
+	return joinInfo, nil
+}
 
-	// ========================================
-	// 阶段 7: 保存集群配置
-	// ========================================
-	if err := SaveClusterConfig(client, cfg); err != nil {
-		ui.Warning("保存集群配置失败: %v", err)
-		ui.Warning("这不影响集群使用，但可能影响后续的 update 命令")
+func (d *deployment) close() {
+	if d.client != nil {
+		d.client.Close()
 	}
+}
 
-	// 显示完成信息
-	ui.Header("✓ 集群部署完成！")
-	printClusterSummary(cfg, firstMasterIP)
-	
-	return nil
+// needsSSHSetup 检查是否有节点使用密码认证
+func needsSSHSetup(cfg *config.ClusterConfig) bool {
+	for _, node := range cfg.Spec.Nodes {
+		if node.SSH.Password != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// useRootSSHKeys 将使用密码认证的节点改为 root + 密钥（SSH 密钥配置完成后）
+func useRootSSHKeys(cfg *config.ClusterConfig) {
+	keyFile := "/root/.ssh/id_rsa"
+	for i := range cfg.Spec.Nodes {
+		if cfg.Spec.Nodes[i].SSH.Password != "" {
+			cfg.Spec.Nodes[i].SSH.User = "root"
+			cfg.Spec.Nodes[i].SSH.KeyFile = keyFile
+			cfg.Spec.Nodes[i].SSH.Password = "" // 清除密码
+		}
+	}
 }
 
 // checkSSHConnections 检查所有节点的 SSH 连接（并发）
@@ -199,7 +579,7 @@ func checkSSHConnections(cfg *config.ClusterConfig) error {
 			
 			ui.SubStep("[%d/%d] 检查节点 %s (%s)...", idx+1, len(cfg.Spec.Nodes), node.Hostname, node.IP)
 			
-			if err := executor.TestConnection(node.IP, node.SSH.Port, node.SSH.User, node.SSH.KeyFile); err != nil {
+			if err := testNodeConnection(&node); err != nil {
 				ui.SubStepFailed()
 				errChan <- fmt.Errorf("节点 %s SSH 连接失败: %w", node.IP, err)
 				return
@@ -221,38 +601,68 @@ func checkSSHConnections(cfg *config.ClusterConfig) error {
 	return nil
 }
 
+// testNodeConnection 测试节点 SSH 连接（包含主机密钥校验）
+func testNodeConnection(node *config.NodeConfig) error {
+	client, err := connectNode(node)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	
+	_, err = client.Execute("echo 'test'")
+	return err
+}
+
 // prepareAllNodes 准备所有节点（并发，带颜色日志）
-func prepareAllNodes(cfg *config.ClusterConfig) error {
+// 已记录为准备完成的节点会被跳过
+func prepareAllNodes(cfg *config.ClusterConfig, state *DeployState) error {
+	var pending []*config.NodeConfig
+	for i := range cfg.Spec.Nodes {
+		node := &cfg.Spec.Nodes[i]
+		if state.NodeStepDone(node.Hostname, nodeStepPrepared) {
+			ui.Info("✓ 节点 %s 已准备完成，跳过", node.Hostname)
+			continue
+		}
+		pending = append(pending, node)
+	}
+	if len(pending) == 0 {
+		ui.Success("所有节点准备完成！")
+		return nil
+	}
+	
 	var wg sync.WaitGroup
-	errChan := make(chan error, len(cfg.Spec.Nodes))
+	errChan := make(chan error, len(pending))
 	
 	// 创建节点名称列表
-	nodeNames := make([]string, len(cfg.Spec.Nodes))
-	for i, node := range cfg.Spec.Nodes {
+	nodeNames := make([]string, len(pending))
+	for i, node := range pending {
 		nodeNames[i] = node.Hostname
 	}
 	
 	// 创建并发日志器
 	logger := ui.NewSimpleProgressLogger(nodeNames)
 	
-	ui.Info("并发准备 %d 个节点...", len(cfg.Spec.Nodes))
+	ui.Info("并发准备 %d 个节点...", len(pending))
 	ui.Info("")
 	
-	for i := range cfg.Spec.Nodes {
+	for i := range pending {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			node := &cfg.Spec.Nodes[idx]
+			node := pending[idx]
 			
 			logger.Log(node.Hostname, "系统优化中...")
 			
 			// 使用静默版本，避免输出混乱
-			if err := PrepareNodeQuiet(node, cfg.Spec.ImageRepository, cfg.Spec.Version); err != nil {
+			if err := PrepareNodeQuiet(cfg, node); err != nil {
 				logger.Error(node.Hostname, fmt.Sprintf("准备失败: %v", err))
-				errChan <- fmt.Errorf("准备节点 %s 失败: %w", node.Hostname, err)
+				errChan <- events.WrapNode(node.Hostname, "prepare", fmt.Errorf("准备节点 %s 失败: %w", node.Hostname, err))
 				return
 			}
 			
+			if err := state.MarkNodeStepDone(node.Hostname, node.IP, nodeStepPrepared); err != nil {
+				ui.Warning("保存部署进度失败: %v", err)
+			}
 			logger.Success(node.Hostname, "节点准备完成")
 		}(i)
 	}
@@ -274,31 +684,19 @@ func prepareAllNodes(cfg *config.ClusterConfig) error {
 
 // setupHAProxy 配置 HAProxy 负载均衡器
 func setupHAProxy(cfg *config.ClusterConfig, firstMasterIP string) error {
-	client, err := executor.NewSSHClient(firstMasterIP, 22, "root", cfg.Spec.Nodes[0].SSH.KeyFile)
+	client, err := connectFirstMaster(cfg)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 	
-	ui.SubStep("安装 HAProxy...")
-	
-	installScript := `
-		# 检测操作系统
-		if [ -f /etc/os-release ]; then
-			. /etc/os-release
-			OS=$ID
-		fi
-		
-		# 安装 HAProxy
-		if [ "$OS" = "ubuntu" ] || [ "$OS" = "debian" ]; then
-			apt-get update
-			apt-get install -y haproxy
-		elif [ "$OS" = "centos" ] || [ "$OS" = "rhel" ]; then
-			yum install -y haproxy
-		fi
-	`
+	osProfile, err := detectOS(client)
+	if err != nil {
+		return err
+	}
 	
-	if _, err := client.Execute(installScript); err != nil {
+	ui.SubStep("安装 HAProxy...")
+	if err := osProfile.InstallPackages(client, "haproxy"); err != nil {
 		ui.SubStepFailed()
 		return err
 	}
@@ -307,14 +705,40 @@ func setupHAProxy(cfg *config.ClusterConfig, firstMasterIP string) error {
 	ui.SubStep("配置 HAProxy...")
 	
 	// 生成 HAProxy 配置
+	haproxyConfig := generateHAProxyConfig(cfg)
+	
+	// 写入配置
+	tmpFile := "/tmp/haproxy.cfg"
+	cmd := fmt.Sprintf("cat > %s << 'EOF'\n%s\nEOF", tmpFile, haproxyConfig)
+	if _, err := client.Execute(cmd); err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	
+	if _, err := client.Execute("mv /tmp/haproxy.cfg /etc/haproxy/haproxy.cfg"); err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	if err := osProfile.EnableService(client, "haproxy"); err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	ui.SubStepDone()
+	
+	ui.Success("HAProxy 配置完成，VIP: %s", net.JoinHostPort(cfg.Spec.HA.VIP, "6443"))
+	return nil
+}
+
+// generateHAProxyConfig 生成 HAProxy 配置，后端为所有 Master 节点的 API Server
+func generateHAProxyConfig(cfg *config.ClusterConfig) string {
 	var backends strings.Builder
 	for i, node := range cfg.Spec.Nodes {
 		if node.Role == "master" {
-			backends.WriteString(fmt.Sprintf("    server master-%d %s:6443 check\n", i+1, node.IP))
+			backends.WriteString(fmt.Sprintf("    server master-%d %s check\n", i+1, net.JoinHostPort(node.IP, "6443")))
 		}
 	}
 	
-	haproxyConfig := fmt.Sprintf(`
+	return fmt.Sprintf(`
 global
     log /dev/log local0
     chroot /var/lib/haproxy
@@ -334,7 +758,7 @@ defaults
     timeout server  50000
 
 frontend k8s-api
-    bind *:6443
+    bind %s
     mode tcp
     default_backend k8s-api-backend
 
@@ -342,30 +766,12 @@ backend k8s-api-backend
     mode tcp
     balance roundrobin
 %s
-`, backends.String())
-	
-	// 写入配置
-	tmpFile := "/tmp/haproxy.cfg"
-	cmd := fmt.Sprintf("cat > %s << 'EOF'\n%s\nEOF", tmpFile, haproxyConfig)
-	if _, err := client.Execute(cmd); err != nil {
-		ui.SubStepFailed()
-		return err
-	}
-	
-	_, err = client.Execute("mv /tmp/haproxy.cfg /etc/haproxy/haproxy.cfg && systemctl restart haproxy && systemctl enable haproxy")
-	if err != nil {
-		ui.SubStepFailed()
-		return err
-	}
-	ui.SubStepDone()
-	
-	ui.Success("HAProxy 配置完成，VIP: %s:6443", cfg.Spec.HA.VIP)
-	return nil
+`, haproxyBind(cfg, "6443"), backends.String())
 }
 
 // initFirstMaster 初始化第一个 Master 节点
 func initFirstMaster(cfg *config.ClusterConfig, masterIP string) (*kubeadm.JoinCommand, error) {
-	client, err := executor.NewSSHClient(masterIP, 22, "root", cfg.Spec.Nodes[0].SSH.KeyFile)
+	client, err := connectFirstMaster(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -378,7 +784,7 @@ func initFirstMaster(cfg *config.ClusterConfig, masterIP string) (*kubeadm.JoinC
 		ui.Warning("检测到 Master 节点已初始化")
 		ui.Warning("继续将会重置节点并重新初始化集群")
 		ui.Warning("这将导致当前集群不可用！")
-		fmt.Println()
+		ui.Text("")
 		
 	if !ui.WaitForDangerousConfirmation("确认重置并重新初始化？") {
 		return nil, fmt.Errorf("用户取消操作")
@@ -416,7 +822,7 @@ func initFirstMaster(cfg *config.ClusterConfig, masterIP string) (*kubeadm.JoinC
 		sleep 3
 	`
 	
-	if _, err := client.Execute(resetCmd); err != nil {
+	if _, err := client.ExecuteWithTimeout(resetCmd, kubeadmResetTimeout); err != nil {
 		ui.SubStepFailed()
 		return nil, fmt.Errorf("重置节点失败: %w", err)
 	}
@@ -426,6 +832,16 @@ func initFirstMaster(cfg *config.ClusterConfig, masterIP string) (*kubeadm.JoinC
 		ui.Info("  Master 节点未初始化，开始部署")
 	}
 	
+	// kube-vip 需要在 kubeadm init 之前就绪，API Server 启动后即可通过 VIP 访问
+	if cfg.Spec.HA.UsesKubeVIP() {
+		ui.SubStep("生成 kube-vip 静态 Pod...")
+		if err := installKubeVIP(client, cfg, getFirstMasterNode(cfg), true); err != nil {
+			ui.SubStepFailed()
+			return nil, err
+		}
+		ui.SubStepDone()
+	}
+	
 	ui.SubStep("生成 kubeadm 配置...")
 	
 	// 生成 kubeadm 配置
@@ -448,7 +864,7 @@ func initFirstMaster(cfg *config.ClusterConfig, masterIP string) (*kubeadm.JoinC
 	
 	// 执行 kubeadm init，跳过 kube-proxy
 	initCmd := kubeadm.GetInitCommand(tmpFile, []string{"addon/kube-proxy"})
-	if _, err := client.Execute(initCmd); err != nil {
+	if _, err := client.ExecuteWithTimeout(initCmd, kubeadmInitTimeout); err != nil {
 		ui.SubStepFailed()
 		return nil, fmt.Errorf("kubeadm init 失败: %w", err)
 	}
@@ -466,15 +882,19 @@ func initFirstMaster(cfg *config.ClusterConfig, masterIP string) (*kubeadm.JoinC
 	}
 	ui.SubStepDone()
 	
+	if cfg.Spec.HA.UsesKubeVIP() {
+		ui.SubStep("切换 kube-vip 到 admin.conf...")
+		if err := switchKubeVIPToAdminConf(client); err != nil {
+			ui.SubStepFailed()
+			return nil, err
+		}
+		ui.SubStepDone()
+	}
+	
 	ui.SubStep("获取 join 信息...")
 	
 	// 获取 join 信息
-	controlPlaneEndpoint := masterIP + ":6443"
-	if cfg.Spec.HA.Enabled {
-		controlPlaneEndpoint = cfg.Spec.HA.VIP + ":6443"
-	}
-	
-	joinInfo, err := kubeadm.GetJoinInfo(client, controlPlaneEndpoint, true)
+	joinInfo, err := kubeadm.GetJoinInfo(client, getControlPlaneEndpoint(cfg), true)
 	if err != nil {
 		ui.SubStepFailed()
 		return nil, err
@@ -495,6 +915,29 @@ func getFirstMasterIP(cfg *config.ClusterConfig) string {
 	return ""
 }
 
+// getControlPlaneEndpoint 获取控制平面地址（host:port），启用 HA 时使用 VIP 或外部负载均衡器
+func getControlPlaneEndpoint(cfg *config.ClusterConfig) string {
+	return cfg.Spec.HA.ControlPlaneEndpoint(getFirstMasterIP(cfg))
+}
+
+// bracketIP IPv6 地址加方括号（用于 scp 等 host:path 格式）
+func bracketIP(ip string) string {
+	if config.IsIPv6(ip) {
+		return "[" + ip + "]"
+	}
+	return ip
+}
+
+// getFirstMasterNode 获取第一个 Master 节点配置
+func getFirstMasterNode(cfg *config.ClusterConfig) *config.NodeConfig {
+	for i := range cfg.Spec.Nodes {
+		if cfg.Spec.Nodes[i].Role == "master" {
+			return &cfg.Spec.Nodes[i]
+		}
+	}
+	return nil
+}
+
 func getOtherMasters(cfg *config.ClusterConfig, firstMasterIP string) []config.NodeConfig {
 	var masters []config.NodeConfig
 	for _, node := range cfg.Spec.Nodes {
@@ -525,30 +968,53 @@ func getGPUNodes(cfg *config.ClusterConfig) []config.NodeConfig {
 	return gpuNodes
 }
 
-func joinMasters(masters []config.NodeConfig, joinInfo *kubeadm.JoinCommand) error {
+// joinMasters 依次加入其他 Master 节点，已记录为加入完成的节点会被跳过
+func joinMasters(cfg *config.ClusterConfig, masters []config.NodeConfig, joinInfo *kubeadm.JoinCommand, state *DeployState) error {
 	for i, node := range masters {
+		if state.NodeStepDone(node.Hostname, nodeStepJoined) {
+			ui.Info("✓ Master %s 已加入集群，跳过", node.Hostname)
+			continue
+		}
+		
 		ui.SubStep("[%d/%d] 加入 Master: %s...", i+1, len(masters), node.Hostname)
 		
-		client, err := executor.NewSSHClient(node.IP, node.SSH.Port, node.SSH.User, node.SSH.KeyFile)
+		client, err := connectNode(&node)
 		if err != nil {
 			ui.SubStepFailed()
-			return err
+			return events.WrapNode(node.Hostname, "connect", err)
 		}
 		
-		joinCmd := kubeadm.GenerateMasterJoinCommand(joinInfo)
-		if _, err := client.Execute(joinCmd); err != nil {
+		if err := joinNode(client, cfg, &node, joinInfo); err != nil {
 			client.Close()
 			ui.SubStepFailed()
-			return fmt.Errorf("节点 %s 加入失败: %w", node.Hostname, err)
+			return events.WrapNode(node.Hostname, "join", fmt.Errorf("节点 %s 加入失败: %w", node.Hostname, err))
 		}
 		
 		client.Close()
+		if err := state.MarkNodeStepDone(node.Hostname, node.IP, nodeStepJoined); err != nil {
+			ui.Warning("保存部署进度失败: %v", err)
+		}
 		ui.SubStepDone()
 	}
 	return nil
 }
 
-func joinWorkers(workers []config.NodeConfig, joinInfo *kubeadm.JoinCommand) error {
+// joinWorkers 并发加入 Worker 节点
+// 已记录为加入完成的节点会被跳过，避免重复执行时重置健康的节点
+func joinWorkers(cfg *config.ClusterConfig, allWorkers []config.NodeConfig, joinInfo *kubeadm.JoinCommand, state *DeployState) error {
+	var workers []config.NodeConfig
+	for _, node := range allWorkers {
+		if state.NodeStepDone(node.Hostname, nodeStepJoined) {
+			ui.Info("✓ Worker %s 已加入集群，跳过", node.Hostname)
+			continue
+		}
+		workers = append(workers, node)
+	}
+	if len(workers) == 0 {
+		ui.Success("所有 Worker 节点加入完成！")
+		return nil
+	}
+	
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(workers))
 	
@@ -572,10 +1038,10 @@ func joinWorkers(workers []config.NodeConfig, joinInfo *kubeadm.JoinCommand) err
 			
 			logger.Log(node.Hostname, "连接节点...")
 			
-			client, err := executor.NewSSHClient(node.IP, node.SSH.Port, node.SSH.User, node.SSH.KeyFile)
+			client, err := connectNode(&node)
 			if err != nil {
 				logger.Error(node.Hostname, fmt.Sprintf("连接失败: %v", err))
-				errChan <- fmt.Errorf("连接节点 %s 失败: %w", node.Hostname, err)
+				errChan <- events.WrapNode(node.Hostname, "connect", fmt.Errorf("连接节点 %s 失败: %w", node.Hostname, err))
 				return
 			}
 			defer client.Close()
@@ -586,22 +1052,24 @@ func joinWorkers(workers []config.NodeConfig, joinInfo *kubeadm.JoinCommand) err
 				// 节点已加入，需要先重置
 				logger.Log(node.Hostname, "节点已加入集群，执行重置...")
 				resetCmd := "kubeadm reset -f --cri-socket unix:///run/containerd/containerd.sock"
-				if _, err := client.Execute(resetCmd); err != nil {
+				if _, err := client.ExecuteWithTimeout(resetCmd, kubeadmResetTimeout); err != nil {
 					logger.Error(node.Hostname, fmt.Sprintf("重置失败: %v", err))
-					errChan <- fmt.Errorf("节点 %s 重置失败: %w", node.Hostname, err)
+					errChan <- events.WrapNode(node.Hostname, "reset", fmt.Errorf("节点 %s 重置失败: %w", node.Hostname, err))
 					return
 				}
 			}
 			
 			logger.Log(node.Hostname, "执行 join 命令...")
 			
-			joinCmd := kubeadm.GenerateWorkerJoinCommand(joinInfo)
-			if _, err := client.Execute(joinCmd); err != nil {
+			if err := joinNode(client, cfg, &node, joinInfo); err != nil {
 				logger.Error(node.Hostname, fmt.Sprintf("加入失败: %v", err))
-				errChan <- fmt.Errorf("节点 %s 加入失败: %w", node.Hostname, err)
+				errChan <- events.WrapNode(node.Hostname, "join", fmt.Errorf("节点 %s 加入失败: %w", node.Hostname, err))
 				return
 			}
 			
+			if err := state.MarkNodeStepDone(node.Hostname, node.IP, nodeStepJoined); err != nil {
+				ui.Warning("保存部署进度失败: %v", err)
+			}
 			logger.Success(node.Hostname, "成功加入集群")
 		}(i)
 	}
@@ -644,30 +1112,31 @@ func validateCluster(client *executor.SSHClient) error {
 }
 
 func printClusterSummary(cfg *config.ClusterConfig, masterIP string) {
-	apiEndpoint := masterIP + ":6443"
-	if cfg.Spec.HA.Enabled {
-		apiEndpoint = cfg.Spec.HA.VIP + ":6443"
-	}
-	
-	fmt.Printf("\n")
-	fmt.Printf("集群信息:\n")
-	fmt.Printf("  名称: %s\n", cfg.Metadata.Name)
-	fmt.Printf("  版本: %s\n", cfg.Spec.Version)
-	fmt.Printf("  API 地址: https://%s\n", apiEndpoint)
-	fmt.Printf("  CNI: Cilium (kube-proxy replacement)\n")
-	fmt.Printf("  容器运行时: containerd\n")
-	fmt.Printf("\n")
-	fmt.Printf("获取 kubeconfig:\n")
-	fmt.Printf("  $ k8s-deployer cluster kubeconfig %s > ~/.kube/config\n", cfg.Metadata.Name)
-	fmt.Printf("\n")
-	fmt.Printf("验证集群:\n")
-	fmt.Printf("  $ kubectl get nodes\n")
-	fmt.Printf("  $ kubectl -n kube-system get pods | grep cilium\n")
-	fmt.Printf("\n")
+	apiEndpoint := getControlPlaneEndpoint(cfg)
+	
+	ui.Text("")
+	ui.Text("集群信息:")
+	ui.Text("  名称: %s", cfg.Metadata.Name)
+	ui.Text("  版本: %s", cfg.Spec.Version)
+	ui.Text("  API 地址: https://%s", apiEndpoint)
+	ui.Text("  CNI: Cilium (kube-proxy replacement)")
+	ui.Text("  容器运行时: containerd")
+	ui.Text("")
+	ui.Text("获取 kubeconfig:")
+	ui.Text("  $ k8s-deployer cluster kubeconfig %s > ~/.kube/config", cfg.Metadata.Name)
+	ui.Text("")
+	ui.Text("验证集群:")
+	ui.Text("  $ kubectl get nodes")
+	ui.Text("  $ kubectl -n kube-system get pods | grep cilium")
+	ui.Text("")
 }
 
 // setupLocalKubectl 配置本地 kubectl 和 kubeconfig
 func setupLocalKubectl(client *executor.SSHClient, cfg *config.ClusterConfig) error {
+	if recordLocal("从 Master 下载 admin.conf 并写入本地 kubeconfig（备份现有文件）", "~/.kube/config", "") {
+		return nil
+	}
+	
 	ui.Step(1, 3, "检查本地 kubectl")
 	
 	// 检查本地是否已安装 kubectl
@@ -677,8 +1146,13 @@ func setupLocalKubectl(client *executor.SSHClient, cfg *config.ClusterConfig) er
 	if !kubectlExists {
 		ui.SubStep("安装 kubectl...")
 		
-		// 使用包管理器中的 kubectl
-		pkgMgr := packages.NewManagerWithVersion(cfg.Spec.Version)
+		// 使用包管理器中的 kubectl（部署机的架构）
+		pkgMgr, err := packages.NewManagerWithVersion(cfg.Spec.Version)
+		if err != nil {
+			ui.SubStepFailed()
+			return err
+		}
+		pkgMgr = pkgMgr.ForArch(runtime.GOARCH)
 		kubectlPath := pkgMgr.GetPackagePath("kubectl")
 		
 		if !pkgMgr.Exists("kubectl") {
@@ -0,0 +1,284 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/kubeadm"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// DestroyOptions 集群销毁选项
+type DestroyOptions struct {
+	RemoveContainerdData bool // 同时删除 containerd 数据（镜像、快照）
+}
+
+// cleanupNetworkScript 清理 CNI 配置、Cilium 接口和 BPF 状态
+const cleanupNetworkScript = `
+	# CNI 配置和状态
+	rm -rf /etc/cni/net.d/* /var/lib/cni 2>/dev/null || true
+
+	# Cilium 网络接口
+	for dev in cilium_host cilium_net cilium_vxlan cilium_geneve cilium_wg0 cni0 flannel.1 kube-ipvs0; do
+		ip link delete $dev 2>/dev/null || true
+	done
+	for dev in $(ip -o link show 2>/dev/null | awk -F': ' '{print $2}' | cut -d@ -f1 | grep '^lxc'); do
+		ip link delete $dev 2>/dev/null || true
+	done
+
+	# Cilium BPF 状态
+	rm -rf /sys/fs/bpf/tc/globals/cilium_* /sys/fs/bpf/cilium 2>/dev/null || true
+	rm -rf /var/run/cilium /run/cilium 2>/dev/null || true
+
+	# Cilium iptables 规则
+	for tool in iptables ip6tables; do
+		if command -v ${tool}-save >/dev/null 2>&1; then
+			${tool}-save | grep -v CILIUM | ${tool}-restore 2>/dev/null || true
+		fi
+	done
+
+	# IPVS 规则
+	ipvsadm -C 2>/dev/null || true
+`
+
+// cleanupDirsScript 清理 Kubernetes 相关目录
+const cleanupDirsScript = `
+	rm -rf /etc/kubernetes/* 2>/dev/null || true
+	rm -rf /var/lib/kubelet/* 2>/dev/null || true
+	rm -rf /var/lib/etcd/* 2>/dev/null || true
+	rm -rf /root/.kube 2>/dev/null || true
+	rm -rf /tmp/cilium* /tmp/helm* /tmp/*-values.yaml 2>/dev/null || true
+`
+
+// cleanupContainerdScript 清理 containerd 数据（镜像、容器、快照）
+const cleanupContainerdScript = `
+	if command -v crictl >/dev/null 2>&1; then
+		crictl rmp -af 2>/dev/null || true
+		crictl rmi --all 2>/dev/null || true
+	fi
+	systemctl stop containerd 2>/dev/null || true
+	rm -rf /var/lib/containerd/* /run/containerd/* 2>/dev/null || true
+	# 只在安装了 containerd 的节点上重新启动
+	if systemctl list-unit-files containerd.service 2>/dev/null | grep -q containerd.service; then
+		systemctl start containerd
+	fi
+`
+
+// DestroyCluster 销毁集群：重置所有节点并清理本地状态
+func DestroyCluster(cfg *config.ClusterConfig, opts DestroyOptions) error {
+	ui.Header(fmt.Sprintf("销毁集群: %s", cfg.Metadata.Name))
+
+	ui.Warning("将在以下节点上执行 kubeadm reset 并清理所有 Kubernetes 数据：")
+	for _, node := range cfg.Spec.Nodes {
+		ui.Warning("  - %s (%s, %s)", node.Hostname, node.IP, node.Role)
+	}
+	ui.Info("")
+	ui.Info("清理内容：")
+	ui.Info("  - kubeadm reset、etcd 数据、证书、kubelet 数据")
+	ui.Info("  - CNI 配置、Cilium 网络接口和 BPF 状态")
+	if cfg.Spec.HA.UsesKubeVIP() {
+		ui.Info("  - kube-vip 静态 Pod（VIP %s）", cfg.Spec.HA.VIP)
+	} else if cfg.Spec.HA.UsesHAProxy() {
+		ui.Info("  - 停止 keepalived / HAProxy（VIP %s）", cfg.Spec.HA.VIP)
+	}
+	ui.Info("  - /etc/hosts 中由 k8s-deployer 管理的条目")
+	ui.Info("  - 节点上的离线包缓存和离线软件源（%s）", remotePackageDir)
+	ui.Info("  - 本地 kubeconfig 和部署进度")
+	if opts.RemoveContainerdData {
+		ui.Warning("  - containerd 数据（所有镜像和容器）")
+	}
+	ui.Info("")
+
+	if !ui.WaitForDangerousConfirmation(fmt.Sprintf("此操作不可恢复，确认销毁集群 %s？", cfg.Metadata.Name)) {
+		ui.Warning("操作已取消")
+		return nil
+	}
+
+	// 步骤 1: 并发清理所有节点
+	ui.Step(1, 2, "清理集群节点")
+	failed := destroyAllNodes(cfg, opts)
+
+	// 步骤 2: 清理本地状态
+	ui.Step(2, 2, "清理本地状态")
+	cleanupLocalState(cfg)
+
+	if len(failed) > 0 {
+		ui.Warning("以下节点清理失败，请检查后重新执行 cluster destroy：")
+		for _, msg := range failed {
+			ui.Warning("  - %s", msg)
+		}
+		return fmt.Errorf("%d 个节点清理失败", len(failed))
+	}
+
+	ui.Header("✓ 集群已销毁")
+	ui.Info("现在可以重新部署集群:")
+	ui.Info("  k8s-deployer cluster create -f <config> --skip-ssh-setup")
+	return nil
+}
+
+// destroyAllNodes 并发清理所有节点，返回失败信息
+func destroyAllNodes(cfg *config.ClusterConfig, opts DestroyOptions) []string {
+	nodeNames := make([]string, len(cfg.Spec.Nodes))
+	for i, node := range cfg.Spec.Nodes {
+		nodeNames[i] = node.Hostname
+	}
+	logger := ui.NewSimpleProgressLogger(nodeNames)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []string
+	)
+
+	for i := range cfg.Spec.Nodes {
+		wg.Add(1)
+		go func(node *config.NodeConfig) {
+			defer wg.Done()
+			if err := destroyNode(cfg, node, opts, logger); err != nil {
+				logger.Error(node.Hostname, err.Error())
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", node.Hostname, err))
+				mu.Unlock()
+				return
+			}
+			logger.Success(node.Hostname, "清理完成")
+		}(&cfg.Spec.Nodes[i])
+	}
+
+	wg.Wait()
+	ui.Info("")
+	return failed
+}
+
+// destroyNode 清理单个节点
+func destroyNode(cfg *config.ClusterConfig, node *config.NodeConfig, opts DestroyOptions, logger *ui.SimpleProgressLogger) error {
+	logger.Log(node.Hostname, "连接节点...")
+	client, err := connectNode(node)
+	if err != nil {
+		return fmt.Errorf("SSH 连接失败: %w", err)
+	}
+	defer client.Close()
+
+	logger.Log(node.Hostname, "执行 kubeadm reset...")
+	resetCmd := fmt.Sprintf("if command -v kubeadm >/dev/null 2>&1; then %s; fi", kubeadm.GetResetCommand())
+	if _, err := client.Execute(resetCmd); err != nil {
+		return fmt.Errorf("kubeadm reset 失败: %w", err)
+	}
+
+	logger.Log(node.Hostname, "停止 kubelet / keepalived / haproxy...")
+	stopCmd := `
+		systemctl stop kubelet 2>/dev/null || true
+		for svc in keepalived haproxy; do
+			systemctl stop $svc 2>/dev/null || true
+			systemctl disable $svc 2>/dev/null || true
+		done
+	`
+	if _, err := client.Execute(stopCmd); err != nil {
+		return fmt.Errorf("停止服务失败: %w", err)
+	}
+
+	logger.Log(node.Hostname, "清理 CNI 和 Cilium 网络状态...")
+	if _, err := client.Execute(cleanupNetworkScript); err != nil {
+		return fmt.Errorf("清理网络状态失败: %w", err)
+	}
+
+	logger.Log(node.Hostname, "清理 Kubernetes 目录...")
+	if _, err := client.Execute(cleanupDirsScript); err != nil {
+		return fmt.Errorf("清理目录失败: %w", err)
+	}
+
+	if opts.RemoveContainerdData {
+		logger.Log(node.Hostname, "清理 containerd 数据...")
+		if _, err := client.Execute(cleanupContainerdScript); err != nil {
+			return fmt.Errorf("清理 containerd 数据失败: %w", err)
+		}
+	}
+
+	// 离线包缓存中包含离线软件源，同时删除软件源定义，避免 apt/yum 引用不存在的目录
+	logger.Log(node.Hostname, "清理离线包缓存...")
+	if _, err := client.Execute(fmt.Sprintf("rm -rf %s %s %s", remotePackageDir, aptSourceList, yumRepoFile)); err != nil {
+		return fmt.Errorf("清理离线包缓存失败: %w", err)
+	}
+
+	logger.Log(node.Hostname, "清理 hosts 条目...")
+	if err := removeRemoteHostsEntries(client, cfg.Metadata.Name); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// cleanupLocalState 清理本地 hosts、kubeconfig 和部署进度（失败不影响结果）
+func cleanupLocalState(cfg *config.ClusterConfig) {
+	ui.SubStep("清理本地 hosts 条目...")
+	if err := removeLocalHostsEntries(cfg.Metadata.Name); err != nil {
+		ui.SubStepFailed()
+		ui.Warning("清理本地 hosts 失败: %v", err)
+	} else {
+		ui.SubStepDone()
+	}
+
+	ui.SubStep("清理本地 kubeconfig...")
+	if err := cleanupLocalKubeconfig(cfg); err != nil {
+		ui.SubStepFailed()
+		ui.Warning("清理本地 kubeconfig 失败: %v", err)
+	} else {
+		ui.SubStepDone()
+	}
+
+	ui.SubStep("清理部署进度...")
+	if path, err := GetDeployStatePath(cfg.Metadata.Name); err == nil {
+		os.Remove(path)
+	}
+	ui.SubStepDone()
+}
+
+// cleanupLocalKubeconfig 清理 setupLocalKubectl 写入的 kubeconfig
+// 如果 ~/.kube/config 指向本集群，则恢复部署前的备份（没有备份时删除）
+func cleanupLocalKubeconfig(cfg *config.ClusterConfig) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	kubeconfigPath := filepath.Join(homeDir, ".kube", "config")
+	backupPath := kubeconfigPath + ".backup." + cfg.Metadata.Name
+
+	data, err := os.ReadFile(kubeconfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return restoreKubeconfigBackup(backupPath, kubeconfigPath)
+		}
+		return err
+	}
+
+	server := fmt.Sprintf("server: https://%s", getControlPlaneEndpoint(cfg))
+	if !strings.Contains(string(data), server) {
+		// 当前 kubeconfig 不属于本集群，保留备份由用户处理
+		if _, err := os.Stat(backupPath); err == nil {
+			ui.Info("  当前 kubeconfig 不属于本集群，保留备份: %s", backupPath)
+		}
+		return nil
+	}
+
+	if err := os.Remove(kubeconfigPath); err != nil {
+		return err
+	}
+	return restoreKubeconfigBackup(backupPath, kubeconfigPath)
+}
+
+// restoreKubeconfigBackup 恢复部署前备份的 kubeconfig
+func restoreKubeconfigBackup(backupPath, kubeconfigPath string) error {
+	if _, err := os.Stat(backupPath); err != nil {
+		return nil
+	}
+	if err := os.Rename(backupPath, kubeconfigPath); err != nil {
+		return fmt.Errorf("恢复 kubeconfig 备份失败: %w", err)
+	}
+	ui.Info("  已恢复部署前的 kubeconfig: %s", kubeconfigPath)
+	return nil
+}
@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/events"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// newDryRunRecorder 创建 --dry-run 使用的记录器
+// 按全新节点模拟：检查类命令返回失败，需要解析输出的命令返回占位结果
+func newDryRunRecorder(cfg *config.ClusterConfig) *executor.Recorder {
+	rec := executor.NewRecorder()
+
+	for _, prefix := range []string{"test -f ", "test -d ", "which ", "nvidia-smi"} {
+		rec.AddFailure(prefix)
+	}
+
+	placeholderHash := strings.Repeat("0", 64)
+	rec.AddOutput("kubeadm token create", "dryrun.0000000000000000")
+	rec.AddOutput("openssl dgst -sha256", placeholderHash)
+	rec.AddOutput("upload-certs", "[upload-certs] Using certificate key:\n"+placeholderHash)
+	rec.AddOutput("route show to default", "eth0")
+	rec.AddOutput("cat /etc/hosts", hostsMarker(cfg.Metadata.Name))
+	rec.AddOutput("cat /etc/os-release", "ID=ubuntu\nVERSION_ID=\"24.04\"")
+	// 节点架构只能从节点标签 kubernetes.io/arch 得知，没有设置时为 unknown（见 detectArch）
+	for i := range cfg.Spec.Nodes {
+		node := &cfg.Spec.Nodes[i]
+		if arch := config.ResolveNodeMetadata(cfg, node).Labels[archLabel]; arch != "" {
+			rec.AddNodeOutput(node.Hostname, "uname -m", arch)
+		}
+	}
+	rec.AddOutput("uname -m", dryRunUnknownArch)
+	rec.AddOutput("{.status.numberReady}", "1/1")
+	rec.AddOutput("kubectl get gatewayclass", "True")
+	rec.AddOutput("kubectl get gateway default-gateway", "<dry-run>")
+	if cfg.Spec.HA.VIP != "" {
+		rec.AddOutput("ip addr show | grep", cfg.Spec.HA.VIP)
+	}
+
+	// 计划中不输出密码
+	for _, secret := range clusterSecrets(cfg) {
+		rec.AddSecret(secret)
+	}
+
+	return rec
+}
+
+// dryRunDeploy 以记录模式执行所有部署阶段，输出执行计划而不修改任何主机
+func dryRunDeploy(cfg *config.ClusterConfig, opts DeployOptions) error {
+	rec := newDryRunRecorder(cfg)
+	executor.SetRecorder(rec)
+	defer executor.SetRecorder(nil)
+
+	ui.Info("dry-run 模式：只记录将要执行的操作，不会连接或修改任何主机")
+	ui.Info("")
+
+	d := &deployment{cfg: cfg, opts: opts}
+	defer d.close()
+
+	for _, phase := range deployPhases {
+		if phase.skip != nil && phase.skip(d) {
+			events.SkipPhase(phase.name, "不需要")
+			continue
+		}
+		rec.SetPhase(phase.name)
+		start := events.StartPhase(phase.name, phase.title)
+		err := phase.run(d)
+		events.FinishPhase(phase.name, start, err)
+		if err != nil {
+			return fmt.Errorf("生成执行计划失败（阶段 %s）: %w", phase.name, err)
+		}
+	}
+
+	ui.Header(fmt.Sprintf("执行计划: %s", cfg.Metadata.Name))
+	rec.PrintPlan()
+
+	if opts.PlanFile != "" {
+		if err := rec.WriteJSON(opts.PlanFile, cfg.Metadata.Name); err != nil {
+			return err
+		}
+		ui.Success("执行计划已保存到 %s", opts.PlanFile)
+	}
+	return nil
+}
+
+// recordLocal 记录模式下记录本地操作并返回 true，调用方应跳过实际操作
+func recordLocal(description, path, content string) bool {
+	rec := executor.ActiveRecorder()
+	if rec == nil {
+		return false
+	}
+	rec.RecordLocal(description, path, content)
+	return true
+}
@@ -0,0 +1,589 @@
+package cluster
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+const (
+	etcdDataDir      = "/var/lib/etcd"
+	etcdPKIDir       = "/etc/kubernetes/pki/etcd"
+	staticPodDir     = "/etc/kubernetes/manifests"
+	staticPodHoldDir = "/etc/kubernetes/manifests.k8s-deployer" // 恢复期间暂存静态 Pod 清单，kubelet 会停止对应的 Pod
+
+	// 本地备份文件: etcd-<时间>.tar.gz，内含 snapshot.db、pki/ 和 backup-info.json
+	etcdBackupPrefix     = "etcd-"
+	etcdBackupSuffix     = ".tar.gz"
+	etcdBackupTimeFormat = "20060102-150405"
+	etcdSnapshotFile     = "snapshot.db"
+	etcdBackupInfoFile   = "backup-info.json"
+
+	etcdSnapshotTimeout = 5 * time.Minute
+	etcdRestoreTimeout  = 10 * time.Minute
+)
+
+// controlPlaneManifests 除 etcd 外的控制平面静态 Pod（恢复时先于 etcd 停止、晚于 etcd 启动）
+var controlPlaneManifests = []string{"kube-apiserver.yaml", "kube-controller-manager.yaml", "kube-scheduler.yaml"}
+
+// EtcdBackupOptions etcd 备份选项
+type EtcdBackupOptions struct {
+	Node      string        // 执行快照的 Master 节点（为空时使用第一个 Master）
+	OutputDir string        // 本地备份目录（为空时使用 ~/.k8s-deployer/clusters/<name>/backups）
+	Keep      int           // 保留最近的备份个数（0 表示不限制）
+	MaxAge    time.Duration // 删除超过该时间的备份（0 表示不限制）
+}
+
+// EtcdRestoreOptions etcd 恢复选项
+type EtcdRestoreOptions struct {
+	Archive     string // etcd backup 生成的备份文件
+	AutoConfirm bool
+}
+
+// etcdBackupInfo 备份文件中记录的集群信息
+type etcdBackupInfo struct {
+	Cluster   string    `json:"cluster"`
+	Version   string    `json:"version"`
+	Node      string    `json:"node"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BackupEtcd 在 Master 节点上保存 etcd 快照，连同 etcd PKI 打包下载到本地，并按保留规则清理旧备份
+// 返回本地备份文件路径
+func BackupEtcd(cfg *config.ClusterConfig, opts EtcdBackupOptions) (string, error) {
+	node, err := etcdBackupNode(cfg, opts.Node)
+	if err != nil {
+		return "", err
+	}
+
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		clusterDir, err := config.GetClusterDir(cfg.Metadata.Name)
+		if err != nil {
+			return "", fmt.Errorf("创建集群数据目录失败: %w", err)
+		}
+		outputDir = filepath.Join(clusterDir, "backups")
+	}
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return "", fmt.Errorf("创建备份目录失败: %w", err)
+	}
+
+	ui.Info("连接 Master 节点: %s (%s)", node.Hostname, node.IP)
+	client, err := connectNode(node)
+	if err != nil {
+		return "", fmt.Errorf("连接 Master 节点失败: %w", err)
+	}
+	defer client.Close()
+
+	image, err := etcdImage(client)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	name := etcdBackupPrefix + now.Format(etcdBackupTimeFormat)
+	remoteDir := path.Join(remotePackageDir, name)
+	remoteArchive := remoteDir + etcdBackupSuffix
+	defer client.Execute(fmt.Sprintf("rm -rf %s %s", remoteDir, remoteArchive))
+
+	if _, err := client.Execute(fmt.Sprintf("mkdir -p %s && chmod 700 %s", remoteDir, remoteDir)); err != nil {
+		return "", fmt.Errorf("创建远程目录失败: %w", err)
+	}
+
+	// 1. 保存快照
+	ui.SubStep("保存 etcd 快照")
+	snapshot := path.Join(remoteDir, etcdSnapshotFile)
+	saveCmd := fmt.Sprintf("etcdctl --endpoints=https://127.0.0.1:2379 --cacert=%[1]s/ca.crt --cert=%[1]s/server.crt --key=%[1]s/server.key snapshot save %[2]s",
+		etcdPKIDir, snapshot)
+	if _, err := client.ExecuteWithTimeout(etcdContainerCommand(image, "backup", saveCmd, true), etcdSnapshotTimeout); err != nil {
+		ui.SubStepFailed()
+		return "", fmt.Errorf("保存 etcd 快照失败: %w", err)
+	}
+	ui.SubStepDone()
+
+	status, err := client.Execute(etcdContainerCommand(image, "status", "etcdutl snapshot status -w table "+snapshot, false))
+	if err != nil {
+		return "", fmt.Errorf("校验 etcd 快照失败: %w", err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(status), "\n") {
+		ui.Info("  %s", line)
+	}
+
+	// 2. 打包快照、PKI 和集群信息
+	ui.SubStep("打包快照和证书")
+	info, err := json.MarshalIndent(etcdBackupInfo{
+		Cluster:   cfg.Metadata.Name,
+		Version:   cfg.Spec.Version,
+		Node:      node.Hostname,
+		CreatedAt: now,
+	}, "", "  ")
+	if err != nil {
+		ui.SubStepFailed()
+		return "", fmt.Errorf("序列化备份信息失败: %w", err)
+	}
+	packCmd := fmt.Sprintf(`cat > %s << 'EOF'
+%s
+EOF
+tar -czf %s -C %s %s %s -C /etc/kubernetes pki && chmod 600 %s`,
+		path.Join(remoteDir, etcdBackupInfoFile), info,
+		remoteArchive, remoteDir, etcdSnapshotFile, etcdBackupInfoFile, remoteArchive)
+	if _, err := client.Execute(packCmd); err != nil {
+		ui.SubStepFailed()
+		return "", fmt.Errorf("打包备份失败: %w", err)
+	}
+	ui.SubStepDone()
+
+	// 3. 下载到本地（包含私钥，只允许当前用户读取）
+	ui.SubStep("下载备份")
+	localPath := filepath.Join(outputDir, name+etcdBackupSuffix)
+	if err := client.DownloadFile(remoteArchive, localPath); err != nil {
+		ui.SubStepFailed()
+		return "", fmt.Errorf("下载备份失败: %w", err)
+	}
+	if err := os.Chmod(localPath, 0600); err != nil {
+		ui.SubStepFailed()
+		return "", fmt.Errorf("设置备份文件权限失败: %w", err)
+	}
+	ui.SubStepDone()
+
+	// 4. 清理旧备份
+	removed, err := pruneEtcdBackups(outputDir, opts.Keep, opts.MaxAge, now)
+	if err != nil {
+		ui.Warning("清理旧备份失败: %v", err)
+	}
+	for _, file := range removed {
+		ui.Info("已删除旧备份: %s", file)
+	}
+
+	return localPath, nil
+}
+
+// etcdBackupNode 选择执行快照的 Master 节点
+func etcdBackupNode(cfg *config.ClusterConfig, hostname string) (*config.NodeConfig, error) {
+	if hostname == "" {
+		node := getFirstMasterNode(cfg)
+		if node == nil {
+			return nil, fmt.Errorf("配置中没有 Master 节点")
+		}
+		return node, nil
+	}
+	for i := range cfg.Spec.Nodes {
+		node := &cfg.Spec.Nodes[i]
+		if node.Hostname == hostname {
+			if node.Role != "master" {
+				return nil, fmt.Errorf("节点 %s 不是 Master 节点", hostname)
+			}
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("配置中没有节点 %s", hostname)
+}
+
+// etcdImage 从静态 Pod 清单中读取 etcd 镜像（etcdctl/etcdutl 使用同一镜像运行，节点上无需安装）
+func etcdImage(client *executor.SSHClient) (string, error) {
+	output, err := client.Execute(fmt.Sprintf("grep -m1 'image:' %s/etcd.yaml", staticPodDir))
+	if err != nil {
+		return "", fmt.Errorf("读取 etcd 静态 Pod 清单失败（该节点不是 Master 或集群未部署）: %w", err)
+	}
+	image := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(output), "image:"))
+	if image == "" {
+		return "", fmt.Errorf("etcd 静态 Pod 清单中没有镜像")
+	}
+	return image, nil
+}
+
+// etcdContainerCommand 生成在 etcd 镜像中执行命令的 ctr 命令
+// etcd 镜像没有 shell，command 直接作为参数执行；挂载 etcd PKI（只读）、/var/lib（恢复数据目录）和 remotePackageDir（快照文件）
+func etcdContainerCommand(image, task, command string, hostNetwork bool) string {
+	netFlag := ""
+	if hostNetwork {
+		netFlag = "--net-host "
+	}
+	return fmt.Sprintf("ctr -n k8s.io run --rm %s"+
+		"--mount type=bind,src=%[2]s,dst=%[2]s,options=rbind:ro "+
+		"--mount type=bind,src=/var/lib,dst=/var/lib,options=rbind:rw "+
+		"--mount type=bind,src=%[3]s,dst=%[3]s,options=rbind:rw "+
+		"%[4]s k8s-deployer-etcd-%[5]s-%[6]d %[7]s",
+		netFlag, etcdPKIDir, remotePackageDir, image, task, time.Now().UnixNano(), command)
+}
+
+// pruneEtcdBackups 按保留规则删除旧备份，返回被删除的文件
+func pruneEtcdBackups(dir string, keep int, maxAge time.Duration, now time.Time) ([]string, error) {
+	backups, err := listEtcdBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i, backup := range backups {
+		// 从新到旧排列，keep 之后的和超过 maxAge 的都删除
+		expired := maxAge > 0 && now.Sub(backup.createdAt) > maxAge
+		if !(keep > 0 && i >= keep) && !expired {
+			continue
+		}
+		if err := os.Remove(backup.path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backup.path)
+	}
+	return removed, nil
+}
+
+type etcdBackupFile struct {
+	path      string
+	createdAt time.Time
+}
+
+// listEtcdBackups 列出目录中的备份文件（按时间从新到旧）
+func listEtcdBackups(dir string) ([]etcdBackupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []etcdBackupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, etcdBackupPrefix) || !strings.HasSuffix(name, etcdBackupSuffix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, etcdBackupPrefix), etcdBackupSuffix)
+		createdAt, err := time.ParseInLocation(etcdBackupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, etcdBackupFile{path: filepath.Join(dir, name), createdAt: createdAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].createdAt.After(backups[j].createdAt)
+	})
+	return backups, nil
+}
+
+// RestoreEtcd 将快照恢复到所有 Master 节点
+// 流程：上传快照 → 停止所有 Master 的 apiserver 等控制平面组件 → 停止 etcd →
+// 每个节点用 etcdutl 重建数据目录（原目录保留为 /var/lib/etcd.before-restore-<时间>）→
+// 启动所有 etcd → 启动其他控制平面组件 → 等待 API Server 就绪
+func RestoreEtcd(cfg *config.ClusterConfig, opts EtcdRestoreOptions) error {
+	tmpDir, err := os.MkdirTemp("", "k8s-deployer-etcd-restore-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshot := filepath.Join(tmpDir, etcdSnapshotFile)
+	info, err := extractEtcdBackup(opts.Archive, snapshot)
+	if err != nil {
+		return err
+	}
+
+	masters := getMasterNodes(cfg)
+	if len(masters) == 0 {
+		return fmt.Errorf("配置中没有 Master 节点")
+	}
+
+	ui.Header(fmt.Sprintf("恢复 etcd: %s", cfg.Metadata.Name))
+	ui.Info("备份文件: %s", opts.Archive)
+	if info != nil {
+		ui.Info("  集群: %s (%s)", info.Cluster, info.Version)
+		ui.Info("  来源节点: %s", info.Node)
+		ui.Info("  备份时间: %s", info.CreatedAt.Format("2006-01-02 15:04:05"))
+		if info.Cluster != cfg.Metadata.Name {
+			ui.Warning("备份来自集群 %s，与当前集群 %s 不一致", info.Cluster, cfg.Metadata.Name)
+		}
+	}
+	ui.Info("")
+	ui.Warning("将在以下 Master 节点上停止控制平面并用快照替换 etcd 数据：")
+	for _, node := range masters {
+		ui.Warning("  - %s (%s)", node.Hostname, node.IP)
+	}
+	ui.Warning("备份之后的所有集群变更都会丢失")
+	ui.Info("")
+
+	if !opts.AutoConfirm && !ui.WaitForDangerousConfirmation(fmt.Sprintf("确认恢复集群 %s 的 etcd？", cfg.Metadata.Name)) {
+		ui.Warning("操作已取消")
+		return nil
+	}
+
+	clients := make([]*executor.SSHClient, len(masters))
+	images := make([]string, len(masters))
+	defer func() {
+		for _, client := range clients {
+			if client != nil {
+				client.Close()
+			}
+		}
+	}()
+
+	stamp := time.Now().Format(etcdBackupTimeFormat)
+	remoteSnapshot := path.Join(remotePackageDir, "etcd-restore-"+stamp+".db")
+
+	// 1. 连接所有 Master 并上传快照（任何节点失败都不修改集群）
+	ui.Step(1, 5, "上传快照到 Master 节点")
+	for i := range masters {
+		node := &masters[i]
+		client, err := connectNode(node)
+		if err != nil {
+			return fmt.Errorf("连接节点 %s 失败: %w", node.Hostname, err)
+		}
+		clients[i] = client
+
+		if images[i], err = etcdImage(client); err != nil {
+			return fmt.Errorf("节点 %s: %w", node.Hostname, err)
+		}
+		client.SetUploadProgress(true)
+		if err := client.UploadFile(snapshot, remoteSnapshot); err != nil {
+			return fmt.Errorf("上传快照到 %s 失败: %w", node.Hostname, err)
+		}
+		defer client.Execute("rm -f " + remoteSnapshot)
+		ui.Success("  %s", node.Hostname)
+	}
+
+	// 2. 先停止所有 apiserver 等组件，再停止 etcd
+	ui.Step(2, 5, "停止控制平面静态 Pod")
+	for i, node := range masters {
+		if err := holdStaticPods(clients[i], controlPlaneManifests); err != nil {
+			printEtcdRestoreRecovery(stamp)
+			return fmt.Errorf("停止节点 %s 的控制平面失败: %w", node.Hostname, err)
+		}
+	}
+	for i, node := range masters {
+		if err := holdStaticPods(clients[i], []string{"etcd.yaml"}); err != nil {
+			printEtcdRestoreRecovery(stamp)
+			return fmt.Errorf("停止节点 %s 的 etcd 失败: %w", node.Hostname, err)
+		}
+		ui.Success("  %s: 已停止", node.Hostname)
+	}
+
+	// 3. 在每个节点上重建 etcd 数据目录
+	ui.Step(3, 5, "恢复 etcd 数据")
+	initialCluster := etcdInitialCluster(masters)
+	for i := range masters {
+		node := &masters[i]
+		if err := restoreEtcdMember(clients[i], images[i], node, initialCluster, remoteSnapshot, stamp); err != nil {
+			printEtcdRestoreRecovery(stamp)
+			return fmt.Errorf("恢复节点 %s 的 etcd 数据失败: %w", node.Hostname, err)
+		}
+		ui.Success("  %s", node.Hostname)
+	}
+
+	// 4. 先启动所有 etcd 组成集群，再启动其他组件
+	ui.Step(4, 5, "启动 etcd 和控制平面")
+	for i, node := range masters {
+		if err := releaseStaticPods(clients[i], []string{"etcd.yaml"}); err != nil {
+			printEtcdRestoreRecovery(stamp)
+			return fmt.Errorf("启动节点 %s 的 etcd 失败: %w", node.Hostname, err)
+		}
+	}
+	for i, node := range masters {
+		if err := waitForPort(clients[i], "2379"); err != nil {
+			printEtcdRestoreRecovery(stamp)
+			return fmt.Errorf("节点 %s 的 etcd 未启动: %w", node.Hostname, err)
+		}
+	}
+	for i, node := range masters {
+		if err := releaseStaticPods(clients[i], controlPlaneManifests); err != nil {
+			printEtcdRestoreRecovery(stamp)
+			return fmt.Errorf("启动节点 %s 的控制平面失败: %w", node.Hostname, err)
+		}
+		if _, err := clients[i].Execute("systemctl restart kubelet"); err != nil {
+			return fmt.Errorf("重启节点 %s 的 kubelet 失败: %w", node.Hostname, err)
+		}
+	}
+
+	// 5. 等待 API Server 就绪
+	ui.Step(5, 5, "等待 API Server 就绪")
+	if err := waitForAPIServer(clients[0]); err != nil {
+		return err
+	}
+
+	ui.Success("etcd 已恢复到 %s 的快照", backupTimeText(info))
+	ui.Info("原 etcd 数据保留在各 Master 的 %s.before-restore-%s，确认集群正常后可以删除", etcdDataDir, stamp)
+	return nil
+}
+
+// etcdInitialCluster 生成 etcd --initial-cluster 参数（成员名与 kubeadm 一致，使用节点主机名）
+func etcdInitialCluster(masters []config.NodeConfig) string {
+	members := make([]string, len(masters))
+	for i, node := range masters {
+		members[i] = fmt.Sprintf("%s=https://%s", node.Hostname, net.JoinHostPort(node.IP, "2380"))
+	}
+	return strings.Join(members, ",")
+}
+
+// restoreEtcdMember 在单个节点上用快照重建 etcd 数据目录
+// --bump-revision/--mark-compacted 使恢复后的 revision 大于所有客户端见过的值，避免 watch 缓存不一致
+func restoreEtcdMember(client *executor.SSHClient, image string, node *config.NodeConfig, initialCluster, snapshot, stamp string) error {
+	backupDir := fmt.Sprintf("%s.before-restore-%s", etcdDataDir, stamp)
+	if _, err := client.Execute(fmt.Sprintf("if [ -d %[1]s ]; then mv %[1]s %[2]s; fi", etcdDataDir, backupDir)); err != nil {
+		return fmt.Errorf("备份原数据目录失败: %w", err)
+	}
+
+	restoreCmd := fmt.Sprintf("etcdutl snapshot restore %s --name %s --initial-cluster %s --initial-cluster-token k8s-deployer-%s "+
+		"--initial-advertise-peer-urls https://%s --data-dir %s --bump-revision 1000000000 --mark-compacted",
+		snapshot, node.Hostname, initialCluster, stamp, net.JoinHostPort(node.IP, "2380"), etcdDataDir)
+	if _, err := client.ExecuteWithTimeout(etcdContainerCommand(image, "restore", restoreCmd, false), etcdRestoreTimeout); err != nil {
+		return err
+	}
+	if _, err := client.Execute(fmt.Sprintf("chmod 700 %s", etcdDataDir)); err != nil {
+		return fmt.Errorf("设置数据目录权限失败: %w", err)
+	}
+	return nil
+}
+
+// holdStaticPods 将静态 Pod 清单移出 manifests 目录，并等待对应的容器停止
+func holdStaticPods(client *executor.SSHClient, manifests []string) error {
+	cmd := fmt.Sprintf("mkdir -p %s", staticPodHoldDir)
+	for _, manifest := range manifests {
+		cmd += fmt.Sprintf(" && if [ -f %[1]s/%[3]s ]; then mv %[1]s/%[3]s %[2]s/; fi", staticPodDir, staticPodHoldDir, manifest)
+	}
+	if _, err := client.Execute(cmd); err != nil {
+		return err
+	}
+	return waitForStaticPods(client, manifests, false)
+}
+
+// releaseStaticPods 将暂存的静态 Pod 清单移回 manifests 目录
+func releaseStaticPods(client *executor.SSHClient, manifests []string) error {
+	cmd := "true"
+	for _, manifest := range manifests {
+		cmd += fmt.Sprintf(" && if [ -f %[2]s/%[3]s ]; then mv %[2]s/%[3]s %[1]s/; fi", staticPodDir, staticPodHoldDir, manifest)
+	}
+	_, err := client.Execute(cmd)
+	return err
+}
+
+// waitForPort 等待本地端口开始监听，最多 2 分钟
+func waitForPort(client *executor.SSHClient, port string) error {
+	cmd := fmt.Sprintf("for i in $(seq 1 60); do if ss -ltnH 'sport = :%s' | grep -q .; then exit 0; fi; sleep 2; done; exit 1", port)
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("等待端口 %s 监听超时", port)
+	}
+	return nil
+}
+
+// waitForStaticPods 等待静态 Pod 的容器全部运行（running=true）或全部停止，最多 2 分钟
+// 容器名即清单文件名去掉 .yaml。不能用端口判断：HAProxy 在所有地址上监听 6443，apiserver 停止后端口仍然处于监听状态
+func waitForStaticPods(client *executor.SSHClient, manifests []string, running bool) error {
+	if _, err := client.Execute("command -v crictl"); err != nil {
+		return fmt.Errorf("节点上未找到 crictl，无法确认静态 Pod 状态")
+	}
+
+	test, state := "-z", "停止"
+	if running {
+		test, state = "-n", "运行"
+	}
+	names := make([]string, len(manifests))
+	checks := make([]string, len(manifests))
+	for i, manifest := range manifests {
+		names[i] = strings.TrimSuffix(manifest, ".yaml")
+		checks[i] = fmt.Sprintf("[ %s \"$(crictl --runtime-endpoint unix:///run/containerd/containerd.sock ps -q --name '^%s$')\" ]", test, names[i])
+	}
+	cmd := fmt.Sprintf("for i in $(seq 1 60); do if %s; then exit 0; fi; sleep 2; done; exit 1", strings.Join(checks, " && "))
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("等待 %s 容器%s超时", strings.Join(names, "、"), state)
+	}
+	return nil
+}
+
+// waitForAPIServer 等待 API Server 恢复响应，最多 5 分钟
+func waitForAPIServer(client *executor.SSHClient) error {
+	cmd := "for i in $(seq 1 60); do kubectl --kubeconfig=/etc/kubernetes/admin.conf get --raw=/readyz >/dev/null 2>&1 && exit 0; sleep 5; done; exit 1"
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("等待 API Server 就绪超时，请检查控制平面日志（crictl ps -a / journalctl -u kubelet）")
+	}
+	return nil
+}
+
+// printEtcdRestoreRecovery 恢复中途失败时提示手动恢复方法
+func printEtcdRestoreRecovery(stamp string) {
+	ui.Warning("恢复未完成，控制平面可能处于停止状态。手动恢复方法：")
+	ui.Warning("  - 原 etcd 数据: %s.before-restore-%s（已重建的节点）", etcdDataDir, stamp)
+	ui.Warning("  - 暂存的静态 Pod 清单: %s，移回 %s 即可启动", staticPodHoldDir, staticPodDir)
+}
+
+func backupTimeText(info *etcdBackupInfo) string {
+	if info == nil {
+		return "备份"
+	}
+	return info.CreatedAt.Format("2006-01-02 15:04:05")
+}
+
+// extractEtcdBackup 从备份文件中解压快照，返回备份信息（旧格式或直接传入快照时为 nil）
+// 也接受 etcdctl snapshot save 生成的 .db 文件
+func extractEtcdBackup(archive, snapshotPath string) (*etcdBackupInfo, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, fmt.Errorf("读取备份文件失败: %w", err)
+	}
+	defer f.Close()
+
+	if !strings.HasSuffix(archive, etcdBackupSuffix) && !strings.HasSuffix(archive, ".tgz") {
+		return nil, copyToFile(f, snapshotPath)
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("解压备份文件失败: %w", err)
+	}
+	defer gz.Close()
+
+	var (
+		info  *etcdBackupInfo
+		found bool
+	)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解压备份文件失败: %w", err)
+		}
+
+		switch path.Clean(hdr.Name) {
+		case etcdSnapshotFile:
+			if err := copyToFile(tr, snapshotPath); err != nil {
+				return nil, err
+			}
+			found = true
+		case etcdBackupInfoFile:
+			info = &etcdBackupInfo{}
+			if err := json.NewDecoder(tr).Decode(info); err != nil {
+				return nil, fmt.Errorf("解析备份信息失败: %w", err)
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("备份文件中没有 %s: %s", etcdSnapshotFile, archive)
+	}
+	return info, nil
+}
+
+func copyToFile(r io.Reader, dst string) error {
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("写入快照文件失败: %w", err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("写入快照文件失败: %w", err)
+	}
+	return out.Close()
+}
@@ -8,38 +8,61 @@ import (
 	"stormdragon/k8s-deployer/pkg/ui"
 )
 
+// gpuPackageDir 本地 GPU 离线包目录：NVIDIA 驱动包在目录下，nvidia-container-toolkit 包在 nvidia-container-toolkit 子目录
+// 按节点系统使用 .deb 或 .rpm 包
+const gpuPackageDir = "packages/gpu"
+
+// gpuBuildPackages 编译 NVIDIA 内核模块需要的软件包
+var gpuBuildPackages = map[string][]string{
+	osFamilyDebian: {"dkms", "build-essential", "linux-headers-$(uname -r)"},
+	osFamilyRHEL:   {"dkms", "gcc", "make", "kernel-devel-$(uname -r)"},
+}
+
+// gpuDriverHoldPatterns 需要锁定版本的 NVIDIA 驱动软件包
+var gpuDriverHoldPatterns = map[string][]string{
+	osFamilyDebian: {"nvidia-driver-*", "nvidia-dkms-*", "nvidia-kernel-source-*"},
+	osFamilyRHEL:   {"nvidia-driver*", "kmod-nvidia*", "nvidia-kmod*"},
+}
+
 // configureGPU 配置 GPU 节点（完全离线）
 func configureGPU(client *executor.SSHClient) error {
+	osProfile, err := detectOS(client)
+	if err != nil {
+		return err
+	}
+	
 	ui.SubStep("上传 NVIDIA 驱动...")
-	if err := uploadNvidiaDriverPackages(client); err != nil {
+	driverFiles, err := uploadGPUPackages(client, gpuPackageDir, osProfile.PackageExt())
+	if err != nil {
 		ui.SubStepFailed()
 		return err
 	}
 	ui.SubStepDone()
 	
 	ui.SubStep("安装 NVIDIA 驱动...")
-	if err := installNvidiaDriver(client); err != nil {
+	if err := installNvidiaDriver(client, osProfile, driverFiles); err != nil {
 		ui.SubStepFailed()
 		return err
 	}
 	ui.SubStepDone()
 	
 	ui.SubStep("锁定驱动版本...")
-	if err := lockDriverVersion(client); err != nil {
+	if err := osProfile.HoldPackages(client, gpuDriverHoldPatterns[osProfile.Family()]...); err != nil {
 		ui.SubStepFailed()
 		return err
 	}
 	ui.SubStepDone()
 	
 	ui.SubStep("上传 nvidia-container-toolkit...")
-	if err := uploadNvidiaContainerToolkit(client); err != nil {
+	toolkitFiles, err := uploadGPUPackages(client, filepath.Join(gpuPackageDir, "nvidia-container-toolkit"), osProfile.PackageExt())
+	if err != nil {
 		ui.SubStepFailed()
 		return err
 	}
 	ui.SubStepDone()
 	
 	ui.SubStep("安装 nvidia-container-toolkit...")
-	if err := installNvidiaContainerToolkit(client); err != nil {
+	if err := installNvidiaContainerToolkit(client, osProfile, toolkitFiles); err != nil {
 		ui.SubStepFailed()
 		return err
 	}
@@ -62,135 +85,69 @@ func configureGPU(client *executor.SSHClient) error {
 	return nil
 }
 
-// uploadNvidiaDriverPackages 上传 NVIDIA 驱动离线包
-func uploadNvidiaDriverPackages(client *executor.SSHClient) error {
-	// 从本地 packages/gpu/ 目录上传
-	gpuPkgDir := "packages/gpu"
-	
-	driverFiles := []string{
-		"nvidia-driver-580-server-open_580.95.05-0ubuntu0.24.04.2_amd64.deb",
-		"nvidia-dkms-580-server-open_580.95.05-0ubuntu0.24.04.2_amd64.deb",
-		"nvidia-kernel-source-580-server-open_580.95.05-0ubuntu0.24.04.2_amd64.deb",
+// uploadGPUPackages 上传目录下扩展名为 ext 的离线包，返回节点上的路径
+func uploadGPUPackages(client *executor.SSHClient, dir, ext string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s 中没有 %s 离线包", dir, ext)
 	}
 	
-	for _, file := range driverFiles {
-		localPath := filepath.Join(gpuPkgDir, file)
-		remotePath := fmt.Sprintf("/tmp/%s", file)
-		
-		if err := client.UploadFile(localPath, remotePath); err != nil {
-			return fmt.Errorf("上传 %s 失败: %w", file, err)
+	var remoteFiles []string
+	for _, file := range files {
+		remotePath := remotePackagePath(file)
+		if err := client.UploadFile(file, remotePath); err != nil {
+			return nil, fmt.Errorf("上传 %s 失败: %w", filepath.Base(file), err)
 		}
+		remoteFiles = append(remoteFiles, remotePath)
 	}
-	
-	return nil
+	return remoteFiles, nil
 }
 
-// installNvidiaDriver 安装 NVIDIA 驱动（使用离线 deb 包）
-func installNvidiaDriver(client *executor.SSHClient) error {
+// installNvidiaDriver 安装 NVIDIA 驱动（使用离线包）
+func installNvidiaDriver(client *executor.SSHClient, osProfile OSProfile, files []string) error {
 	// 检查是否已安装驱动
 	if _, err := client.Execute("nvidia-smi"); err == nil {
 		ui.Info("  NVIDIA 驱动已安装")
 		return nil
 	}
 	
-	// 使用 dpkg 安装离线包
-	installScript := `
-		cd /tmp
-		
-		# 安装必要的依赖
-		apt-get update
-		apt-get install -y dkms build-essential linux-headers-$(uname -r)
-		
-		# 安装 NVIDIA 驱动离线包
-		dpkg -i nvidia-kernel-source-580-server-open_*.deb || true
-		dpkg -i nvidia-dkms-580-server-open_*.deb || true
-		dpkg -i nvidia-driver-580-server-open_*.deb || true
-		
-		# 修复依赖
-		apt-get install -f -y
-		
-		# 清理临时文件
-		rm -f /tmp/nvidia-*.deb
-		
-		# 验证安装
+	// 安装编译内核模块需要的依赖
+	if err := osProfile.InstallPackages(client, gpuBuildPackages[osProfile.Family()]...); err != nil {
+		return fmt.Errorf("安装驱动编译依赖失败: %w", err)
+	}
+	
+	// 安装 NVIDIA 驱动离线包（包之间的依赖由包管理器处理）
+	if err := osProfile.InstallLocalPackages(client, files...); err != nil {
+		return err
+	}
+	
+	// 验证安装
+	_, err := client.Execute(`
 		sleep 2
 		if ! nvidia-smi > /dev/null 2>&1; then
 			echo "警告: nvidia-smi 尚未可用，可能需要重启系统"
 		fi
-	`
-	
-	_, err := client.Execute(installScript)
+	`)
 	return err
 }
 
-// lockDriverVersion 锁定驱动版本
-func lockDriverVersion(client *executor.SSHClient) error {
-	lockScript := `
-		# 标记软件包为 hold，防止自动升级
-		apt-mark hold nvidia-driver-580-server-open
-		apt-mark hold nvidia-dkms-580-server-open
-		apt-mark hold nvidia-kernel-source-580-server-open
-		
-		echo "✓ NVIDIA 驱动 580-server-open 已锁定"
-	`
-	
-	_, err := client.Execute(lockScript)
-	return err
-}
-
-// uploadNvidiaContainerToolkit 上传 nvidia-container-toolkit 离线包
-func uploadNvidiaContainerToolkit(client *executor.SSHClient) error {
-	// 上传所有 toolkit 相关的 deb 包
-	toolkitDir := "packages/gpu/nvidia-container-toolkit"
-	
-	debFiles := []string{
-		"libnvidia-container1_1.18.0-1_amd64.deb",
-		"libnvidia-container-tools_1.18.0-1_amd64.deb",
-		"nvidia-container-toolkit-base_1.18.0-1_amd64.deb",
-		"nvidia-container-toolkit_1.18.0-1_amd64.deb",
-	}
-	
-	for _, file := range debFiles {
-		localPath := filepath.Join(toolkitDir, file)
-		remotePath := fmt.Sprintf("/tmp/%s", file)
-		
-		if err := client.UploadFile(localPath, remotePath); err != nil {
-			return fmt.Errorf("上传 %s 失败: %w", file, err)
-		}
-	}
-	
-	return nil
-}
-
-// installNvidiaContainerToolkit 安装 nvidia-container-toolkit（使用离线 deb 包）
-func installNvidiaContainerToolkit(client *executor.SSHClient) error {
+// installNvidiaContainerToolkit 安装 nvidia-container-toolkit（使用离线包）
+func installNvidiaContainerToolkit(client *executor.SSHClient, osProfile OSProfile, files []string) error {
 	// 检查是否已安装
 	if _, err := client.Execute("which nvidia-container-runtime"); err == nil {
 		ui.Info("  nvidia-container-toolkit 已安装")
 		return nil
 	}
 	
-	installScript := `
-		cd /tmp
-		
-		# 按顺序安装 deb 包（注意依赖关系）
-		dpkg -i libnvidia-container1_1.18.0-1_amd64.deb || true
-		dpkg -i libnvidia-container-tools_1.18.0-1_amd64.deb || true
-		dpkg -i nvidia-container-toolkit-base_1.18.0-1_amd64.deb || true
-		dpkg -i nvidia-container-toolkit_1.18.0-1_amd64.deb || true
-		
-		# 修复可能的依赖问题
-		apt-get install -f -y
-		
-		# 清理临时文件
-		rm -f /tmp/libnvidia-container*.deb /tmp/nvidia-container-toolkit*.deb
-		
-		# 验证安装
-		which nvidia-container-runtime
-		which nvidia-ctk
-	`
+	if err := osProfile.InstallLocalPackages(client, files...); err != nil {
+		return err
+	}
 	
-	_, err := client.Execute(installScript)
+	// 验证安装
+	_, err := client.Execute("which nvidia-container-runtime && which nvidia-ctk")
 	return err
 }
 
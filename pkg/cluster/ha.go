@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
 	"stormdragon/k8s-deployer/pkg/config"
@@ -40,26 +41,20 @@ func SetupHA(cfg *config.ClusterConfig) error {
 
 // setupHAOnNode 在单个节点上配置 HA
 func setupHAOnNode(cfg *config.ClusterConfig, node *config.NodeConfig, priority int, isMaster bool) error {
-	client, err := executor.NewSSHClientWithPassword(
-		node.IP,
-		node.SSH.Port,
-		node.SSH.User,
-		node.SSH.KeyFile,
-		node.SSH.Password,
-	)
+	client, err := connectNode(node)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 	
+	osProfile, err := detectOS(client)
+	if err != nil {
+		return err
+	}
+	
 	// 1. 安装 Keepalived 和 HAProxy
 	ui.SubStep("安装 Keepalived 和 HAProxy...")
-	installScript := `
-		export DEBIAN_FRONTEND=noninteractive
-		apt-get update -qq
-		apt-get install -y keepalived haproxy
-	`
-	if _, err := client.Execute(installScript); err != nil {
+	if err := osProfile.InstallPackages(client, "keepalived", "haproxy"); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("安装软件包失败: %w", err)
 	}
@@ -87,19 +82,20 @@ func setupHAOnNode(cfg *config.ClusterConfig, node *config.NodeConfig, priority
 	
 	// 4. 启动服务
 	ui.SubStep("启动服务...")
-	startScript := `
-		systemctl enable haproxy
-		systemctl restart haproxy
-		systemctl enable keepalived
-		systemctl restart keepalived
-		
+	for _, service := range []string{"haproxy", "keepalived"} {
+		if err := osProfile.EnableService(client, service); err != nil {
+			ui.SubStepFailed()
+			return fmt.Errorf("启动 %s 失败: %w", service, err)
+		}
+	}
+	
+	// 验证服务状态
+	checkScript := `
 		sleep 2
-		
-		# 验证服务状态
 		systemctl is-active haproxy || exit 1
 		systemctl is-active keepalived || exit 1
 	`
-	if _, err := client.Execute(startScript); err != nil {
+	if _, err := client.Execute(checkScript); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("启动服务失败: %w", err)
 	}
@@ -120,7 +116,7 @@ func configureHAProxy(client *executor.SSHClient, cfg *config.ClusterConfig) err
 	var backends strings.Builder
 	for i, node := range cfg.Spec.Nodes {
 		if node.Role == "master" {
-			backends.WriteString(fmt.Sprintf("    server master-%d %s:6443 check inter 2000 rise 2 fall 3\n", i+1, node.IP))
+			backends.WriteString(fmt.Sprintf("    server master-%d %s check inter 2000 rise 2 fall 3\n", i+1, net.JoinHostPort(node.IP, "6443")))
 		}
 	}
 	
@@ -146,7 +142,7 @@ defaults
 
 # Kubernetes API Server Frontend
 frontend k8s-api
-    bind *:6443
+    bind %s
     mode tcp
     option tcplog
     default_backend k8s-api-backend
@@ -160,13 +156,13 @@ backend k8s-api-backend
 
 # Stats page (可选)
 listen stats
-    bind *:8404
+    bind %s
     mode http
     stats enable
     stats uri /
     stats refresh 10s
     stats auth admin:admin
-`, backends.String())
+`, haproxyBind(cfg, "6443"), backends.String(), haproxyBind(cfg, "8404"))
 	
 	// 写入配置
 	cmd := fmt.Sprintf("cat > /etc/haproxy/haproxy.cfg << 'EOF'\n%s\nEOF", haproxyConfig)
@@ -185,16 +181,12 @@ listen stats
 // configureKeepalived 配置 Keepalived
 func configureKeepalived(client *executor.SSHClient, cfg *config.ClusterConfig, node *config.NodeConfig, state string, priority int) error {
 	// 检测网卡名称
-	output, err := client.Execute("ip -o -4 route show to default | awk '{print $5}' | head -1")
+	interfaceName, err := detectVIPInterface(client, cfg.Spec.HA.VIP)
 	if err != nil {
-		return fmt.Errorf("检测网卡失败: %w", err)
-	}
-	interfaceName := strings.TrimSpace(output)
-	if interfaceName == "" {
-		interfaceName = "eth0" // 默认值
+		return err
 	}
 	
-	// 生成路由 ID（使用 VIP 最后一位）
+	// 生成路由 ID（使用 VIP 最后一个字节）
 	routerID := getRouterID(cfg.Spec.HA.VIP)
 	
 	// 生成认证密码（使用集群名称）
@@ -202,6 +194,16 @@ func configureKeepalived(client *executor.SSHClient, cfg *config.ClusterConfig,
 	if len(authPass) > 8 {
 		authPass = authPass[:8]
 	}
+	// IPv6 VIP 使用 VRRPv3，不支持认证
+	authentication := fmt.Sprintf(`
+    authentication {
+        auth_type PASS
+        auth_pass %s
+    }
+    `, authPass)
+	if config.IsIPv6(cfg.Spec.HA.VIP) {
+		authentication = ""
+	}
 	
 	keepalivedConfig := fmt.Sprintf(`# Keepalived configuration for Kubernetes HA
 global_defs {
@@ -223,12 +225,7 @@ vrrp_instance VI_1 {
     virtual_router_id %d
     priority %d
     advert_int 1
-    
-    authentication {
-        auth_type PASS
-        auth_pass %s
-    }
-    
+    %s
     virtual_ipaddress {
         %s
     }
@@ -237,7 +234,7 @@ vrrp_instance VI_1 {
         check_apiserver
     }
 }
-`, node.Hostname, state, interfaceName, routerID, priority, authPass, cfg.Spec.HA.VIP)
+`, node.Hostname, state, interfaceName, routerID, priority, authentication, cfg.Spec.HA.VIP)
 	
 	// 写入 Keepalived 配置
 	cmd := fmt.Sprintf("cat > /etc/keepalived/keepalived.conf << 'EOF'\n%s\nEOF", keepalivedConfig)
@@ -277,6 +274,23 @@ exit 0
 	return nil
 }
 
+// detectVIPInterface 检测绑定 VIP 的网卡（按 VIP 的地址族查找默认路由）
+func detectVIPInterface(client *executor.SSHClient, vip string) (string, error) {
+	family := "-4"
+	if config.IsIPv6(vip) {
+		family = "-6"
+	}
+	output, err := client.Execute(fmt.Sprintf("ip -o %s route show to default | awk '{print $5}' | head -1", family))
+	if err != nil {
+		return "", fmt.Errorf("检测网卡失败: %w", err)
+	}
+	interfaceName := strings.TrimSpace(output)
+	if interfaceName == "" {
+		interfaceName = "eth0" // 默认值
+	}
+	return interfaceName, nil
+}
+
 // getMasterNodes 获取所有 Master 节点
 func getMasterNodes(cfg *config.ClusterConfig) []config.NodeConfig {
 	var masters []config.NodeConfig
@@ -288,19 +302,24 @@ func getMasterNodes(cfg *config.ClusterConfig) []config.NodeConfig {
 	return masters
 }
 
-// getRouterID 从 VIP 生成 Router ID
+// getRouterID 从 VIP 生成 Router ID（IPv4 和 IPv6 都使用最后一个字节）
 func getRouterID(vip string) int {
-	parts := strings.Split(vip, ".")
-	if len(parts) == 4 {
-		var id int
-		fmt.Sscanf(parts[3], "%d", &id)
-		if id > 0 && id < 256 {
+	if ip := net.ParseIP(vip); ip != nil {
+		if id := int(ip[len(ip)-1]); id > 0 {
 			return id
 		}
 	}
 	return 51 // 默认值
 }
 
+// haproxyBind 生成 HAProxy 的 bind 地址，IPv6 VIP 时同时监听 IPv4 和 IPv6
+func haproxyBind(cfg *config.ClusterConfig, port string) string {
+	if config.IsIPv6(cfg.Spec.HA.VIP) {
+		return ":::" + port + " v4v6"
+	}
+	return "*:" + port
+}
+
 // CheckHAStatus 检查 HA 状态
 func CheckHAStatus(cfg *config.ClusterConfig) error {
 	ui.Header("检查高可用状态")
@@ -310,13 +329,7 @@ func CheckHAStatus(cfg *config.ClusterConfig) error {
 	for i, node := range masterNodes {
 		ui.Step(i+1, len(masterNodes), "检查节点: %s", node.Hostname)
 		
-		client, err := executor.NewSSHClientWithPassword(
-			node.IP,
-			node.SSH.Port,
-			node.SSH.User,
-			node.SSH.KeyFile,
-			node.SSH.Password,
-		)
+		client, err := connectNode(&node)
 		if err != nil {
 			ui.Warning("连接失败: %v", err)
 			continue
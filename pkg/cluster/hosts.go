@@ -39,13 +39,7 @@ func SetupHostsFile(cfg *config.ClusterConfig) error {
 		ui.Step(i+1, len(cfg.Spec.Nodes), "配置节点: %s (%s)", node.Hostname, node.IP)
 		
 		// 建立 SSH 连接
-		client, err := executor.NewSSHClientWithPassword(
-			node.IP,
-			node.SSH.Port,
-			node.SSH.User,
-			node.SSH.KeyFile,
-			node.SSH.Password,
-		)
+		client, err := connectNode(&node)
 		if err != nil {
 			ui.Error("SSH 连接失败: %v", err)
 			return err
@@ -78,8 +72,22 @@ func generateHostsEntries(cfg *config.ClusterConfig) []string {
 	return entries
 }
 
+// hostsMarker 集群 hosts 条目的起始标记
+func hostsMarker(clusterName string) string {
+	return fmt.Sprintf("# === %s Cluster Hosts (Managed by k8s-deployer) ===", clusterName)
+}
+
+// hostsEndMarker 集群 hosts 条目的结束标记
+func hostsEndMarker(clusterName string) string {
+	return fmt.Sprintf("# === End of %s Cluster Hosts ===", clusterName)
+}
+
 // updateLocalHostsFile 更新本地（运行 k8s-deployer 的机器）的 hosts 文件
 func updateLocalHostsFile(entries []string, clusterName string) error {
+	if recordLocal("更新本地 hosts 文件", "/etc/hosts", strings.Join(entries, "\n")) {
+		return nil
+	}
+	
 	// 检查本地 hostname
 	localHostname, err := os.Hostname()
 	if err != nil {
@@ -107,37 +115,19 @@ func updateLocalHostsFile(entries []string, clusterName string) error {
 	}
 	
 	currentHosts := string(hostsData)
-	marker := fmt.Sprintf("# === %s Cluster Hosts (Managed by k8s-deployer) ===", clusterName)
+	marker := hostsMarker(clusterName)
 	
 	// 检查是否已存在相同的集群配置
 	if strings.Contains(currentHosts, marker) {
 		ui.Info("  本地 hosts 已包含集群配置，更新中...")
 		// 删除旧配置
-		lines := strings.Split(currentHosts, "\n")
-		var newLines []string
-		inClusterSection := false
-		endMarker := fmt.Sprintf("# === End of %s Cluster Hosts ===", clusterName)
-		
-		for _, line := range lines {
-			if strings.TrimSpace(line) == marker {
-				inClusterSection = true
-				continue
-			}
-			if strings.TrimSpace(line) == endMarker {
-				inClusterSection = false
-				continue
-			}
-			if !inClusterSection {
-				newLines = append(newLines, line)
-			}
-		}
-		currentHosts = strings.Join(newLines, "\n")
+		currentHosts = stripClusterHostsBlock(currentHosts, clusterName)
 	}
 	
 	// 生成新的 hosts 内容
 	hostsContent := strings.Join(entries, "\n")
-	newHosts := fmt.Sprintf("%s\n%s\n%s\n# === End of %s Cluster Hosts ===\n",
-		currentHosts, marker, hostsContent, clusterName)
+	newHosts := fmt.Sprintf("%s\n%s\n%s\n%s\n",
+		currentHosts, marker, hostsContent, hostsEndMarker(clusterName))
 	
 	// 备份原文件
 	backupFile := fmt.Sprintf("/etc/hosts.backup.k8s-deployer.%s", clusterName)
@@ -179,8 +169,8 @@ func updateRemoteHostsFile(client *executor.SSHClient, entries []string, cluster
 	ui.SubStep("更新 hosts 条目...")
 	
 	// 生成要添加的内容
-	marker := fmt.Sprintf("# === %s Cluster Hosts (Managed by k8s-deployer) ===", clusterName)
-	endMarker := fmt.Sprintf("# === End of %s Cluster Hosts ===", clusterName)
+	marker := hostsMarker(clusterName)
+	endMarker := hostsEndMarker(clusterName)
 	hostsContent := strings.Join(entries, "\n")
 	
 	// 构建智能更新脚本
@@ -192,9 +182,9 @@ func updateRemoteHostsFile(client *executor.SSHClient, entries []string, cluster
 		TMP_HOSTS="/tmp/hosts.tmp.$$"
 		
 		# 读取当前 hosts，过滤掉旧的集群配置
-		awk '
-			/^# === %s Cluster Hosts/ { skip=1; next }
-			/^# === End of %s Cluster Hosts/ { skip=0; next }
+		awk -v start='%s' -v end='%s' '
+			$0 == start { skip=1; next }
+			$0 == end { skip=0; next }
 			!skip { print }
 		' /etc/hosts > "$TMP_HOSTS"
 		
@@ -209,7 +199,7 @@ HOSTS_EOF
 		# 原子性替换（避免并发问题）
 		mv "$TMP_HOSTS" /etc/hosts
 		chmod 644 /etc/hosts
-	`, clusterName, clusterName, marker, hostsContent, endMarker)
+	`, marker, endMarker, marker, hostsContent, endMarker)
 	
 	if _, err := client.Execute(updateScript); err != nil {
 		ui.SubStepFailed()
@@ -235,6 +225,82 @@ HOSTS_EOF
 	return nil
 }
 
+// stripClusterHostsBlock 删除 hosts 内容中由 k8s-deployer 管理的集群条目
+func stripClusterHostsBlock(content, clusterName string) string {
+	marker := hostsMarker(clusterName)
+	endMarker := hostsEndMarker(clusterName)
+	
+	var newLines []string
+	inClusterSection := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == marker {
+			inClusterSection = true
+			continue
+		}
+		if strings.TrimSpace(line) == endMarker {
+			inClusterSection = false
+			continue
+		}
+		if !inClusterSection {
+			newLines = append(newLines, line)
+		}
+	}
+	return strings.Join(newLines, "\n")
+}
+
+// removeLocalHostsEntries 删除本地 /etc/hosts 中的集群条目以及备份文件
+func removeLocalHostsEntries(clusterName string) error {
+	hostsData, err := os.ReadFile("/etc/hosts")
+	if err != nil {
+		return fmt.Errorf("读取 /etc/hosts 失败: %w", err)
+	}
+	
+	marker := hostsMarker(clusterName)
+	if strings.Contains(string(hostsData), marker) {
+		newHosts := stripClusterHostsBlock(string(hostsData), clusterName)
+		
+		tmpFile := fmt.Sprintf("/tmp/hosts.k8s-deployer.%s", clusterName)
+		if err := os.WriteFile(tmpFile, []byte(newHosts), 0644); err != nil {
+			return fmt.Errorf("写入临时文件失败: %w", err)
+		}
+		defer os.Remove(tmpFile)
+		
+		cmd := exec.Command("sudo", "cp", tmpFile, "/etc/hosts")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("更新 /etc/hosts 失败: %s, %w", string(output), err)
+		}
+	}
+	
+	// 删除 updateLocalHostsFile 留下的备份
+	backupFile := fmt.Sprintf("/etc/hosts.backup.k8s-deployer.%s", clusterName)
+	if _, err := os.Stat(backupFile); err == nil {
+		if output, err := exec.Command("sudo", "rm", "-f", backupFile).CombinedOutput(); err != nil {
+			return fmt.Errorf("删除 %s 失败: %s, %w", backupFile, string(output), err)
+		}
+	}
+	
+	return nil
+}
+
+// removeRemoteHostsEntries 删除远程节点 /etc/hosts 中的集群条目
+func removeRemoteHostsEntries(client *executor.SSHClient, clusterName string) error {
+	removeScript := fmt.Sprintf(`
+		TMP_HOSTS="/tmp/hosts.tmp.$$"
+		awk -v start='%s' -v end='%s' '
+			$0 == start { skip=1; next }
+			$0 == end { skip=0; next }
+			!skip { print }
+		' /etc/hosts > "$TMP_HOSTS"
+		mv "$TMP_HOSTS" /etc/hosts
+		chmod 644 /etc/hosts
+	`, hostsMarker(clusterName), hostsEndMarker(clusterName))
+	
+	if _, err := client.Execute(removeScript); err != nil {
+		return fmt.Errorf("清理 hosts 文件失败: %w", err)
+	}
+	return nil
+}
+
 // TestHostsResolution 测试主机名解析
 func TestHostsResolution(client *executor.SSHClient, targetHostname string) error {
 	ui.SubStep("测试解析 %s...", targetHostname)
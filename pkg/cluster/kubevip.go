@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/packages"
+)
+
+//go:embed templates/kube-vip.yaml
+var kubeVIPTemplate string
+
+// kubeVIPManifest kube-vip 静态 Pod 的路径（kubeadm reset 时随 manifests 目录一起清理）
+const kubeVIPManifest = "/etc/kubernetes/manifests/kube-vip.yaml"
+
+// kube-vip 使用的 kubeconfig
+// 第一个 Master 执行 kubeadm init 时 admin.conf 还没有 RBAC 权限，需要先使用 super-admin.conf
+const (
+	kubeVIPAdminConf      = "/etc/kubernetes/admin.conf"
+	kubeVIPSuperAdminConf = "/etc/kubernetes/super-admin.conf"
+)
+
+// KubeVIPConfig kube-vip 静态 Pod 模板参数
+type KubeVIPConfig struct {
+	Image      string // 镜像（镜像仓库/名称:tag）
+	VIP        string
+	VIPSubnet  string // 32 / 128
+	Interface  string // 绑定 VIP 的网卡
+	Loopback   string // kubernetes 主机名解析到的本地地址
+	Kubeconfig string
+	BGP        bool
+	RouterID   string // BGP router ID（节点的 IPv4 地址，没有时由 kube-vip 自动选择）
+	LocalASN   int
+	BGPPeers   string // kube-vip bgp_peers 格式: <地址>:<AS>::false，逗号分隔
+}
+
+// installKubeVIP 在 Master 节点上生成 kube-vip 静态 Pod，需要在 kubeadm init/join 之前执行
+// kubelet 启动后即运行 kube-vip 并绑定 VIP，init 为 true 表示第一个 Master（kubeadm init）
+func installKubeVIP(client *executor.SSHClient, cfg *config.ClusterConfig, node *config.NodeConfig, init bool) error {
+	pkgMgr, err := packages.NewManager()
+	if err != nil {
+		return err
+	}
+	image, ok := pkgMgr.Image("kube-vip", "kube-vip")
+	if !ok {
+		return fmt.Errorf("离线包清单中 kube-vip 缺少镜像 kube-vip")
+	}
+
+	interfaceName, err := detectVIPInterface(client, cfg.Spec.HA.VIP)
+	if err != nil {
+		return err
+	}
+
+	kubeconfig := kubeVIPAdminConf
+	if init {
+		kubeconfig = kubeVIPSuperAdminConf
+	}
+	manifest, err := generateKubeVIPManifest(cfg, node, parseImageRegistry(cfg.Spec.ImageRepository)+"/"+image.Ref(), interfaceName, kubeconfig)
+	if err != nil {
+		return fmt.Errorf("生成 kube-vip 配置失败: %w", err)
+	}
+
+	cmd := fmt.Sprintf("mkdir -p /etc/kubernetes/manifests && cat > %s << 'EOF'\n%s\nEOF", kubeVIPManifest, manifest)
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("写入 kube-vip 静态 Pod 失败: %w", err)
+	}
+	return nil
+}
+
+// switchKubeVIPToAdminConf kubeadm init 完成后将第一个 Master 的 kube-vip 切换为 admin.conf
+func switchKubeVIPToAdminConf(client *executor.SSHClient) error {
+	cmd := fmt.Sprintf("sed -i 's#path: %s#path: %s#' %s", kubeVIPSuperAdminConf, kubeVIPAdminConf, kubeVIPManifest)
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("更新 kube-vip 配置失败: %w", err)
+	}
+	return nil
+}
+
+// generateKubeVIPManifest 生成 kube-vip 静态 Pod，启用 BGP 时使用 spec.bgp 的 AS 号和对等体
+func generateKubeVIPManifest(cfg *config.ClusterConfig, node *config.NodeConfig, image, interfaceName, kubeconfig string) (string, error) {
+	params := KubeVIPConfig{
+		Image:      image,
+		VIP:        cfg.Spec.HA.VIP,
+		VIPSubnet:  "32",
+		Interface:  interfaceName,
+		Loopback:   "127.0.0.1",
+		Kubeconfig: kubeconfig,
+		BGP:        cfg.Spec.BGP.Enabled,
+	}
+	if config.IsIPv6(cfg.Spec.HA.VIP) {
+		params.VIPSubnet = "128"
+		params.Loopback = "::1"
+	}
+
+	if params.BGP {
+		params.LocalASN = cfg.Spec.BGP.LocalASN
+		for _, ip := range node.NodeIPs() {
+			if !config.IsIPv6(ip) {
+				params.RouterID = ip
+				break
+			}
+		}
+		peers := make([]string, len(cfg.Spec.BGP.Peers))
+		for i, peer := range cfg.Spec.BGP.Peers {
+			// IPv6 对等体地址加方括号，避免与分隔符冲突
+			peers[i] = net.JoinHostPort(peer.PeerAddress, strconv.Itoa(peer.PeerASN)) + "::false"
+		}
+		params.BGPPeers = strings.Join(peers, ",")
+	}
+
+	tmpl, err := template.New("kube-vip").Parse(kubeVIPTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -53,7 +53,11 @@ func deployMetalLBHelm(client executor.CommandExecutor, cfg *config.ClusterConfi
 	ui.SubStep("检查 MetalLB Helm chart...")
 
 	// 初始化包管理器
-	pkgMgr := packages.NewManager()
+	pkgMgr, err := packages.NewManager()
+	if err != nil {
+		ui.SubStepFailed()
+		return err
+	}
 
 	// 检查本地 MetalLB Helm chart
 	chartPath := pkgMgr.GetPackagePath("metallb-chart")
@@ -63,18 +67,30 @@ func deployMetalLBHelm(client executor.CommandExecutor, cfg *config.ClusterConfi
 	}
 	ui.SubStepDone()
 
-	// 解析镜像仓库
+	// 解析镜像仓库，镜像 tag 使用离线包清单中的版本
 	imageRegistry := parseImageRegistry(cfg.Spec.ImageRepository)
+	controllerImage, ok := pkgMgr.Image("metallb-chart", "metallb-controller")
+	if !ok {
+		return fmt.Errorf("离线包清单中 metallb-chart 缺少镜像 metallb-controller")
+	}
+	speakerImage, ok := pkgMgr.Image("metallb-chart", "metallb-speaker")
+	if !ok {
+		return fmt.Errorf("离线包清单中 metallb-chart 缺少镜像 metallb-speaker")
+	}
 
 	ui.SubStep("安装 MetalLB...")
 	installCmd := fmt.Sprintf(`helm install metallb %s `+
 		`--namespace metallb-system --create-namespace `+
 		`--set controller.image.registry=%s `+
-		`--set controller.image.repository=metallb-controller `+
+		`--set controller.image.repository=%s `+
+		`--set controller.image.tag=%s `+
 		`--set speaker.image.registry=%s `+
-		`--set speaker.image.repository=metallb-speaker `+
+		`--set speaker.image.repository=%s `+
+		`--set speaker.image.tag=%s `+
 		`--wait`,
-		chartPath, imageRegistry, imageRegistry)
+		chartPath,
+		imageRegistry, controllerImage.Name, controllerImage.Tag,
+		imageRegistry, speakerImage.Name, speakerImage.Tag)
 
 	if _, err := client.Execute(installCmd); err != nil {
 		ui.SubStepFailed()
@@ -10,66 +10,82 @@ import (
 )
 
 // AddNode 添加节点到集群
-func AddNode(masterIP string, masterSSHConfig config.SSHConfig, newNode *config.NodeConfig, imageRepo, controlPlaneEndpoint, k8sVersion string) error {
+// 成功后会更新所有节点的 /etc/hosts，并把新节点记录到集群保存的配置中
+func AddNode(cfg *config.ClusterConfig, newNode *config.NodeConfig) error {
 	ui.Header(fmt.Sprintf("添加节点: %s (%s)", newNode.Hostname, newNode.IP))
-	
-	// 步骤 1: 准备新节点
-	ui.Step(1, 3, "准备节点环境")
-	if err := PrepareNode(newNode, imageRepo, k8sVersion); err != nil {
-		return err
-	}
-	
-	// 步骤 2: 获取 join 信息
-	ui.Step(2, 3, "获取集群 join 信息")
-	
-	masterClient, err := executor.NewSSHClient(masterIP, masterSSHConfig.Port, masterSSHConfig.User, masterSSHConfig.KeyFile)
+
+	masterClient, err := connectFirstMaster(cfg)
 	if err != nil {
-		return fmt.Errorf("连接 master 节点失败: %w", err)
+		return err
 	}
 	defer masterClient.Close()
-	
+
+	// 检查节点是否已在集群中
+	if _, err := masterClient.Execute(fmt.Sprintf("kubectl get node %s", newNode.Hostname)); err == nil {
+		return fmt.Errorf("节点 %s 已存在于集群中", newNode.Hostname)
+	}
+
+	// 新节点加入集群配置（配置文件中可能已经包含该节点）
+	if findNode(cfg, newNode.Hostname) == nil {
+		cfg.Spec.Nodes = append(cfg.Spec.Nodes, *newNode)
+	}
+
+	// 步骤 1: 配置 hosts（新节点需要能解析集群内其他节点）
+	ui.Step(1, 5, "更新所有节点的 hosts 文件")
+	if err := SetupHostsFile(cfg); err != nil {
+		return fmt.Errorf("更新 hosts 文件失败: %w", err)
+	}
+
+	// 步骤 2: 准备新节点
+	ui.Step(2, 5, "准备节点环境")
+	if err := PrepareNode(cfg, newNode); err != nil {
+		return err
+	}
+
+	// 步骤 3: 获取 join 信息
+	ui.Step(3, 5, "获取集群 join 信息")
 	isMaster := (newNode.Role == "master")
-	joinInfo, err := kubeadm.GetJoinInfo(masterClient, controlPlaneEndpoint, isMaster)
+	joinInfo, err := kubeadm.GetJoinInfo(masterClient, getControlPlaneEndpoint(cfg), isMaster)
 	if err != nil {
 		return err
 	}
-	
-	// 步骤 3: 加入集群
-	ui.Step(3, 3, "加入集群")
-	
-	nodeClient, err := executor.NewSSHClient(newNode.IP, newNode.SSH.Port, newNode.SSH.User, newNode.SSH.KeyFile)
+
+	// 步骤 4: 加入集群
+	ui.Step(4, 5, "加入集群")
+
+	nodeClient, err := connectNode(newNode)
 	if err != nil {
 		return fmt.Errorf("连接新节点失败: %w", err)
 	}
 	defer nodeClient.Close()
-	
-	var joinCmd string
+
 	if isMaster {
-		joinCmd = kubeadm.GenerateMasterJoinCommand(joinInfo)
 		ui.Info("加入 Master 节点...")
 	} else {
-		joinCmd = kubeadm.GenerateWorkerJoinCommand(joinInfo)
 		ui.Info("加入 Worker 节点...")
 	}
-	
+
 	ui.SubStep("执行 join 命令...")
-	if _, err := nodeClient.Execute(joinCmd); err != nil {
+	if err := joinNode(nodeClient, cfg, newNode, joinInfo); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("加入集群失败: %w", err)
 	}
 	ui.SubStepDone()
-	
-	// 如果是 GPU 节点，打标签
-	if newNode.GPU {
-		ui.SubStep("标记 GPU 节点...")
-		if err := LabelGPUNode(masterClient, newNode.Hostname); err != nil {
-			ui.SubStepFailed()
-			ui.Warning("标记 GPU 节点失败: %v", err)
-		} else {
-			ui.SubStepDone()
+
+	if err := cleanupRemotePackages(nodeClient); err != nil {
+		ui.Warning("%v", err)
+	}
+
+	// keepalived + HAProxy 模式下新 Master 需要加入第一个 Master 上的 HAProxy 后端才会收到 API 请求
+	if isMaster && cfg.Spec.HA.UsesHAProxy() {
+		if err := updateHAProxyBackends(cfg); err != nil {
+			return fmt.Errorf("更新 HAProxy 配置失败: %w", err)
 		}
 	}
-	
+
+	// GPU 标签、管理标签以及配置的标签、污点和注解
+	labelJoinedNode(masterClient, cfg, newNode)
+
 	// 验证节点状态
 	ui.SubStep("验证节点状态...")
 	output, err := masterClient.Execute(fmt.Sprintf("kubectl get node %s", newNode.Hostname))
@@ -80,25 +96,40 @@ func AddNode(masterIP string, masterSSHConfig config.SSHConfig, newNode *config.
 		ui.SubStepDone()
 		ui.Info("节点状态:\n%s", output)
 	}
-	
+
+	// 步骤 5: 记录到集群配置
+	ui.Step(5, 5, "更新集群配置记录")
+	if err := UpdateClusterConfigMapRemote(masterClient, cfg); err != nil {
+		ui.Warning("更新配置记录失败: %v", err)
+		ui.Warning("这不影响集群使用，但配置记录可能不同步")
+	} else {
+		ui.Success("配置记录已更新")
+	}
+
 	ui.Success("节点 %s 已成功添加到集群！", newNode.Hostname)
 	return nil
 }
 
 // RemoveNode 从集群删除节点
-func RemoveNode(masterIP string, masterSSHConfig config.SSHConfig, nodeName string, reset bool) error {
+// reset 为 true 时会通过 SSH 在节点上执行 kubeadm reset
+func RemoveNode(cfg *config.ClusterConfig, nodeName string, reset bool) error {
 	ui.Header(fmt.Sprintf("删除节点: %s", nodeName))
-	
-	masterClient, err := executor.NewSSHClient(masterIP, masterSSHConfig.Port, masterSSHConfig.User, masterSSHConfig.KeyFile)
+
+	node := findNode(cfg, nodeName)
+	if node != nil && node.IP == getFirstMasterIP(cfg) {
+		return fmt.Errorf("不能删除第一个 Master 节点 %s", nodeName)
+	}
+
+	masterClient, err := connectFirstMaster(cfg)
 	if err != nil {
-		return fmt.Errorf("连接 master 节点失败: %w", err)
+		return err
 	}
 	defer masterClient.Close()
-	
+
 	// 步骤 1: Drain 节点
-	ui.Step(1, 3, "驱逐节点上的 Pod")
+	ui.Step(1, 4, "驱逐节点上的 Pod")
 	ui.SubStep("执行 kubectl drain...")
-	
+
 	drainCmd := fmt.Sprintf("kubectl drain %s --delete-emptydir-data --ignore-daemonsets --force --timeout=300s", nodeName)
 	if _, err := masterClient.Execute(drainCmd); err != nil {
 		ui.SubStepFailed()
@@ -107,102 +138,211 @@ func RemoveNode(masterIP string, masterSSHConfig config.SSHConfig, nodeName stri
 	} else {
 		ui.SubStepDone()
 	}
-	
+
 	// 步骤 2: Delete 节点
-	ui.Step(2, 3, "从集群删除节点")
+	ui.Step(2, 4, "从集群删除节点")
 	ui.SubStep("执行 kubectl delete node...")
-	
+
 	deleteCmd := fmt.Sprintf("kubectl delete node %s", nodeName)
 	if _, err := masterClient.Execute(deleteCmd); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("删除节点失败: %w", err)
 	}
 	ui.SubStepDone()
-	
+
 	// 步骤 3: 可选的 reset 操作
-	if reset {
-		ui.Step(3, 3, "重置节点（可选）")
-		ui.Warning("需要手动在节点上执行: kubeadm reset -f")
-		// 如果有节点的 SSH 信息，可以在这里执行 reset
+	switch {
+	case reset && node != nil:
+		ui.Step(3, 4, "重置节点")
+		if err := resetRemovedNode(node); err != nil {
+			ui.Warning("重置节点失败: %v", err)
+			ui.Warning("需要手动在节点上执行: %s", kubeadm.GetResetCommand())
+		}
+	case reset:
+		ui.Step(3, 4, "重置节点")
+		ui.Warning("配置中没有节点 %s 的 SSH 信息", nodeName)
+		ui.Warning("需要手动在节点上执行: %s", kubeadm.GetResetCommand())
+	default:
+		ui.Step(3, 4, "跳过节点重置")
+	}
+
+	// 步骤 4: 从集群配置中移除
+	ui.Step(4, 4, "更新集群配置记录")
+	if node != nil {
+		removeNodeFromConfig(cfg, nodeName)
+		if err := UpdateClusterConfigMapRemote(masterClient, cfg); err != nil {
+			ui.Warning("更新配置记录失败: %v", err)
+		} else {
+			ui.Success("配置记录已更新")
+		}
 	} else {
-		ui.Step(3, 3, "跳过节点重置")
+		ui.Info("配置中没有节点 %s，跳过", nodeName)
 	}
-	
+
 	ui.Success("节点 %s 已从集群删除！", nodeName)
 	return nil
 }
 
+// resetRemovedNode 在已删除的节点上执行 kubeadm reset
+func resetRemovedNode(node *config.NodeConfig) error {
+	client, err := connectNode(node)
+	if err != nil {
+		return fmt.Errorf("SSH 连接失败: %w", err)
+	}
+	defer client.Close()
+
+	ui.SubStep("执行 kubeadm reset...")
+	if _, err := client.Execute(kubeadm.GetResetCommand()); err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	ui.SubStepDone()
+	return nil
+}
+
 // ListNodes 列出集群的所有节点
-func ListNodes(masterIP string, masterSSHConfig config.SSHConfig) error {
-	client, err := executor.NewSSHClient(masterIP, masterSSHConfig.Port, masterSSHConfig.User, masterSSHConfig.KeyFile)
+func ListNodes(cfg *config.ClusterConfig) error {
+	client, err := connectFirstMaster(cfg)
 	if err != nil {
-		return fmt.Errorf("连接 master 节点失败: %w", err)
+		return err
 	}
 	defer client.Close()
-	
+
 	ui.Info("获取节点列表...")
 	output, err := client.Execute("kubectl get nodes -o wide")
 	if err != nil {
 		return fmt.Errorf("获取节点列表失败: %w", err)
 	}
-	
-	fmt.Println(output)
+
+	ui.Text("%s", output)
 	return nil
 }
 
 // GetNodeInfo 获取节点详细信息
-func GetNodeInfo(masterIP string, masterSSHConfig config.SSHConfig, nodeName string) error {
-	client, err := executor.NewSSHClient(masterIP, masterSSHConfig.Port, masterSSHConfig.User, masterSSHConfig.KeyFile)
+func GetNodeInfo(cfg *config.ClusterConfig, nodeName string) error {
+	client, err := connectFirstMaster(cfg)
 	if err != nil {
-		return fmt.Errorf("连接 master 节点失败: %w", err)
+		return err
 	}
 	defer client.Close()
-	
+
 	ui.Info("获取节点详细信息: %s", nodeName)
-	
+
 	// 基本信息
 	output, err := client.Execute(fmt.Sprintf("kubectl describe node %s", nodeName))
 	if err != nil {
 		return fmt.Errorf("获取节点信息失败: %w", err)
 	}
-	
-	fmt.Println(output)
+
+	ui.Text("%s", output)
 	return nil
 }
 
 // CordonNode 标记节点为不可调度
-func CordonNode(masterIP string, masterSSHConfig config.SSHConfig, nodeName string) error {
-	client, err := executor.NewSSHClient(masterIP, masterSSHConfig.Port, masterSSHConfig.User, masterSSHConfig.KeyFile)
+func CordonNode(cfg *config.ClusterConfig, nodeName string) error {
+	client, err := connectFirstMaster(cfg)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
-	
+
 	ui.Info("标记节点 %s 为不可调度...", nodeName)
 	_, err = client.Execute(fmt.Sprintf("kubectl cordon %s", nodeName))
 	if err != nil {
 		return fmt.Errorf("cordon 节点失败: %w", err)
 	}
-	
+
 	ui.Success("节点 %s 已标记为不可调度", nodeName)
 	return nil
 }
 
 // UncordonNode 取消节点不可调度标记
-func UncordonNode(masterIP string, masterSSHConfig config.SSHConfig, nodeName string) error {
-	client, err := executor.NewSSHClient(masterIP, masterSSHConfig.Port, masterSSHConfig.User, masterSSHConfig.KeyFile)
+func UncordonNode(cfg *config.ClusterConfig, nodeName string) error {
+	client, err := connectFirstMaster(cfg)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
-	
+
 	ui.Info("取消节点 %s 的不可调度标记...", nodeName)
 	_, err = client.Execute(fmt.Sprintf("kubectl uncordon %s", nodeName))
 	if err != nil {
 		return fmt.Errorf("uncordon 节点失败: %w", err)
 	}
-	
+
 	ui.Success("节点 %s 已恢复调度", nodeName)
 	return nil
 }
 
+// LoadClusterConfigFromMaster 通过 Master 节点读取集群保存的配置
+// ConfigMap 中不保存 SSH 密码，未配置认证方式的节点使用 master 的 SSH 配置
+// bastion 为连接 Master 使用的跳板机（可为 nil），调用前需要先执行 ConfigureSSH
+func LoadClusterConfigFromMaster(master *config.NodeConfig, bastion *config.BastionConfig) (*config.ClusterConfig, error) {
+	client, err := connectNode(master)
+	if err != nil {
+		return nil, fmt.Errorf("连接 master 节点失败: %w", err)
+	}
+	defer client.Close()
+
+	cfg, err := LoadClusterConfig(client, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cfg.Spec.Nodes {
+		ssh := &cfg.Spec.Nodes[i].SSH
+		if ssh.KeyFile == "" && ssh.Password == "" {
+			nodeBastion := ssh.Bastion
+			*ssh = master.SSH
+			ssh.Bastion = nodeBastion
+		}
+	}
+	// 命令行指定的跳板机覆盖集群保存的跳板机
+	// ConfigMap 中不保存跳板机密码，未配置认证方式的节点跳板机使用命令行指定的跳板机认证
+	if bastion != nil {
+		cfg.Spec.Bastion = bastion
+		for i := range cfg.Spec.Nodes {
+			nodeBastion := cfg.Spec.Nodes[i].SSH.Bastion
+			if nodeBastion != nil && nodeBastion.KeyFile == "" && nodeBastion.Password == "" {
+				nodeBastion.KeyFile = bastion.KeyFile
+				nodeBastion.Password = bastion.Password
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// connectFirstMaster 连接集群的第一个 Master 节点
+func connectFirstMaster(cfg *config.ClusterConfig) (*executor.SSHClient, error) {
+	master := getFirstMasterNode(cfg)
+	if master == nil {
+		return nil, fmt.Errorf("配置中没有 Master 节点")
+	}
+
+	client, err := connectNode(master)
+	if err != nil {
+		return nil, fmt.Errorf("连接 master 节点失败: %w", err)
+	}
+	return client, nil
+}
+
+// findNode 按主机名查找节点配置
+func findNode(cfg *config.ClusterConfig, hostname string) *config.NodeConfig {
+	for i := range cfg.Spec.Nodes {
+		if cfg.Spec.Nodes[i].Hostname == hostname {
+			return &cfg.Spec.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// removeNodeFromConfig 从集群配置中移除节点
+func removeNodeFromConfig(cfg *config.ClusterConfig, hostname string) {
+	nodes := cfg.Spec.Nodes[:0]
+	for _, node := range cfg.Spec.Nodes {
+		if node.Hostname != hostname {
+			nodes = append(nodes, node)
+		}
+	}
+	cfg.Spec.Nodes = nodes
+}
@@ -0,0 +1,218 @@
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/kubeadm"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// joinConfigFile 节点上 kubeadm join 配置文件的路径
+const joinConfigFile = "/tmp/kubeadm-join.yaml"
+
+// joinNode 使用 JoinConfiguration 将节点加入集群，节点（及其节点组）的标签和污点通过 nodeRegistration 设置
+// Master 节点需要 joinInfo.CertificateKey，Worker 节点会忽略该字段
+func joinNode(client *executor.SSHClient, cfg *config.ClusterConfig, node *config.NodeConfig, joinInfo *kubeadm.JoinCommand) error {
+	info := *joinInfo
+	if node.Role != "master" {
+		info.CertificateKey = ""
+	}
+
+	// kube-vip 随 Master 的 kubelet 启动，需要在 join 之前生成静态 Pod
+	if node.Role == "master" && cfg.Spec.HA.UsesKubeVIP() {
+		if err := installKubeVIP(client, cfg, node, false); err != nil {
+			return err
+		}
+	}
+
+	joinConfig, err := kubeadm.GenerateJoinConfig(&info, kubeadm.NewNodeRegistration(cfg, node))
+	if err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf("cat > %s << 'EOF'\n%s\nEOF", joinConfigFile, joinConfig)
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("上传 join 配置失败: %w", err)
+	}
+	defer client.Execute("rm -f " + joinConfigFile)
+
+	_, err = client.ExecuteWithTimeout(kubeadm.GetJoinCommand(joinConfigFile), kubeadmJoinTimeout)
+	return err
+}
+
+// applyNodeMetadata 使用 kubectl 设置节点的标签、污点和注解
+// old 为上次应用的配置，其中有而 meta 中没有的 key 会从节点上删除；old 为 nil 时只添加和更新
+func applyNodeMetadata(client executor.CommandExecutor, nodeName string, meta, old *config.NodeMetadata) error {
+	if old == nil {
+		old = &config.NodeMetadata{}
+	}
+
+	// 标签
+	var labelArgs []string
+	for _, key := range config.SortedKeys(meta.Labels) {
+		labelArgs = append(labelArgs, key+"="+shellQuote(meta.Labels[key]))
+	}
+	for _, key := range config.SortedKeys(old.Labels) {
+		if _, ok := meta.Labels[key]; !ok {
+			labelArgs = append(labelArgs, key+"-")
+		}
+	}
+	if len(labelArgs) > 0 {
+		cmd := fmt.Sprintf("kubectl label node %s %s --overwrite", nodeName, strings.Join(labelArgs, " "))
+		if _, err := client.Execute(cmd); err != nil {
+			return fmt.Errorf("设置节点 %s 的标签失败: %w", nodeName, err)
+		}
+	}
+
+	// 注解
+	var annotationArgs []string
+	for _, key := range config.SortedKeys(meta.Annotations) {
+		annotationArgs = append(annotationArgs, key+"="+shellQuote(meta.Annotations[key]))
+	}
+	for _, key := range config.SortedKeys(old.Annotations) {
+		if _, ok := meta.Annotations[key]; !ok {
+			annotationArgs = append(annotationArgs, key+"-")
+		}
+	}
+	if len(annotationArgs) > 0 {
+		cmd := fmt.Sprintf("kubectl annotate node %s %s --overwrite", nodeName, strings.Join(annotationArgs, " "))
+		if _, err := client.Execute(cmd); err != nil {
+			return fmt.Errorf("设置节点 %s 的注解失败: %w", nodeName, err)
+		}
+	}
+
+	// 污点（按 key+effect 区分，删除时节点上已不存在的污点不算错误）
+	for _, taint := range old.Taints {
+		if taintIndex(meta.Taints, taint) >= 0 {
+			continue
+		}
+		cmd := fmt.Sprintf("kubectl taint node %s %s:%s-", nodeName, taint.Key, taint.Effect)
+		if _, err := client.Execute(cmd); err != nil && !strings.Contains(err.Error(), "not found") {
+			return fmt.Errorf("删除节点 %s 的污点 %s 失败: %w", nodeName, taint, err)
+		}
+	}
+	if len(meta.Taints) > 0 {
+		var taintArgs []string
+		for _, taint := range meta.Taints {
+			taintArgs = append(taintArgs, taint.String())
+		}
+		cmd := fmt.Sprintf("kubectl taint node %s %s --overwrite", nodeName, strings.Join(taintArgs, " "))
+		if _, err := client.Execute(cmd); err != nil {
+			return fmt.Errorf("设置节点 %s 的污点失败: %w", nodeName, err)
+		}
+	}
+
+	return nil
+}
+
+// reconcileNodeMetadata 将新配置中所有节点的标签、污点和注解同步到集群
+// 只处理新旧配置中都存在的节点（新增节点在加入集群时设置）
+func reconcileNodeMetadata(client executor.CommandExecutor, oldCfg, newCfg *config.ClusterConfig) error {
+	for i := range newCfg.Spec.Nodes {
+		node := &newCfg.Spec.Nodes[i]
+		oldNode := findNode(oldCfg, node.Hostname)
+		if oldNode == nil {
+			continue
+		}
+
+		meta := config.ResolveNodeMetadata(newCfg, node)
+		oldMeta := config.ResolveNodeMetadata(oldCfg, oldNode)
+		if nodeMetadataEqual(&meta, &oldMeta) {
+			continue
+		}
+
+		ui.SubStep("更新节点 %s 的标签、污点和注解...", node.Hostname)
+		if err := applyNodeMetadata(client, node.Hostname, &meta, &oldMeta); err != nil {
+			ui.SubStepFailed()
+			return err
+		}
+		ui.SubStepDone()
+	}
+	return nil
+}
+
+// detectNodeMetadataChanges 检测节点标签、污点和注解的变更
+func detectNodeMetadataChanges(oldCfg, newCfg *config.ClusterConfig) []ConfigChange {
+	var changes []ConfigChange
+	for i := range newCfg.Spec.Nodes {
+		node := &newCfg.Spec.Nodes[i]
+		oldNode := findNode(oldCfg, node.Hostname)
+		if oldNode == nil {
+			continue
+		}
+
+		meta := config.ResolveNodeMetadata(newCfg, node)
+		oldMeta := config.ResolveNodeMetadata(oldCfg, oldNode)
+		if nodeMetadataEqual(&meta, &oldMeta) {
+			continue
+		}
+		changes = append(changes, ConfigChange{
+			Type:              "NodeMetadata",
+			Description:       fmt.Sprintf("更新节点 %s 的标签、污点和注解", node.Hostname),
+			OldValue:          formatNodeMetadata(&oldMeta),
+			NewValue:          formatNodeMetadata(&meta),
+			AffectedComponent: "Node",
+			RequiresRestart:   false,
+		})
+	}
+	return changes
+}
+
+func nodeMetadataEqual(a, b *config.NodeMetadata) bool {
+	if !reflect.DeepEqual(a.Labels, b.Labels) || !reflect.DeepEqual(a.Annotations, b.Annotations) {
+		return false
+	}
+	if len(a.Taints) != len(b.Taints) {
+		return false
+	}
+	for _, taint := range a.Taints {
+		i := taintIndex(b.Taints, taint)
+		if i < 0 || b.Taints[i].Value != taint.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func taintIndex(taints []config.TaintConfig, taint config.TaintConfig) int {
+	for i, t := range taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatNodeMetadata 格式化节点元数据用于显示变更
+func formatNodeMetadata(meta *config.NodeMetadata) string {
+	var parts []string
+	if len(meta.Labels) > 0 {
+		var labels []string
+		for _, key := range config.SortedKeys(meta.Labels) {
+			labels = append(labels, key+"="+meta.Labels[key])
+		}
+		parts = append(parts, "labels: "+strings.Join(labels, ","))
+	}
+	if len(meta.Taints) > 0 {
+		var taints []string
+		for _, taint := range meta.Taints {
+			taints = append(taints, taint.String())
+		}
+		parts = append(parts, "taints: "+strings.Join(taints, ","))
+	}
+	if len(meta.Annotations) > 0 {
+		parts = append(parts, fmt.Sprintf("annotations: %d 个", len(meta.Annotations)))
+	}
+	if len(parts) == 0 {
+		return "无"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// shellQuote 用单引号包裹字符串，用于拼接 shell 命令
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -28,20 +28,22 @@ func optimizeSystemInternal(client *executor.SSHClient, verbose bool) error {
 		ui.Step(1, 1, "系统优化")
 	}
 	
+	var osProfile OSProfile
 	steps := []struct {
 		name string
 		fn   func() error
 	}{
-		{"检测操作系统", func() error { return detectOS(client) }},
+		{"检测操作系统", func() (err error) { osProfile, err = detectOS(client); return err }},
+		{"配置离线软件源", func() error { return setupOfflineRepo(client, osProfile) }},
 		{"关闭 swap", func() error { return disableSwap(client) }},
 		{"配置性能模式", func() error { return setPerformanceMode(client) }},
-		{"关闭防火墙", func() error { return disableFirewall(client) }},
-		{"禁用 SELinux", func() error { return disableSELinux(client) }},
+		{"关闭防火墙", func() error { return osProfile.DisableFirewall(client) }},
+		{"禁用 SELinux", func() error { return osProfile.DisableSELinux(client) }},
 		{"配置 sysctl", func() error { return configureSysctl(client) }},
 		{"加载内核模块", func() error { return loadKernelModules(client) }},
 		{"配置模块自动加载", func() error { return configureModulesAutoload(client) }},
 		{"配置系统限制", func() error { return configureSystemLimits(client) }},
-		{"配置时间同步", func() error { return configureTimeSync(client) }},
+		{"配置时间同步", func() error { return configureTimeSync(client, osProfile) }},
 	}
 	
 	for i, step := range steps {
@@ -62,12 +64,6 @@ func optimizeSystemInternal(client *executor.SSHClient, verbose bool) error {
 	return nil
 }
 
-// detectOS 检测操作系统
-func detectOS(client *executor.SSHClient) error {
-	_, err := client.Execute("cat /etc/os-release")
-	return err
-}
-
 // disableSwap 关闭 swap
 func disableSwap(client *executor.SSHClient) error {
 	// 临时关闭
@@ -100,33 +96,6 @@ func setPerformanceMode(client *executor.SSHClient) error {
 	return err
 }
 
-// disableFirewall 关闭防火墙
-func disableFirewall(client *executor.SSHClient) error {
-	// 尝试关闭 firewalld
-	client.Execute("systemctl stop firewalld 2>/dev/null || true")
-	client.Execute("systemctl disable firewalld 2>/dev/null || true")
-	
-	// 尝试关闭 ufw
-	client.Execute("ufw disable 2>/dev/null || true")
-	
-	return nil
-}
-
-// disableSELinux 禁用 SELinux
-func disableSELinux(client *executor.SSHClient) error {
-	// 临时禁用
-	client.Execute("setenforce 0 2>/dev/null || true")
-	
-	// 永久禁用
-	_, err := client.Execute(`
-		if [ -f /etc/selinux/config ]; then
-			sed -i 's/^SELINUX=enforcing/SELINUX=disabled/' /etc/selinux/config
-			sed -i 's/^SELINUX=permissive/SELINUX=disabled/' /etc/selinux/config
-		fi
-	`)
-	return err
-}
-
 // configureSysctl 配置 sysctl 参数
 func configureSysctl(client *executor.SSHClient) error {
 	// 创建临时文件
@@ -185,24 +154,20 @@ func configureSystemLimits(client *executor.SSHClient) error {
 }
 
 // configureTimeSync 配置时间同步
-func configureTimeSync(client *executor.SSHClient) error {
+func configureTimeSync(client *executor.SSHClient, osProfile OSProfile) error {
 	// 检查是否安装了 chrony
 	if _, err := client.Execute("which chronyd"); err == nil {
-		client.Execute("systemctl enable chronyd")
-		client.Execute("systemctl start chronyd")
-		return nil
+		return osProfile.EnableService(client, "chrony")
 	}
 	
 	// 检查是否安装了 ntp
 	if _, err := client.Execute("which ntpd"); err == nil {
-		client.Execute("systemctl enable ntpd")
-		client.Execute("systemctl start ntpd")
-		return nil
+		return osProfile.EnableService(client, "ntpd")
 	}
 	
-	// 如果都没有，尝试安装 chrony
-	client.Execute("apt-get install -y chrony 2>/dev/null || yum install -y chrony 2>/dev/null || true")
-	
-	return nil
+	// 如果都没有，尝试安装 chrony（软件源不可用时跳过，预检会提示时间同步问题）
+	if err := osProfile.InstallPackages(client, "chrony"); err != nil {
+		return nil
+	}
+	return osProfile.EnableService(client, "chrony")
 }
-
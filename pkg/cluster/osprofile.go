@@ -0,0 +1,332 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/packages"
+)
+
+// 发行版系列
+const (
+	osFamilyDebian = "debian"
+	osFamilyRHEL   = "rhel"
+)
+
+// offlineRepoDir 节点上离线软件源的目录
+const offlineRepoDir = remotePackageDir + "/repo"
+
+// offlineRepoName 离线软件源名称（apt 源文件名 / yum 仓库 ID）
+const offlineRepoName = "k8s-deployer"
+
+// OSProfile 节点操作系统相关的操作：软件包、防火墙、SELinux、服务管理和离线软件源
+// 每个节点由 detectOS 检测一次，之后的系统操作都通过 OSProfile 执行
+type OSProfile interface {
+	// Name 发行版 ID 和版本，如 "ubuntu 24.04"、"rocky 9.4"
+	Name() string
+	// Family 发行版系列（debian / rhel）
+	Family() string
+	// RepoDir 离线软件源在 packages/system 下的目录名，如 ubuntu-24.04、el9、openeuler-24.03
+	RepoDir() string
+	// PackageExt 本地软件包的扩展名（.deb / .rpm）
+	PackageExt() string
+	// ServiceName 返回服务在该发行版上的 systemd 单元名（如 chrony 在 RHEL 系为 chronyd）
+	ServiceName(service string) string
+
+	// ConfigureOfflineRepo 将节点上的目录（已上传软件包和索引）配置为离线软件源
+	ConfigureOfflineRepo(client *executor.SSHClient, dir string) error
+	// InstallPackages 安装软件包，配置了离线软件源时只使用离线软件源
+	// 包名不加引号，可以使用 $(uname -r) 等 shell 展开
+	InstallPackages(client *executor.SSHClient, pkgs ...string) error
+	// InstallLocalPackages 安装节点上的软件包文件，依赖从软件源获取
+	InstallLocalPackages(client *executor.SSHClient, files ...string) error
+	// HoldPackages 锁定已安装软件包的版本，防止系统升级时被更新（支持通配符）
+	HoldPackages(client *executor.SSHClient, patterns ...string) error
+	// DisableFirewall 关闭防火墙
+	DisableFirewall(client *executor.SSHClient) error
+	// DisableSELinux 关闭 SELinux（没有 SELinux 的发行版不做操作）
+	DisableSELinux(client *executor.SSHClient) error
+	// EnableService 设置服务开机启动并重启服务
+	EnableService(client *executor.SSHClient, service string) error
+}
+
+var (
+	osProfilesMu sync.Mutex
+	osProfiles   = make(map[string]OSProfile) // 按节点地址缓存
+)
+
+// detectOS 检测节点操作系统，每个节点只检测一次
+func detectOS(client *executor.SSHClient) (OSProfile, error) {
+	key := nodeKey(client)
+	osProfilesMu.Lock()
+	profile, ok := osProfiles[key]
+	osProfilesMu.Unlock()
+	if ok {
+		return profile, nil
+	}
+
+	output, err := client.Execute("cat /etc/os-release")
+	if err != nil {
+		return nil, fmt.Errorf("读取 /etc/os-release 失败: %w", err)
+	}
+	profile, err = newOSProfile(parseOSRelease(output))
+	if err != nil {
+		return nil, err
+	}
+
+	osProfilesMu.Lock()
+	osProfiles[key] = profile
+	osProfilesMu.Unlock()
+	return profile, nil
+}
+
+// parseOSRelease 解析 /etc/os-release 的 KEY=value 行
+func parseOSRelease(content string) map[string]string {
+	release := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		release[key] = strings.Trim(value, `"'`)
+	}
+	return release
+}
+
+// newOSProfile 按 ID 和 ID_LIKE 选择发行版实现
+func newOSProfile(release map[string]string) (OSProfile, error) {
+	id, version := release["ID"], release["VERSION_ID"]
+	like := strings.Fields(release["ID_LIKE"])
+	is := func(names ...string) bool {
+		for _, name := range names {
+			if strings.EqualFold(id, name) {
+				return true
+			}
+			for _, l := range like {
+				if l == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch {
+	case is("ubuntu", "debian"):
+		return &debianOS{systemdOS{id: id, version: version, services: map[string]string{
+			"chrony": "chrony",
+			"ntpd":   "ntp",
+		}}}, nil
+	case is("rhel", "rocky", "almalinux", "centos", "fedora", "openEuler"):
+		return &rhelOS{systemdOS{id: id, version: version, services: map[string]string{
+			"chrony": "chronyd",
+		}}}, nil
+	}
+	return nil, fmt.Errorf("不支持的操作系统 %s %s（支持 Ubuntu/Debian、RHEL/Rocky/AlmaLinux 和 openEuler）", id, version)
+}
+
+// setupOfflineRepo 本地有 packages/system/<RepoDir> 时上传到节点并配置为离线软件源，
+// 没有时使用节点上已有的软件源
+func setupOfflineRepo(client *executor.SSHClient, profile OSProfile) error {
+	localDir := filepath.Join("packages", packages.SystemDir, profile.RepoDir())
+	if info, err := os.Stat(localDir); err != nil || !info.IsDir() {
+		return nil
+	}
+
+	err := filepath.Walk(localDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		if err := client.UploadFile(p, path.Join(offlineRepoDir, filepath.ToSlash(rel))); err != nil {
+			return fmt.Errorf("上传 %s 失败: %w", p, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return profile.ConfigureOfflineRepo(client, offlineRepoDir)
+}
+
+// systemdOS 使用 systemd 的发行版的公共部分
+type systemdOS struct {
+	id       string
+	version  string
+	services map[string]string // 服务名 -> systemd 单元名（与服务名相同时不需要列出）
+}
+
+func (o *systemdOS) Name() string {
+	return o.id + " " + o.version
+}
+
+func (o *systemdOS) ServiceName(service string) string {
+	if unit, ok := o.services[service]; ok {
+		return unit
+	}
+	return service
+}
+
+func (o *systemdOS) EnableService(client *executor.SSHClient, service string) error {
+	unit := o.ServiceName(service)
+	_, err := client.Execute(fmt.Sprintf("systemctl enable %s && systemctl restart %s", unit, unit))
+	return err
+}
+
+// debianOS Ubuntu / Debian
+type debianOS struct {
+	systemdOS
+}
+
+// aptSourceList 离线软件源的 apt 源文件
+const aptSourceList = "/etc/apt/sources.list.d/" + offlineRepoName + ".list"
+
+// aptScript 生成 apt-get 命令：配置了离线软件源时只使用该源（忽略 sources.list 中无法访问的源）
+func aptScript(args string) string {
+	return fmt.Sprintf(`
+		export DEBIAN_FRONTEND=noninteractive
+		if [ -f %[1]s ]; then
+			APT_OPTS="-o Dir::Etc::sourcelist=%[1]s -o Dir::Etc::sourceparts=- -o APT::Get::List-Cleanup=0"
+		fi
+		apt-get update -qq $APT_OPTS
+		apt-get install -y $APT_OPTS %[2]s
+	`, aptSourceList, args)
+}
+
+func (o *debianOS) Family() string     { return osFamilyDebian }
+func (o *debianOS) RepoDir() string    { return o.id + "-" + o.version }
+func (o *debianOS) PackageExt() string { return ".deb" }
+
+// ConfigureOfflineRepo 目录中需要有 dpkg-scanpackages 生成的 Packages 索引
+func (o *debianOS) ConfigureOfflineRepo(client *executor.SSHClient, dir string) error {
+	if _, err := client.Execute(fmt.Sprintf("[ -f %[1]s/Packages ] || [ -f %[1]s/Packages.gz ]", dir)); err != nil {
+		return fmt.Errorf("离线软件源 packages/%s/%s 缺少 Packages 索引（使用 dpkg-scanpackages 生成）", packages.SystemDir, o.RepoDir())
+	}
+	_, err := client.Execute(fmt.Sprintf("echo 'deb [trusted=yes] file:%s ./' > %s", dir, aptSourceList))
+	return err
+}
+
+func (o *debianOS) InstallPackages(client *executor.SSHClient, pkgs ...string) error {
+	_, err := client.Execute(aptScript(strings.Join(pkgs, " ")))
+	return err
+}
+
+func (o *debianOS) InstallLocalPackages(client *executor.SSHClient, files ...string) error {
+	_, err := client.Execute(aptScript(strings.Join(files, " ")))
+	return err
+}
+
+func (o *debianOS) HoldPackages(client *executor.SSHClient, patterns ...string) error {
+	_, err := client.Execute(fmt.Sprintf(`
+		pkgs=$(dpkg-query -W -f='${Package}\n' %s 2>/dev/null)
+		[ -z "$pkgs" ] || apt-mark hold $pkgs
+	`, quotePatterns(patterns)))
+	return err
+}
+
+func (o *debianOS) DisableFirewall(client *executor.SSHClient) error {
+	client.Execute("ufw disable 2>/dev/null || true")
+	client.Execute("systemctl disable --now firewalld 2>/dev/null || true")
+	return nil
+}
+
+// DisableSELinux Ubuntu / Debian 使用 AppArmor，不需要处理
+func (o *debianOS) DisableSELinux(client *executor.SSHClient) error {
+	return nil
+}
+
+// rhelOS RHEL / Rocky / AlmaLinux / CentOS Stream / openEuler
+type rhelOS struct {
+	systemdOS
+}
+
+// yumRepoFile 离线软件源的 yum/dnf 仓库文件
+const yumRepoFile = "/etc/yum.repos.d/" + offlineRepoName + ".repo"
+
+// dnfScript 生成 dnf install 命令：配置了离线软件源时只使用该源
+func dnfScript(args string) string {
+	return fmt.Sprintf(`
+		PM=$(command -v dnf || command -v yum)
+		if [ -f %s ]; then
+			REPO_OPTS="--disablerepo=* --enablerepo=%s"
+		fi
+		$PM install -y $REPO_OPTS %s
+	`, yumRepoFile, offlineRepoName, args)
+}
+
+func (o *rhelOS) Family() string     { return osFamilyRHEL }
+func (o *rhelOS) PackageExt() string { return ".rpm" }
+
+// RepoDir RHEL 兼容发行版按主版本共用软件包（el9），openEuler 按版本区分
+func (o *rhelOS) RepoDir() string {
+	if strings.EqualFold(o.id, "openEuler") {
+		return "openeuler-" + o.version
+	}
+	major, _, _ := strings.Cut(o.version, ".")
+	return "el" + major
+}
+
+// ConfigureOfflineRepo 目录中需要有 createrepo 生成的 repodata
+func (o *rhelOS) ConfigureOfflineRepo(client *executor.SSHClient, dir string) error {
+	if _, err := client.Execute(fmt.Sprintf("[ -f %s/repodata/repomd.xml ]", dir)); err != nil {
+		return fmt.Errorf("离线软件源 packages/%s/%s 缺少 repodata（使用 createrepo_c 生成）", packages.SystemDir, o.RepoDir())
+	}
+	repo := fmt.Sprintf("[%s]\nname=k8s-deployer offline repository\nbaseurl=file://%s\nenabled=1\ngpgcheck=0\n", offlineRepoName, dir)
+	_, err := client.Execute(fmt.Sprintf("cat > %s << 'EOF'\n%sEOF", yumRepoFile, repo))
+	return err
+}
+
+func (o *rhelOS) InstallPackages(client *executor.SSHClient, pkgs ...string) error {
+	_, err := client.Execute(dnfScript(strings.Join(pkgs, " ")))
+	return err
+}
+
+func (o *rhelOS) InstallLocalPackages(client *executor.SSHClient, files ...string) error {
+	_, err := client.Execute(dnfScript(strings.Join(files, " ")))
+	return err
+}
+
+// HoldPackages 优先使用 dnf versionlock，没有 versionlock 插件时在 dnf.conf 中排除这些软件包
+func (o *rhelOS) HoldPackages(client *executor.SSHClient, patterns ...string) error {
+	_, err := client.Execute(fmt.Sprintf(`
+		if ! dnf versionlock add %s 2>/dev/null; then
+			sed -i '/^excludepkgs=/d' /etc/dnf/dnf.conf
+			echo 'excludepkgs=%s' >> /etc/dnf/dnf.conf
+		fi
+	`, quotePatterns(patterns), strings.Join(patterns, ",")))
+	return err
+}
+
+func (o *rhelOS) DisableFirewall(client *executor.SSHClient) error {
+	client.Execute("systemctl disable --now firewalld 2>/dev/null || true")
+	return nil
+}
+
+// DisableSELinux 按 kubeadm 文档设置为 permissive
+// （RHEL 9 起配置文件中的 SELINUX=disabled 不再完全关闭 SELinux）
+func (o *rhelOS) DisableSELinux(client *executor.SSHClient) error {
+	client.Execute("setenforce 0 2>/dev/null || true")
+	_, err := client.Execute(`
+		if [ -f /etc/selinux/config ]; then
+			sed -i 's/^SELINUX=enforcing/SELINUX=permissive/' /etc/selinux/config
+		fi
+	`)
+	return err
+}
+
+// quotePatterns 为软件包通配符加单引号，避免被 shell 展开为文件名
+func quotePatterns(patterns []string) string {
+	quoted := make([]string, len(patterns))
+	for i, p := range patterns {
+		quoted[i] = "'" + p + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,688 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// PreflightStatus 检查结果
+type PreflightStatus string
+
+const (
+	PreflightPass PreflightStatus = "pass"
+	PreflightWarn PreflightStatus = "warn"
+	PreflightFail PreflightStatus = "fail"
+)
+
+// preflightLocalNode 本地检查使用的节点名称
+const preflightLocalNode = "(本地)"
+
+// PreflightResult 单项检查结果
+type PreflightResult struct {
+	Node    string
+	Check   string
+	Status  PreflightStatus
+	Message string
+}
+
+// nodeFacts 检查过程中收集的节点信息，用于跨节点检查
+type nodeFacts struct {
+	hostname    string
+	productUUID string
+	macs        []string
+	arch        string
+}
+
+// nodeCheck 在单个节点上执行的检查
+type nodeCheck struct {
+	name string
+	run  func(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string)
+}
+
+// clusterCheck 本地或跨节点的检查（所有节点检查完成后执行）
+type clusterCheck struct {
+	name string
+	run  func(cfg *config.ClusterConfig, facts map[string]*nodeFacts) []PreflightResult
+}
+
+// 检查阈值
+const (
+	minKernelVersion         = "4.19"
+	recommendedKernelVersion = "5.4" // Cilium eBPF 功能推荐版本
+	minDiskGiB               = 10
+	recommendedDiskGiB       = 20
+	maxTimeSkew              = 5 * time.Second
+)
+
+// preflightNodeChecks 节点检查列表（按显示顺序）
+var preflightNodeChecks = []nodeCheck{
+	{name: "os", run: checkOS},
+	{name: "arch", run: checkArch},
+	{name: "cpu-memory", run: checkCPUMemory},
+	{name: "disk", run: checkDisk},
+	{name: "swap", run: checkSwap},
+	{name: "ports", run: checkPorts},
+	{name: "time", run: checkTime},
+	{name: "registry", run: checkRegistry},
+	{name: "identity", run: collectIdentity},
+}
+
+// preflightClusterChecks 跨节点和本地检查列表
+var preflightClusterChecks = []clusterCheck{
+	{name: "hostname", run: checkHostnameUnique},
+	{name: "product-uuid", run: checkProductUUIDUnique},
+	{name: "mac", run: checkMACUnique},
+	{name: "vip", run: checkVIPFree},
+	{name: "endpoint", run: checkExternalEndpoint},
+	{name: "packages", run: checkLocalPackages},
+}
+
+// RunPreflight 在所有节点上并发执行预检，打印结果表格
+// 有失败项时返回错误，警告不影响结果
+func RunPreflight(cfg *config.ClusterConfig) ([]PreflightResult, error) {
+	ui.Info("并发检查 %d 个节点...", len(cfg.Spec.Nodes))
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		byNode  = make(map[string][]PreflightResult)
+		factMap = make(map[string]*nodeFacts)
+	)
+
+	for i := range cfg.Spec.Nodes {
+		wg.Add(1)
+		go func(node *config.NodeConfig) {
+			defer wg.Done()
+			results, facts := runNodeChecks(cfg, node)
+			mu.Lock()
+			byNode[node.Hostname] = results
+			if facts != nil {
+				factMap[node.Hostname] = facts
+			}
+			mu.Unlock()
+		}(&cfg.Spec.Nodes[i])
+	}
+	wg.Wait()
+
+	// 按配置中的节点顺序汇总
+	var results []PreflightResult
+	for _, node := range cfg.Spec.Nodes {
+		results = append(results, byNode[node.Hostname]...)
+	}
+	for _, check := range preflightClusterChecks {
+		results = append(results, check.run(cfg, factMap)...)
+	}
+
+	printPreflightResults(results)
+
+	failed, warned := 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case PreflightFail:
+			failed++
+		case PreflightWarn:
+			warned++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("预检发现 %d 项失败、%d 项警告", failed, warned)
+	}
+	if warned > 0 {
+		ui.Warning("预检通过，但有 %d 项警告", warned)
+	} else {
+		ui.Success("预检全部通过")
+	}
+	return results, nil
+}
+
+// runNodeChecks 在单个节点上依次执行所有节点检查
+func runNodeChecks(cfg *config.ClusterConfig, node *config.NodeConfig) ([]PreflightResult, *nodeFacts) {
+	client, err := connectNode(node)
+	if err != nil {
+		return []PreflightResult{{
+			Node: node.Hostname, Check: "ssh", Status: PreflightFail,
+			Message: fmt.Sprintf("SSH 连接失败: %v", err),
+		}}, nil
+	}
+	defer client.Close()
+
+	facts := &nodeFacts{}
+	var results []PreflightResult
+	for _, check := range preflightNodeChecks {
+		status, msg := check.run(cfg, node, client, facts)
+		if status == "" {
+			continue // 只收集信息，不输出结果
+		}
+		results = append(results, PreflightResult{Node: node.Hostname, Check: check.name, Status: status, Message: msg})
+	}
+	return results, facts
+}
+
+// printPreflightResults 以表格形式打印检查结果
+func printPreflightResults(results []PreflightResult) {
+	table := ui.NewTable([]string{"节点", "检查项", "结果", "说明"})
+	for _, r := range results {
+		var status string
+		switch r.Status {
+		case PreflightPass:
+			status = "✓ 通过"
+		case PreflightWarn:
+			status = "! 警告"
+		default:
+			status = "✗ 失败"
+		}
+		table.Append([]string{r.Node, r.Check, status, r.Message})
+	}
+	table.Render()
+}
+
+// checkOS 检查操作系统和内核版本
+func checkOS(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	osProfile, err := detectOS(client)
+	if err != nil {
+		return PreflightFail, err.Error()
+	}
+	output, err := client.Execute("uname -r")
+	if err != nil {
+		return PreflightFail, fmt.Sprintf("读取内核版本失败: %v", err)
+	}
+	kernel := strings.TrimSpace(output)
+	msg := fmt.Sprintf("%s, 内核 %s", osProfile.Name(), kernel)
+
+	if compareKernelVersion(kernel, minKernelVersion) < 0 {
+		return PreflightFail, msg + fmt.Sprintf("（需要 >= %s）", minKernelVersion)
+	}
+	if compareKernelVersion(kernel, recommendedKernelVersion) < 0 {
+		return PreflightWarn, msg + fmt.Sprintf("（Cilium 推荐 >= %s）", recommendedKernelVersion)
+	}
+	return PreflightPass, msg
+}
+
+// checkArch 检查节点架构，以及该架构的离线包是否齐全（Master 还需要 Helm）
+func checkArch(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	arch, err := detectArch(client)
+	if err != nil {
+		return PreflightFail, err.Error()
+	}
+	facts.arch = arch
+
+	pkgMgr, err := packages.NewManagerWithVersion(cfg.Spec.Version)
+	if err != nil {
+		return PreflightFail, err.Error()
+	}
+	required := append([]string{}, nodeBinaryPackages...)
+	if node.Role == "master" {
+		required = append(required, "helm")
+	}
+	if _, err := nodePackageManager(client, pkgMgr, required...); err != nil {
+		return PreflightFail, err.Error()
+	}
+	return PreflightPass, arch
+}
+
+// checkCPUMemory 检查 CPU 和内存（kubeadm 要求 Master 至少 2 核、1700MB 内存）
+func checkCPUMemory(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	output, err := client.Execute("nproc && awk '/MemTotal/ {print $2}' /proc/meminfo")
+	if err != nil {
+		return PreflightFail, fmt.Sprintf("读取 CPU/内存失败: %v", err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) < 2 {
+		return PreflightFail, "无法解析 CPU/内存信息"
+	}
+	cpus, _ := strconv.Atoi(fields[0])
+	memKB, _ := strconv.Atoi(fields[1])
+	memMB := memKB / 1024
+	msg := fmt.Sprintf("%d 核, %d MB", cpus, memMB)
+
+	if node.Role == "master" && (cpus < 2 || memMB < 1700) {
+		return PreflightFail, msg + "（Master 至少需要 2 核、1700MB）"
+	}
+	if cpus < 2 || memMB < 2048 {
+		return PreflightWarn, msg + "（推荐至少 2 核、2GB）"
+	}
+	return PreflightPass, msg
+}
+
+// checkDisk 检查 /var/lib 可用空间（镜像、etcd、kubelet 数据）
+func checkDisk(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	output, err := client.Execute("df -Pk /var/lib | tail -1 | awk '{print $4}'")
+	if err != nil {
+		return PreflightFail, fmt.Sprintf("读取磁盘空间失败: %v", err)
+	}
+	availKB, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return PreflightFail, "无法解析磁盘空间"
+	}
+	availGiB := availKB / 1024 / 1024
+	msg := fmt.Sprintf("/var/lib 可用 %d GiB", availGiB)
+
+	if availGiB < minDiskGiB {
+		return PreflightFail, msg + fmt.Sprintf("（至少需要 %d GiB）", minDiskGiB)
+	}
+	if availGiB < recommendedDiskGiB {
+		return PreflightWarn, msg + fmt.Sprintf("（推荐 %d GiB 以上）", recommendedDiskGiB)
+	}
+	return PreflightPass, msg
+}
+
+// checkSwap 检查 swap（准备节点时会自动关闭）
+func checkSwap(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	output, err := client.Execute("swapon --show --noheadings 2>/dev/null || true")
+	if err != nil {
+		return PreflightWarn, fmt.Sprintf("无法检查 swap: %v", err)
+	}
+	if strings.TrimSpace(output) != "" {
+		return PreflightWarn, "swap 已启用，准备节点时将自动关闭"
+	}
+	return PreflightPass, "未启用"
+}
+
+// requiredPorts 节点上需要空闲的端口
+func requiredPorts(node *config.NodeConfig) []int {
+	if node.Role == "master" {
+		return []int{6443, 2379, 2380, 10250, 10257, 10259}
+	}
+	return []int{10250}
+}
+
+// checkPorts 检查 Kubernetes 组件端口是否被占用
+func checkPorts(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	output, err := client.Execute("ss -Hltn 2>/dev/null | awk '{print $4}'")
+	if err != nil {
+		return PreflightWarn, fmt.Sprintf("无法检查端口: %v", err)
+	}
+
+	listening := make(map[int]bool)
+	for _, addr := range strings.Fields(output) {
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			if port, err := strconv.Atoi(addr[i+1:]); err == nil {
+				listening[port] = true
+			}
+		}
+	}
+
+	var busy []string
+	for _, port := range requiredPorts(node) {
+		if listening[port] {
+			busy = append(busy, strconv.Itoa(port))
+		}
+	}
+	if len(busy) > 0 {
+		return PreflightFail, fmt.Sprintf("端口已被占用: %s", strings.Join(busy, ", "))
+	}
+	return PreflightPass, "所需端口均空闲"
+}
+
+// checkTime 检查节点与本机的时间偏差和 NTP 同步状态
+func checkTime(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	before := time.Now()
+	output, err := client.Execute("date +%s && (timedatectl show -p NTPSynchronized --value 2>/dev/null || echo unknown)")
+	if err != nil {
+		return PreflightWarn, fmt.Sprintf("无法读取时间: %v", err)
+	}
+	local := before.Add(time.Since(before) / 2)
+
+	fields := strings.Fields(output)
+	if len(fields) < 2 {
+		return PreflightWarn, "无法解析时间信息"
+	}
+	remote, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return PreflightWarn, "无法解析时间信息"
+	}
+
+	skew := time.Unix(remote, 0).Sub(local).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+	msg := fmt.Sprintf("与本机偏差 %s, NTP 同步: %s", skew, fields[1])
+
+	if skew > maxTimeSkew {
+		return PreflightFail, msg + "（证书校验和 etcd 需要时间同步）"
+	}
+	if fields[1] != "yes" {
+		return PreflightWarn, msg
+	}
+	return PreflightPass, msg
+}
+
+// checkRegistry 检查节点能否访问镜像仓库（任何 HTTP 响应都视为可达）
+func checkRegistry(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	registry := parseImageRegistry(cfg.Spec.ImageRepository)
+	cmd := fmt.Sprintf(
+		"code=$(curl -sk -o /dev/null -w '%%{http_code}' --max-time 5 https://%[1]s/v2/) || "+
+			"code=$(curl -s -o /dev/null -w '%%{http_code}' --max-time 5 http://%[1]s/v2/); echo $code",
+		registry)
+	output, err := client.Execute(cmd)
+	code := strings.TrimSpace(output)
+	if err != nil || code == "" || strings.HasPrefix(code, "000") {
+		return PreflightFail, fmt.Sprintf("无法访问镜像仓库 %s", registry)
+	}
+	return PreflightPass, fmt.Sprintf("%s 可达 (HTTP %s)", registry, code)
+}
+
+// collectIdentity 收集主机名、product_uuid 和 MAC 地址，供跨节点检查使用
+func collectIdentity(cfg *config.ClusterConfig, node *config.NodeConfig, client *executor.SSHClient, facts *nodeFacts) (PreflightStatus, string) {
+	if output, err := client.Execute("hostname"); err == nil {
+		facts.hostname = strings.TrimSpace(output)
+	}
+	if output, err := client.Execute("cat /sys/class/dmi/id/product_uuid 2>/dev/null"); err == nil {
+		facts.productUUID = strings.ToLower(strings.TrimSpace(output))
+	}
+	// 只检查物理网卡（排除 lo、虚拟网卡）
+	output, err := client.Execute("for dev in /sys/class/net/*; do [ -e $dev/device ] && cat $dev/address; done 2>/dev/null || true")
+	if err == nil {
+		facts.macs = strings.Fields(output)
+	}
+	return "", ""
+}
+
+// checkHostnameUnique 检查节点实际主机名唯一，且与配置一致（kubelet 使用实际主机名注册）
+func checkHostnameUnique(cfg *config.ClusterConfig, facts map[string]*nodeFacts) []PreflightResult {
+	seen := make(map[string]string)
+	var results []PreflightResult
+	for _, node := range cfg.Spec.Nodes {
+		f, ok := facts[node.Hostname]
+		if !ok || f.hostname == "" {
+			continue
+		}
+		if other, dup := seen[f.hostname]; dup {
+			results = append(results, PreflightResult{Node: node.Hostname, Check: "hostname", Status: PreflightFail,
+				Message: fmt.Sprintf("主机名 %s 与节点 %s 重复", f.hostname, other)})
+			continue
+		}
+		seen[f.hostname] = node.Hostname
+		if f.hostname != node.Hostname {
+			results = append(results, PreflightResult{Node: node.Hostname, Check: "hostname", Status: PreflightWarn,
+				Message: fmt.Sprintf("实际主机名 %s 与配置不一致，节点将以实际主机名注册", f.hostname)})
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, PreflightResult{Node: "*", Check: "hostname", Status: PreflightPass, Message: "主机名唯一"})
+	}
+	return results
+}
+
+// checkProductUUIDUnique 检查 product_uuid 唯一（克隆的虚拟机常见问题）
+func checkProductUUIDUnique(cfg *config.ClusterConfig, facts map[string]*nodeFacts) []PreflightResult {
+	seen := make(map[string]string)
+	var results []PreflightResult
+	for _, node := range cfg.Spec.Nodes {
+		f, ok := facts[node.Hostname]
+		if !ok || f.productUUID == "" {
+			continue
+		}
+		if other, dup := seen[f.productUUID]; dup {
+			results = append(results, PreflightResult{Node: node.Hostname, Check: "product-uuid", Status: PreflightFail,
+				Message: fmt.Sprintf("product_uuid 与节点 %s 相同", other)})
+			continue
+		}
+		seen[f.productUUID] = node.Hostname
+	}
+	if len(results) == 0 {
+		results = append(results, PreflightResult{Node: "*", Check: "product-uuid", Status: PreflightPass, Message: "product_uuid 唯一"})
+	}
+	return results
+}
+
+// checkMACUnique 检查物理网卡 MAC 地址唯一
+func checkMACUnique(cfg *config.ClusterConfig, facts map[string]*nodeFacts) []PreflightResult {
+	seen := make(map[string]string)
+	var results []PreflightResult
+	for _, node := range cfg.Spec.Nodes {
+		f, ok := facts[node.Hostname]
+		if !ok {
+			continue
+		}
+		for _, mac := range f.macs {
+			if other, dup := seen[mac]; dup && other != node.Hostname {
+				results = append(results, PreflightResult{Node: node.Hostname, Check: "mac", Status: PreflightFail,
+					Message: fmt.Sprintf("MAC %s 与节点 %s 相同", mac, other)})
+				continue
+			}
+			seen[mac] = node.Hostname
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, PreflightResult{Node: "*", Check: "mac", Status: PreflightPass, Message: "MAC 地址唯一"})
+	}
+	return results
+}
+
+// checkVIPFree 检查 VIP 未被占用（从第一个 Master 探测）
+func checkVIPFree(cfg *config.ClusterConfig, facts map[string]*nodeFacts) []PreflightResult {
+	if !cfg.Spec.HA.Enabled || cfg.Spec.HA.External() {
+		return nil
+	}
+	result := PreflightResult{Node: "*", Check: "vip"}
+
+	master := getFirstMasterNode(cfg)
+	client, err := connectNode(master)
+	if err != nil {
+		result.Status, result.Message = PreflightWarn, fmt.Sprintf("无法连接 %s 检查 VIP: %v", master.Hostname, err)
+		return []PreflightResult{result}
+	}
+	defer client.Close()
+
+	if _, err := client.Execute(fmt.Sprintf("ping -c 2 -W 1 %s", cfg.Spec.HA.VIP)); err == nil {
+		result.Status, result.Message = PreflightFail, fmt.Sprintf("VIP %s 已被其他主机使用", cfg.Spec.HA.VIP)
+	} else {
+		result.Status, result.Message = PreflightPass, fmt.Sprintf("VIP %s 未被使用", cfg.Spec.HA.VIP)
+	}
+	return []PreflightResult{result}
+}
+
+// 外部负载均衡器检查使用的临时文件
+const (
+	endpointProbeResult = "/tmp/k8s-deployer-endpoint-probe"
+	endpointProbePID    = "/tmp/k8s-deployer-endpoint-probe.pid"
+)
+
+// endpointProbeListener 在 6443 端口临时监听，收到探测令牌时写入结果文件
+// 负载均衡器的健康检查连接同样会被接受，使后端尽快被标记为可用
+const endpointProbeListener = `import socket
+s = socket.socket(socket.%s, socket.SOCK_STREAM)
+s.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+s.bind(("%s", 6443))
+s.listen(16)
+while True:
+    c, _ = s.accept()
+    c.settimeout(2)
+    try:
+        if b"%s" in c.recv(128):
+            open("%s", "w").write("ok")
+    except Exception:
+        pass
+    c.close()`
+
+// endpointProbeSocat 没有 python3 时使用 socat 临时监听，每个连接读取令牌，匹配时写入结果文件
+const endpointProbeSocat = `socat %s-LISTEN:6443,reuseaddr,fork SYSTEM:'timeout 2 head -c 128 | grep -q %s && echo ok > %s'`
+
+// checkExternalEndpoint 检查外部负载均衡器将 TCP 6443 转发到第一个 Master
+// 在第一个 Master 的 6443 端口启动临时监听，从其他节点经负载均衡器发送令牌，确认令牌到达第一个 Master
+func checkExternalEndpoint(cfg *config.ClusterConfig, facts map[string]*nodeFacts) []PreflightResult {
+	if !cfg.Spec.HA.External() {
+		return nil
+	}
+	result := PreflightResult{Node: "*", Check: "endpoint"}
+	endpoint := cfg.Spec.HA.Endpoint
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		result.Status, result.Message = PreflightFail, fmt.Sprintf("负载均衡器地址格式不正确: %s", endpoint)
+		return []PreflightResult{result}
+	}
+
+	master := getFirstMasterNode(cfg)
+	masterClient, err := connectNode(master)
+	if err != nil {
+		result.Status, result.Message = PreflightWarn, fmt.Sprintf("无法连接 %s 检查负载均衡器: %v", master.Hostname, err)
+		return []PreflightResult{result}
+	}
+	defer masterClient.Close()
+
+	// 从其他节点探测（部分负载均衡器不支持后端经自身回环访问），只有一个节点时使用第一个 Master
+	probeNode := master
+	for i := range cfg.Spec.Nodes {
+		if cfg.Spec.Nodes[i].Hostname != master.Hostname {
+			probeNode = &cfg.Spec.Nodes[i]
+			break
+		}
+	}
+	probeClient := masterClient
+	if probeNode != master {
+		if probeClient, err = connectNode(probeNode); err != nil {
+			result.Status, result.Message = PreflightWarn, fmt.Sprintf("无法连接 %s 检查负载均衡器: %v", probeNode.Hostname, err)
+			return []PreflightResult{result}
+		}
+		defer probeClient.Close()
+	}
+
+	// 临时监听优先使用 python3，没有时使用 socat；都没有时无法确认转发，预检失败
+	token := fmt.Sprintf("k8s-deployer-probe-%d", time.Now().UnixNano())
+	var listener string
+	if _, err := masterClient.Execute("command -v python3"); err == nil {
+		family, bindAddr := "AF_INET", "0.0.0.0"
+		if config.IsIPv6(master.IP) {
+			family, bindAddr = "AF_INET6", "::"
+		}
+		listener = fmt.Sprintf("python3 -c '%s'", fmt.Sprintf(endpointProbeListener, family, bindAddr, token, endpointProbeResult))
+	} else if _, err := masterClient.Execute("command -v socat"); err == nil {
+		family := "TCP4"
+		if config.IsIPv6(master.IP) {
+			family = "TCP6"
+		}
+		listener = fmt.Sprintf(endpointProbeSocat, family, token, endpointProbeResult)
+	} else {
+		result.Status, result.Message = PreflightFail, fmt.Sprintf("无法检查负载均衡器 %s：%s 上没有 python3 或 socat，无法在 6443 端口临时监听（安装其中之一后重试）", endpoint, master.Hostname)
+		return []PreflightResult{result}
+	}
+
+	startCmd := fmt.Sprintf("rm -f %[1]s; nohup timeout 120 %[2]s </dev/null >/dev/null 2>&1 & echo $! > %[3]s; sleep 1; kill -0 $(cat %[3]s)",
+		endpointProbeResult, listener, endpointProbePID)
+	if _, err := masterClient.Execute(startCmd); err != nil {
+		result.Status, result.Message = PreflightFail, fmt.Sprintf("无法检查负载均衡器 %s：%s 的 6443 端口启动临时监听失败（端口是否已被占用）", endpoint, master.Hostname)
+		return []PreflightResult{result}
+	}
+	defer masterClient.Execute(fmt.Sprintf("kill $(cat %[1]s) 2>/dev/null; rm -f %[1]s %[2]s", endpointProbePID, endpointProbeResult))
+
+	// 负载均衡器需要几次健康检查后才会将后端标记为可用，多次重试
+	probeCmd := fmt.Sprintf("timeout 5 bash -c 'echo %s > /dev/tcp/%s/%s'", token, host, port)
+	connected := false
+	for attempt := 0; attempt < 10; attempt++ {
+		if attempt > 0 {
+			time.Sleep(3 * time.Second)
+		}
+		if _, err := probeClient.Execute(probeCmd); err != nil {
+			continue
+		}
+		connected = true
+		if output, err := masterClient.Execute(fmt.Sprintf("cat %s 2>/dev/null || true", endpointProbeResult)); err == nil && strings.TrimSpace(output) == "ok" {
+			result.Status, result.Message = PreflightPass, fmt.Sprintf("%s 已转发到 %s:6443", endpoint, master.Hostname)
+			return []PreflightResult{result}
+		}
+	}
+
+	if !connected {
+		result.Status, result.Message = PreflightFail, fmt.Sprintf("从 %s 无法连接负载均衡器 %s", probeNode.Hostname, endpoint)
+	} else {
+		result.Status, result.Message = PreflightFail, fmt.Sprintf("可以连接 %s，但未转发到 %s:6443（检查负载均衡器后端配置）", endpoint, master.Hostname)
+	}
+	return []PreflightResult{result}
+}
+
+// checkLocalPackages 检查本地离线包是否齐全
+// 节点二进制文件按架构在各节点的 arch 检查中确认，这里检查与架构无关的 Helm chart
+func checkLocalPackages(cfg *config.ClusterConfig, facts map[string]*nodeFacts) []PreflightResult {
+	result := PreflightResult{Node: preflightLocalNode, Check: "packages"}
+	pkgMgr, err := packages.NewManagerWithVersion(cfg.Spec.Version)
+	if err != nil {
+		result.Status, result.Message = PreflightFail, err.Error()
+		return []PreflightResult{result}
+	}
+
+	required := []string{"cilium-chart"}
+	if cfg.Spec.LoadBalancer.Provider == "metallb" || cfg.Spec.BGP.Enabled {
+		required = append(required, "metallb-chart")
+	}
+
+	// 集群使用的架构
+	archSet := make(map[string]bool)
+	for _, f := range facts {
+		if f.arch != "" {
+			archSet[f.arch] = true
+		}
+	}
+	var archs []string
+	for arch := range archSet {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+
+	if missing := pkgMgr.CheckRequiredPackages(required); len(missing) > 0 {
+		result.Status = PreflightFail
+		result.Message = fmt.Sprintf("缺少离线包: %s（cd scripts && ./download-all.sh，k8s-deployer packages verify 查看详情）", strings.Join(missing, ", "))
+		return []PreflightResult{result}
+	}
+
+	// 校验 sha256：不一致时失败，清单中没有记录时给出警告
+	nodePackages := append(append([]string{}, nodeBinaryPackages...), "helm")
+	verify := pkgMgr.VerifyPackages(required)
+	for _, arch := range archs {
+		verify = append(verify, pkgMgr.ForArch(arch).VerifyPackages(nodePackages)...)
+	}
+	var mismatched, unchecked []string
+	for _, r := range verify {
+		switch r.Status {
+		case packages.VerifyMismatch:
+			mismatched = append(mismatched, r.Artifact.Path)
+		case packages.VerifyUnchecked:
+			unchecked = append(unchecked, r.Artifact.Path)
+		}
+	}
+
+	switch {
+	case len(mismatched) > 0:
+		result.Status = PreflightFail
+		result.Message = fmt.Sprintf("离线包 sha256 与清单不一致: %s（重新下载后执行 k8s-deployer packages verify）", strings.Join(mismatched, ", "))
+	case len(unchecked) > 0:
+		result.Status = PreflightWarn
+		result.Message = fmt.Sprintf("%d 个离线包没有记录 sha256，未校验完整性: %s（确认来源可信后执行 k8s-deployer packages verify --write-checksums）",
+			len(unchecked), strings.Join(unchecked, ", "))
+	case len(archs) > 1:
+		result.Status, result.Message = PreflightPass, fmt.Sprintf("离线包齐全（混合架构: %s，镜像需要包含所有架构）", strings.Join(archs, ", "))
+	default:
+		result.Status, result.Message = PreflightPass, "离线包齐全"
+	}
+	return []PreflightResult{result}
+}
+
+// compareKernelVersion 比较内核版本的主次版本号（如 "5.15.0-91-generic" 与 "5.4"）
+func compareKernelVersion(kernel, want string) int {
+	parse := func(v string) (int, int) {
+		v = strings.SplitN(v, "-", 2)[0]
+		parts := strings.Split(v, ".")
+		major, _ := strconv.Atoi(parts[0])
+		minor := 0
+		if len(parts) > 1 {
+			minor, _ = strconv.Atoi(parts[1])
+		}
+		return major, minor
+	}
+	km, kn := parse(kernel)
+	wm, wn := parse(want)
+	if km != wm {
+		return km - wm
+	}
+	return kn - wn
+}
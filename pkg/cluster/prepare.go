@@ -4,6 +4,8 @@ import (
 	_ "embed"
 	"bytes"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"text/template"
 
 	"stormdragon/k8s-deployer/pkg/config"
@@ -22,36 +24,76 @@ var containerdGPUConfigTemplate string
 type ContainerdConfig struct {
 	ImageRepository string
 	HarborHost      string
+	RegistryAuth    bool   // 是否配置 Harbor 认证
+	HarborUsername  string // TOML 字符串（已加引号和转义）
+	HarborPassword  string // TOML 字符串（已加引号和转义）
+}
+
+// remotePackageDir 节点上缓存离线包的目录，重复部署时内容一致的文件不会重新上传
+// 部署完成（或节点加入、升级完成）后删除已安装的离线包，离线软件源（repo/）保留到 cluster destroy
+const remotePackageDir = "/var/cache/k8s-deployer"
+
+// remotePackagePath 离线包在节点上的缓存路径
+func remotePackagePath(localPath string) string {
+	return remotePackageDir + "/" + filepath.Base(localPath)
+}
+
+// cleanupRemotePackages 删除节点缓存目录中上传的离线包（只删除文件，保留离线软件源目录）
+func cleanupRemotePackages(client *executor.SSHClient) error {
+	cmd := fmt.Sprintf("if [ -d %[1]s ]; then find %[1]s -maxdepth 1 -type f -delete; fi", remotePackageDir)
+	if _, err := client.Execute(cmd); err != nil {
+		return fmt.Errorf("清理节点离线包缓存失败: %w", err)
+	}
+	return nil
+}
+
+// cleanupNodesPackageCache 清理所有节点的离线包缓存，失败只给出警告
+func cleanupNodesPackageCache(cfg *config.ClusterConfig) {
+	ui.SubStep("清理节点离线包缓存...")
+	var failed []string
+	for i := range cfg.Spec.Nodes {
+		node := &cfg.Spec.Nodes[i]
+		client, err := connectNode(node)
+		if err != nil {
+			failed = append(failed, node.Hostname)
+			continue
+		}
+		if err := cleanupRemotePackages(client); err != nil {
+			failed = append(failed, node.Hostname)
+		}
+		client.Close()
+	}
+	if len(failed) > 0 {
+		ui.SubStepFailed()
+		ui.Warning("以下节点的离线包缓存（%s）未能清理: %s", remotePackageDir, strings.Join(failed, ", "))
+		return
+	}
+	ui.SubStepDone()
 }
 
 // PrepareNode 准备节点（带 UI 输出）
-func PrepareNode(node *config.NodeConfig, imageRepo string, k8sVersion string) error {
-	return prepareNodeInternal(node, imageRepo, k8sVersion, true)
+func PrepareNode(cfg *config.ClusterConfig, node *config.NodeConfig) error {
+	return prepareNodeInternal(cfg, node, true)
 }
 
 // PrepareNodeQuiet 准备节点（静默模式，用于并发）
-func PrepareNodeQuiet(node *config.NodeConfig, imageRepo string, k8sVersion string) error {
-	return prepareNodeInternal(node, imageRepo, k8sVersion, false)
+func PrepareNodeQuiet(cfg *config.ClusterConfig, node *config.NodeConfig) error {
+	return prepareNodeInternal(cfg, node, false)
 }
 
 // prepareNodeInternal 准备节点的内部实现
-func prepareNodeInternal(node *config.NodeConfig, imageRepo string, k8sVersion string, verbose bool) error {
+func prepareNodeInternal(cfg *config.ClusterConfig, node *config.NodeConfig, verbose bool) error {
 	if verbose {
 		ui.Header(fmt.Sprintf("准备节点: %s (%s)", node.Hostname, node.IP))
 	}
 	
 	// 建立 SSH 连接（支持密码或密钥）
-	client, err := executor.NewSSHClientWithPassword(
-		node.IP, 
-		node.SSH.Port, 
-		node.SSH.User, 
-		node.SSH.KeyFile,
-		node.SSH.Password,
-	)
+	client, err := connectNode(node)
 	if err != nil {
 		return fmt.Errorf("SSH 连接失败: %w", err)
 	}
 	defer client.Close()
+	client.SetUploadProgress(verbose)
 	
 	// 阶段 1: 系统优化
 	if err := optimizeSystemInternal(client, verbose); err != nil {
@@ -62,7 +104,7 @@ func prepareNodeInternal(node *config.NodeConfig, imageRepo string, k8sVersion s
 	if verbose {
 		ui.Step(2, 4, "安装容器运行时 (containerd)")
 	}
-	if err := installContainerd(client, imageRepo, node.GPU); err != nil {
+	if err := installContainerd(client, cfg, node.GPU); err != nil {
 		return err
 	}
 	
@@ -70,7 +112,7 @@ func prepareNodeInternal(node *config.NodeConfig, imageRepo string, k8sVersion s
 	if verbose {
 		ui.Step(3, 4, "安装 Kubernetes 组件")
 	}
-	if err := installK8sComponents(client, k8sVersion); err != nil {
+	if err := installK8sComponents(client, cfg.Spec.Version); err != nil {
 		return err
 	}
 	
@@ -91,17 +133,19 @@ func prepareNodeInternal(node *config.NodeConfig, imageRepo string, k8sVersion s
 }
 
 // installContainerd 安装 containerd（使用离线包）
-func installContainerd(client *executor.SSHClient, imageRepo string, isGPU bool) error {
+func installContainerd(client *executor.SSHClient, cfg *config.ClusterConfig, isGPU bool) error {
 	// 初始化包管理器
-	pkgMgr := packages.NewManager()
+	pkgMgr, err := packages.NewManager()
+	if err != nil {
+		return err
+	}
 	
-	// 检查本地离线包
+	// 检查本地离线包（按节点架构）
 	ui.SubStep("检查离线包...")
-	requiredPkgs := []string{"containerd", "runc", "cni-plugins"}
-	missingPkgs := pkgMgr.CheckRequiredPackages(requiredPkgs)
-	if len(missingPkgs) > 0 {
+	pkgMgr, err = nodePackageManager(client, pkgMgr, "containerd", "runc", "cni-plugins")
+	if err != nil {
 		ui.SubStepFailed()
-		return fmt.Errorf("缺少离线包，请先运行: cd scripts && ./download-all.sh")
+		return err
 	}
 	ui.SubStepDone()
 	
@@ -113,16 +157,15 @@ func installContainerd(client *executor.SSHClient, imageRepo string, isGPU bool)
 	// 上传并安装 containerd 二进制包（强制覆盖）
 	ui.SubStep("安装 containerd...")
 	containerdTar := pkgMgr.GetPackagePath("containerd")
-	if err := client.UploadFile(containerdTar, "/tmp/containerd.tar.gz"); err != nil {
+	containerdRemote := remotePackagePath(containerdTar)
+	if err := client.UploadFile(containerdTar, containerdRemote); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("上传 containerd 失败: %w", err)
 	}
 	
 	// 解压并安装 containerd（覆盖旧文件）
 	installCmd := `
-		cd /tmp
-		tar -xzf containerd.tar.gz -C /usr/local
-		rm -f containerd.tar.gz
+		tar -xzf ` + containerdRemote + ` -C /usr/local
 		
 		# 创建 systemd 服务（覆盖）
 		cat > /etc/systemd/system/containerd.service << 'EOF'
@@ -158,15 +201,13 @@ EOF
 	// 安装 runc（强制覆盖）
 	ui.SubStep("安装 runc...")
 	runcPath := pkgMgr.GetPackagePath("runc")
-	if err := client.UploadFile(runcPath, "/tmp/runc.amd64"); err != nil {
+	runcRemote := remotePackagePath(runcPath)
+	if err := client.UploadFile(runcPath, runcRemote); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("上传 runc 失败: %w", err)
 	}
 	
-	runcInstallCmd := `
-		install -m 755 /tmp/runc.amd64 /usr/local/sbin/runc
-		rm -f /tmp/runc.amd64
-	`
+	runcInstallCmd := fmt.Sprintf("install -m 755 %s /usr/local/sbin/runc", runcRemote)
 	if _, err := client.Execute(runcInstallCmd); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("安装 runc 失败: %w", err)
@@ -176,16 +217,16 @@ EOF
 	// 安装 CNI plugins（强制覆盖）
 	ui.SubStep("安装 CNI plugins...")
 	cniPath := pkgMgr.GetPackagePath("cni-plugins")
-	if err := client.UploadFile(cniPath, "/tmp/cni-plugins.tgz"); err != nil {
+	cniRemote := remotePackagePath(cniPath)
+	if err := client.UploadFile(cniPath, cniRemote); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("上传 CNI plugins 失败: %w", err)
 	}
 	
-	cniInstallCmd := `
+	cniInstallCmd := fmt.Sprintf(`
 		mkdir -p /opt/cni/bin
-		tar -xzf /tmp/cni-plugins.tgz -C /opt/cni/bin
-		rm -f /tmp/cni-plugins.tgz
-	`
+		tar -xzf %s -C /opt/cni/bin
+	`, cniRemote)
 	if _, err := client.Execute(cniInstallCmd); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("安装 CNI plugins 失败: %w", err)
@@ -194,7 +235,7 @@ EOF
 	
 	// 配置 containerd（强制覆盖配置文件）
 	ui.SubStep("配置 containerd...")
-	if err := configureContainerd(client, imageRepo, isGPU); err != nil {
+	if err := configureContainerd(client, cfg, isGPU); err != nil {
 		return err
 	}
 	
@@ -219,27 +260,22 @@ EOF
 }
 
 // configureContainerd 配置 containerd
-func configureContainerd(client *executor.SSHClient, imageRepo string, isGPU bool) error {
-	return generateContainerdConfig(client, imageRepo, isGPU)
+func configureContainerd(client *executor.SSHClient, cfg *config.ClusterConfig, isGPU bool) error {
+	return generateContainerdConfig(client, cfg, isGPU)
 }
 
 // generateContainerdConfig 生成 containerd 配置
-func generateContainerdConfig(client *executor.SSHClient, imageRepo string, isGPU bool) error {
-	// 解析 Harbor 主机
-	harborHost := imageRepo
-	if len(harborHost) > 7 && harborHost[:7] == "http://" {
-		harborHost = harborHost[7:]
-	} else if len(harborHost) > 8 && harborHost[:8] == "https://" {
-		harborHost = harborHost[8:]
-	}
-	// 移除路径部分
-	if idx := bytes.IndexByte([]byte(harborHost), '/'); idx != -1 {
-		harborHost = harborHost[:idx]
-	}
+// 镜像仓库的地址、认证和 TLS 设置来自 spec.imageRepository 和 spec.harbor
+func generateContainerdConfig(client *executor.SSHClient, cfg *config.ClusterConfig, isGPU bool) error {
+	harbor := &cfg.Spec.Harbor
+	harborHost := registryHost(cfg.Spec.ImageRepository)
 	
 	params := ContainerdConfig{
-		ImageRepository: imageRepo,
+		ImageRepository: cfg.Spec.ImageRepository,
 		HarborHost:      harborHost,
+		RegistryAuth:    harbor.Username != "",
+		HarborUsername:  tomlQuote(harbor.Username),
+		HarborPassword:  tomlQuote(harbor.Password),
 	}
 	
 	// 选择模板
@@ -268,56 +304,41 @@ func generateContainerdConfig(client *executor.SSHClient, imageRepo string, isGP
 		return err
 	}
 	
-	// 创建目录并移动配置
+	// 创建目录并移动配置（配置中可能包含 Harbor 密码，只允许 root 读取）
 	_, err = client.Execute(`
 		mkdir -p /etc/containerd
 		mv /tmp/containerd-config.toml /etc/containerd/config.toml
+		chmod 600 /etc/containerd/config.toml
 	`)
 	if err != nil {
 		return err
 	}
 	
-	// 创建镜像仓库配置目录和 hosts.toml
-	// 使用 config_path 方式配置镜像仓库（兼容 containerd v2.x）
-	hostsTomlContent := fmt.Sprintf(`server = "http://%s"
-
-[host."http://%s"]
-  capabilities = ["pull", "resolve", "push"]
-  skip_verify = true
-`, harborHost, harborHost)
-	
-	hostsCmd := fmt.Sprintf("cat > /tmp/hosts.toml << 'EOF'\n%s\nEOF", hostsTomlContent)
-	if _, err := client.Execute(hostsCmd); err != nil {
-		return err
-	}
-	
-	_, err = client.Execute(fmt.Sprintf(`
-		mkdir -p /etc/containerd/certs.d/%s
-		mv /tmp/hosts.toml /etc/containerd/certs.d/%s/hosts.toml
-	`, harborHost, harborHost))
-	
-	return err
+	// 镜像仓库的 hosts.toml 和 CA 证书
+	return configureRegistryHosts(client, harborHost, harbor)
 }
 
 // installK8sComponents 安装 Kubernetes 组件（使用离线包）
 func installK8sComponents(client *executor.SSHClient, k8sVersion string) error {
 	// 初始化包管理器（使用指定的 K8s 版本）
-	pkgMgr := packages.NewManagerWithVersion(k8sVersion)
+	pkgMgr, err := packages.NewManagerWithVersion(k8sVersion)
+	if err != nil {
+		return err
+	}
 	
-	// 检查本地离线包
+	// 检查本地离线包（按节点架构）
 	ui.SubStep("检查 K8s 离线包...")
-	requiredPkgs := []string{"kubectl", "kubeadm", "kubelet"}
-	missingPkgs := pkgMgr.CheckRequiredPackages(requiredPkgs)
-	if len(missingPkgs) > 0 {
+	pkgMgr, err = nodePackageManager(client, pkgMgr, "kubectl", "kubeadm", "kubelet")
+	if err != nil {
 		ui.SubStepFailed()
-		return fmt.Errorf("缺少离线包，请先运行: cd scripts && ./download-all.sh")
+		return err
 	}
 	ui.SubStepDone()
 	
 	// 上传 kubectl
 	ui.SubStep("上传 kubectl...")
 	kubectlBin := pkgMgr.GetPackagePath("kubectl")
-	if err := client.UploadFile(kubectlBin, "/tmp/kubectl"); err != nil {
+	if err := client.UploadFile(kubectlBin, remotePackagePath(kubectlBin)); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("上传 kubectl 失败: %w", err)
 	}
@@ -326,7 +347,7 @@ func installK8sComponents(client *executor.SSHClient, k8sVersion string) error {
 	// 上传 kubeadm
 	ui.SubStep("上传 kubeadm...")
 	kubeadmBin := pkgMgr.GetPackagePath("kubeadm")
-	if err := client.UploadFile(kubeadmBin, "/tmp/kubeadm"); err != nil {
+	if err := client.UploadFile(kubeadmBin, remotePackagePath(kubeadmBin)); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("上传 kubeadm 失败: %w", err)
 	}
@@ -335,7 +356,7 @@ func installK8sComponents(client *executor.SSHClient, k8sVersion string) error {
 	// 上传 kubelet
 	ui.SubStep("上传 kubelet...")
 	kubeletBin := pkgMgr.GetPackagePath("kubelet")
-	if err := client.UploadFile(kubeletBin, "/tmp/kubelet"); err != nil {
+	if err := client.UploadFile(kubeletBin, remotePackagePath(kubeletBin)); err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("上传 kubelet 失败: %w", err)
 	}
@@ -344,10 +365,9 @@ func installK8sComponents(client *executor.SSHClient, k8sVersion string) error {
 	// 安装二进制文件
 	ui.SubStep("安装 K8s 组件...")
 	installCmd := `
-		install -m 755 /tmp/kubectl /usr/local/bin/kubectl
-		install -m 755 /tmp/kubeadm /usr/local/bin/kubeadm
-		install -m 755 /tmp/kubelet /usr/local/bin/kubelet
-		rm -f /tmp/kubectl /tmp/kubeadm /tmp/kubelet
+		install -m 755 ` + remotePackagePath(kubectlBin) + ` /usr/local/bin/kubectl
+		install -m 755 ` + remotePackagePath(kubeadmBin) + ` /usr/local/bin/kubeadm
+		install -m 755 ` + remotePackagePath(kubeletBin) + ` /usr/local/bin/kubelet
 		
 		# 创建 kubelet systemd 服务
 		mkdir -p /etc/systemd/system/kubelet.service.d
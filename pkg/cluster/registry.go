@@ -0,0 +1,251 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/events"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+const (
+	// registryCertsDir containerd 镜像仓库配置目录（config_path）
+	registryCertsDir = "/etc/containerd/certs.d"
+
+	// registryCheckImage 验证镜像拉取使用的镜像（与 containerd 模板中的 sandbox_image 一致）
+	registryCheckImage = "pause:3.10.1"
+
+	// containerdReadyTimeout 重启 containerd 后等待其就绪的时间
+	containerdReadyTimeout = 60 * time.Second
+)
+
+// registryHost 从镜像仓库地址中解析主机名（去掉协议和路径）
+func registryHost(imageRepo string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(imageRepo, "http://"), "https://")
+	if idx := strings.IndexByte(host, '/'); idx != -1 {
+		host = host[:idx]
+	}
+	return host
+}
+
+// registryCAPath 节点上 Harbor CA 证书的路径
+func registryCAPath(host string) string {
+	return fmt.Sprintf("%s/%s/ca.crt", registryCertsDir, host)
+}
+
+// generateRegistryHostsToml 生成镜像仓库的 hosts.toml
+//   - 配置 caFile: 使用 HTTPS，并用该 CA 校验证书
+//   - insecure: 先尝试 HTTPS（跳过证书校验），失败时回退到 HTTP
+//   - 其他情况: 使用 HTTP
+func generateRegistryHostsToml(host string, harbor *config.HarborConfig) string {
+	switch {
+	case harbor.CAFile != "":
+		return fmt.Sprintf(`server = "https://%s"
+
+[host."https://%s"]
+  capabilities = ["pull", "resolve", "push"]
+  ca = "%s"
+`, host, host, registryCAPath(host))
+	case harbor.Insecure:
+		return fmt.Sprintf(`server = "http://%s"
+
+[host."https://%s"]
+  capabilities = ["pull", "resolve", "push"]
+  skip_verify = true
+
+[host."http://%s"]
+  capabilities = ["pull", "resolve", "push"]
+`, host, host, host)
+	default:
+		return fmt.Sprintf(`server = "http://%s"
+
+[host."http://%s"]
+  capabilities = ["pull", "resolve", "push"]
+  skip_verify = true
+`, host, host)
+	}
+}
+
+// configureRegistryHosts 写入镜像仓库的 hosts.toml，配置了 caFile 时上传 CA 证书
+// 未配置 caFile 时删除之前上传的 CA 证书
+func configureRegistryHosts(client *executor.SSHClient, host string, harbor *config.HarborConfig) error {
+	hostDir := fmt.Sprintf("%s/%s", registryCertsDir, host)
+	if _, err := client.Execute("mkdir -p " + hostDir); err != nil {
+		return err
+	}
+
+	if harbor.CAFile != "" {
+		if err := client.UploadFile(config.ExpandHomePath(harbor.CAFile), registryCAPath(host)); err != nil {
+			return fmt.Errorf("上传 Harbor CA 证书失败: %w", err)
+		}
+	} else if _, err := client.Execute("rm -f " + registryCAPath(host)); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("cat > /tmp/hosts.toml << 'EOF'\n%s\nEOF", generateRegistryHostsToml(host, harbor))
+	if _, err := client.Execute(cmd); err != nil {
+		return err
+	}
+	_, err := client.Execute(fmt.Sprintf("mv /tmp/hosts.toml %s/hosts.toml", hostDir))
+	return err
+}
+
+// tomlQuote 将字符串转换为 TOML 基本字符串（带双引号）
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// detectHarborChanges 检测 Harbor 认证和 TLS 配置变更
+func detectHarborChanges(oldCfg, newCfg *config.ClusterConfig) []ConfigChange {
+	var changes []ConfigChange
+	oldHarbor, newHarbor := oldCfg.Spec.Harbor, newCfg.Spec.Harbor
+
+	if oldHarbor.Username != newHarbor.Username || oldHarbor.Password != newHarbor.Password {
+		changes = append(changes, ConfigChange{
+			Type:              "Harbor",
+			Description:       "更新 Harbor 认证信息",
+			AffectedComponent: "Containerd",
+			RequiresRestart:   true,
+		})
+	}
+
+	if oldHarbor.Insecure != newHarbor.Insecure || oldHarbor.CAFile != newHarbor.CAFile {
+		changes = append(changes, ConfigChange{
+			Type:              "Harbor",
+			Description:       "更新 Harbor TLS 配置",
+			OldValue:          formatHarborTLS(&oldHarbor),
+			NewValue:          formatHarborTLS(&newHarbor),
+			AffectedComponent: "Containerd",
+			RequiresRestart:   true,
+		})
+	}
+
+	return changes
+}
+
+func formatHarborTLS(harbor *config.HarborConfig) string {
+	switch {
+	case harbor.CAFile != "":
+		return "HTTPS，CA: " + harbor.CAFile
+	case harbor.Insecure:
+		return "HTTPS（跳过证书校验）/ HTTP"
+	default:
+		return "HTTP"
+	}
+}
+
+// rolloutRegistryConfig 逐个节点重新生成 containerd 配置并重启 containerd
+// 每个节点重启后验证能否拉取镜像，失败时停止，避免所有节点同时无法拉取镜像
+func rolloutRegistryConfig(cfg *config.ClusterConfig) error {
+	ui.Header("更新 containerd 镜像仓库配置")
+
+	image := cfg.Spec.ImageRepository + "/" + registryCheckImage
+	var updated []string
+	for i := range cfg.Spec.Nodes {
+		node := &cfg.Spec.Nodes[i]
+		ui.Step(i+1, len(cfg.Spec.Nodes), "更新节点: %s (%s)", node.Hostname, node.IP)
+
+		if err := updateNodeRegistryConfig(cfg, node, image); err != nil {
+			if len(updated) > 0 {
+				ui.Warning("已更新的节点: %s", strings.Join(updated, ", "))
+			}
+			return events.WrapNode(node.Hostname, "registry", fmt.Errorf("节点 %s: %w", node.Hostname, err))
+		}
+		updated = append(updated, node.Hostname)
+	}
+
+	if err := updateHarborSecret(cfg); err != nil {
+		ui.Warning("更新 Harbor 认证记录失败: %v", err)
+	}
+
+	ui.Success("所有节点的镜像仓库配置已更新")
+	return nil
+}
+
+// updateNodeRegistryConfig 更新单个节点的 containerd 配置、重启 containerd 并验证镜像拉取
+func updateNodeRegistryConfig(cfg *config.ClusterConfig, node *config.NodeConfig, image string) error {
+	client, err := connectNode(node)
+	if err != nil {
+		return fmt.Errorf("SSH 连接失败: %w", err)
+	}
+	defer client.Close()
+
+	ui.SubStep("更新 containerd 配置...")
+	if err := generateContainerdConfig(client, cfg, node.GPU); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("更新 containerd 配置失败: %w", err)
+	}
+	ui.SubStepDone()
+
+	ui.SubStep("重启 containerd...")
+	restartCmd := fmt.Sprintf(`
+		systemctl restart containerd
+		for i in $(seq 1 %d); do
+			ctr version >/dev/null 2>&1 && exit 0
+			sleep 1
+		done
+		exit 1
+	`, int(containerdReadyTimeout.Seconds()))
+	if _, err := client.ExecuteWithTimeout(restartCmd, containerdReadyTimeout+10*time.Second); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("containerd 重启后未就绪: %w", err)
+	}
+	ui.SubStepDone()
+
+	ui.SubStep("验证镜像拉取 (%s)...", image)
+	if _, err := client.Execute(registryPullCommand(cfg, image)); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("拉取镜像失败，请检查 Harbor 地址、认证和证书配置: %w", err)
+	}
+	ui.SubStepDone()
+	return nil
+}
+
+// registryPullCommand 生成验证镜像拉取的命令
+// 优先使用 crictl（经过 CRI，与 kubelet 使用相同的认证配置），没有 crictl 时使用 ctr
+func registryPullCommand(cfg *config.ClusterConfig, image string) string {
+	ctrCmd := fmt.Sprintf("ctr -n k8s.io images pull --hosts-dir %s", registryCertsDir)
+	if cfg.Spec.Harbor.Username != "" {
+		ctrCmd += " --user " + shellQuote(cfg.Spec.Harbor.Username+":"+cfg.Spec.Harbor.Password)
+	}
+	return fmt.Sprintf(`
+		if command -v crictl >/dev/null 2>&1; then
+			crictl --runtime-endpoint unix:///run/containerd/containerd.sock pull %s
+		else
+			%s %s
+		fi
+	`, image, ctrCmd, image)
+}
+
+// updateHarborSecret 更新集群中记录的 Harbor 认证信息，认证信息被清空时删除 Secret
+func updateHarborSecret(cfg *config.ClusterConfig) error {
+	client, err := connectFirstMaster(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if cfg.Spec.Harbor.Username == "" && cfg.Spec.Harbor.Password == "" {
+		_, err := client.Execute(fmt.Sprintf("kubectl delete secret %s -n %s --ignore-not-found", DeployerSecret, DeployerNamespace))
+		return err
+	}
+	return saveSensitiveToSecret(client, cfg)
+}
@@ -55,6 +55,15 @@ func ensureSSHKey(forceNew bool) (string, string, error) {
 	privateKeyPath := filepath.Join(sshDir, "id_rsa")
 	publicKeyPath := filepath.Join(sshDir, "id_rsa.pub")
 	
+	// dry-run 时不生成或删除本地密钥
+	if executor.ActiveRecorder() != nil {
+		if pubKey, err := os.ReadFile(publicKeyPath); err == nil && !forceNew {
+			return privateKeyPath, string(pubKey), nil
+		}
+		recordLocal("生成 SSH 密钥", privateKeyPath, "")
+		return privateKeyPath, "<id_rsa.pub>", nil
+	}
+	
 	// 检查是否已存在完整的密钥对
 	if !forceNew {
 		privExists := false
@@ -126,13 +135,9 @@ func ensureSSHKey(forceNew bool) (string, string, error) {
 // setupNodeSSHKey 为单个节点配置 SSH 密钥
 func setupNodeSSHKey(node config.NodeConfig, pubKey string) error {
 	// 使用密码连接（第一次）
-	client, err := executor.NewSSHClientWithPassword(
-		node.IP,
-		node.SSH.Port,
-		node.SSH.User,
-		"", // 不使用密钥
-		node.SSH.Password,
-	)
+	opts := nodeClientOptions(&node)
+	opts.KeyFile = "" // 不使用密钥
+	client, err := executor.NewSSHClientWithOptions(opts)
 	if err != nil {
 		return fmt.Errorf("SSH 连接失败: %w", err)
 	}
@@ -183,7 +188,9 @@ func setupNodeSSHKey(node config.NodeConfig, pubKey string) error {
 	homeDir, _ := os.UserHomeDir()
 	keyPath := filepath.Join(homeDir, ".ssh", "id_rsa")
 	
-	testClient, err := executor.NewSSHClient(node.IP, node.SSH.Port, "root", keyPath)
+	testOpts := nodeClientOptions(&node)
+	testOpts.User, testOpts.KeyFile, testOpts.Password = "root", keyPath, ""
+	testClient, err := executor.NewSSHClientWithOptions(testOpts)
 	if err != nil {
 		ui.SubStepFailed()
 		return fmt.Errorf("验证失败: %w", err)
@@ -200,6 +207,79 @@ func setupNodeSSHKey(node config.NodeConfig, pubKey string) error {
 	return nil
 }
 
+// connectNode 使用节点配置中的 SSH 信息建立连接（支持密钥或密码）
+func connectNode(node *config.NodeConfig) (*executor.SSHClient, error) {
+	return executor.NewSSHClientWithOptions(nodeClientOptions(node))
+}
+
+// nodeClientOptions 根据节点配置生成 SSH 连接选项
+func nodeClientOptions(node *config.NodeConfig) executor.ClientOptions {
+	return executor.ClientOptions{
+		Host:        node.IP,
+		Port:        node.SSH.Port,
+		User:        node.SSH.User,
+		KeyFile:     node.SSH.KeyFile,
+		Password:    node.SSH.Password,
+		NodeName:    node.Hostname,
+		HostKeyMode: executor.HostKeyMode(node.SSH.HostKeyCheck),
+		Bastion:     bastionOptions(node.SSH.Bastion),
+	}
+}
+
+// bastionOptions 将跳板机配置转换为连接选项（未配置时返回 nil）
+func bastionOptions(bastion *config.BastionConfig) *executor.BastionOptions {
+	if bastion == nil {
+		return nil
+	}
+	port := bastion.Port
+	if port == 0 {
+		port = 22
+	}
+	return &executor.BastionOptions{
+		Host:     bastion.Host,
+		Port:     port,
+		User:     bastion.User,
+		KeyFile:  bastion.KeyFile,
+		Password: bastion.Password,
+	}
+}
+
+// ConfigureSSH 使用集群独立的 known_hosts 文件（~/.k8s-deployer/clusters/<name>/known_hosts），
+// 设置集群级别的跳板机，并注册需要在命令日志中隐藏的密码
+func ConfigureSSH(cfg *config.ClusterConfig) error {
+	executor.SetDefaultBastion(bastionOptions(cfg.Spec.Bastion))
+
+	clusterDir, err := config.GetClusterDir(cfg.Metadata.Name)
+	if err != nil {
+		return fmt.Errorf("创建集群数据目录失败: %w", err)
+	}
+	executor.SetKnownHostsFile(filepath.Join(clusterDir, "known_hosts"))
+
+	for _, secret := range clusterSecrets(cfg) {
+		executor.AddSecret(secret)
+	}
+	return nil
+}
+
+// clusterSecrets 返回配置中的密码（SSH、跳板机、Harbor）
+func clusterSecrets(cfg *config.ClusterConfig) []string {
+	secrets := []string{cfg.Spec.Harbor.Password}
+	// containerd 配置中的密码经过 TOML 转义
+	if quoted := tomlQuote(cfg.Spec.Harbor.Password); len(quoted) > 2 {
+		secrets = append(secrets, quoted[1:len(quoted)-1])
+	}
+	if cfg.Spec.Bastion != nil {
+		secrets = append(secrets, cfg.Spec.Bastion.Password)
+	}
+	for _, node := range cfg.Spec.Nodes {
+		secrets = append(secrets, node.SSH.Password)
+		if node.SSH.Bastion != nil {
+			secrets = append(secrets, node.SSH.Bastion.Password)
+		}
+	}
+	return secrets
+}
+
 // executeLocalCommand 执行本地命令
 func executeLocalCommand(cmd string) error {
 	_, err := executor.ExecuteLocalCommand(cmd)
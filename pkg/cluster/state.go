@@ -0,0 +1,229 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/config"
+)
+
+const (
+	// deployStateFile 部署进度文件名（位于 ~/.k8s-deployer/clusters/<name>/）
+	deployStateFile = "state.json"
+
+	// 节点级别的步骤
+	nodeStepPrepared = "prepared"
+	nodeStepJoined   = "joined"
+)
+
+// DeployState 部署进度，用于中断后继续部署
+type DeployState struct {
+	ClusterName string                `json:"clusterName"`
+	Version     string                `json:"version"`
+	NodesHash   string                `json:"nodesHash"` // 节点列表的哈希，节点变化后不能继续部署
+	StartedAt   time.Time             `json:"startedAt"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+	Phases      map[string]time.Time  `json:"phases"` // 已完成的阶段 -> 完成时间
+	Nodes       map[string]*NodeState `json:"nodes"`  // 主机名 -> 节点进度
+
+	path string
+	mu   sync.Mutex
+}
+
+// NodeState 单个节点的部署进度
+type NodeState struct {
+	IP    string               `json:"ip"`
+	Steps map[string]time.Time `json:"steps"` // 已完成的步骤 -> 完成时间
+}
+
+// GetDeployStatePath 获取集群部署进度文件路径
+func GetDeployStatePath(clusterName string) (string, error) {
+	clusterDir, err := config.GetClusterDir(clusterName)
+	if err != nil {
+		return "", fmt.Errorf("创建集群数据目录失败: %w", err)
+	}
+	return filepath.Join(clusterDir, deployStateFile), nil
+}
+
+// NewDeployState 创建新的部署进度（会覆盖已有的进度文件）
+func NewDeployState(cfg *config.ClusterConfig) (*DeployState, error) {
+	path, err := GetDeployStatePath(cfg.Metadata.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &DeployState{
+		ClusterName: cfg.Metadata.Name,
+		Version:     cfg.Spec.Version,
+		NodesHash:   NodesHash(cfg),
+		StartedAt:   time.Now(),
+		Phases:      make(map[string]time.Time),
+		Nodes:       make(map[string]*NodeState),
+		path:        path,
+	}
+	for _, node := range cfg.Spec.Nodes {
+		state.Nodes[node.Hostname] = &NodeState{IP: node.IP, Steps: make(map[string]time.Time)}
+	}
+
+	return state, state.Save()
+}
+
+// NodesHash 计算配置中节点列表（顺序、主机名、角色、IP、GPU）的哈希
+func NodesHash(cfg *config.ClusterConfig) string {
+	h := sha256.New()
+	for _, node := range cfg.Spec.Nodes {
+		fmt.Fprintf(h, "%s|%s|%s|%s|%t\n", node.Hostname, node.Role, node.IP, node.SecondaryIP, node.GPU)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// LoadDeployState 加载已有的部署进度
+func LoadDeployState(clusterName string) (*DeployState, error) {
+	path, err := GetDeployStatePath(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("没有找到集群 %s 的部署进度: %s", clusterName, path)
+		}
+		return nil, fmt.Errorf("读取部署进度失败: %w", err)
+	}
+
+	var state DeployState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析部署进度失败: %w", err)
+	}
+	if state.Phases == nil {
+		state.Phases = make(map[string]time.Time)
+	}
+	if state.Nodes == nil {
+		state.Nodes = make(map[string]*NodeState)
+	}
+	state.path = path
+
+	return &state, nil
+}
+
+// Save 保存部署进度到文件
+func (s *DeployState) Save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked()
+}
+
+func (s *DeployState) saveLocked() error {
+	s.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化部署进度失败: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免中断时留下损坏的文件
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("写入部署进度失败: %w", err)
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// Remove 删除进度文件（部署完成后调用）
+func (s *DeployState) Remove() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除部署进度失败: %w", err)
+	}
+	return nil
+}
+
+// Path 返回进度文件路径
+func (s *DeployState) Path() string {
+	return s.path
+}
+
+// PhaseDone 检查阶段是否已完成
+func (s *DeployState) PhaseDone(phase string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.Phases[phase]
+	return ok
+}
+
+// MarkPhaseDone 标记阶段完成并保存
+func (s *DeployState) MarkPhaseDone(phase string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Phases[phase] = time.Now()
+	return s.saveLocked()
+}
+
+// ResetPhase 清除阶段及其节点步骤的完成记录（用于强制重新执行）
+func (s *DeployState) ResetPhase(phase string, nodeStep string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Phases, phase)
+	if nodeStep != "" {
+		for _, node := range s.Nodes {
+			delete(node.Steps, nodeStep)
+		}
+	}
+	return s.saveLocked()
+}
+
+// NodeStepDone 检查节点步骤是否已完成
+func (s *DeployState) NodeStepDone(hostname, step string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node, ok := s.Nodes[hostname]
+	if !ok {
+		return false
+	}
+	_, ok = node.Steps[step]
+	return ok
+}
+
+// MarkNodeStepDone 标记节点步骤完成并保存（并发安全）
+func (s *DeployState) MarkNodeStepDone(hostname, ip, step string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node, ok := s.Nodes[hostname]
+	if !ok {
+		node = &NodeState{IP: ip}
+		s.Nodes[hostname] = node
+	}
+	if node.Steps == nil {
+		node.Steps = make(map[string]time.Time)
+	}
+	node.Steps[step] = time.Now()
+	return s.saveLocked()
+}
@@ -54,9 +54,19 @@ func UpdateCluster(newCfg *config.ClusterConfig, onlyBGP bool, autoConfirm bool)
 			ui.Info("  - Pod 网段 (spec.networking.podSubnet)")
 			ui.Info("  - Service 网段 (spec.networking.serviceSubnet)")
 			ui.Info("  - Kubernetes 版本 (spec.version)")
+			ui.Info("  - 高可用 VIP 提供者 (spec.ha.provider)")
+			ui.Info("  - 高可用模式和外部负载均衡器地址 (spec.ha.mode / spec.ha.endpoint)")
+			ui.Info("  - 已有节点的 IP 和角色 (spec.nodes[].ip/secondaryIP/role)")
 			return fmt.Errorf("配置验证失败")
 		}
 		ui.Success("不可变配置检查通过")
+
+		if !onlyBGP {
+			if err := validateNodeChanges(oldCfg, newCfg); err != nil {
+				ui.Error("%v", err)
+				return fmt.Errorf("配置验证失败")
+			}
+		}
 	}
 
 	// 检测并显示变更
@@ -261,16 +271,14 @@ func detectAllChanges(oldCfg, newCfg *config.ClusterConfig) []ConfigChange {
 	bgpChanges := detectBGPChanges(oldCfg, newCfg)
 	changes = append(changes, bgpChanges...)
 
-	// Harbor 认证变更
-	if oldCfg.Spec.Harbor.Username != newCfg.Spec.Harbor.Username ||
-		oldCfg.Spec.Harbor.Password != newCfg.Spec.Harbor.Password {
-		changes = append(changes, ConfigChange{
-			Type:              "Harbor",
-			Description:       "更新 Harbor 认证信息",
-			AffectedComponent: "Containerd",
-			RequiresRestart:   false,
-		})
-	}
+	// 节点新增和删除（以及 hosts、HAProxy）
+	changes = append(changes, detectNodeChanges(oldCfg, newCfg)...)
+
+	// 节点标签、污点和注解变更
+	changes = append(changes, detectNodeMetadataChanges(oldCfg, newCfg)...)
+
+	// Harbor 认证和 TLS 变更
+	changes = append(changes, detectHarborChanges(oldCfg, newCfg)...)
 
 	return changes
 }
@@ -382,12 +390,42 @@ func updateFull(client executor.CommandExecutor, oldCfg, newCfg *config.ClusterC
 	}
 
 	// 应用变更
+	nodeMetadataUpdated := false
+	nodesUpdated := false
+	registryUpdated := false
 	for _, change := range changes {
 		switch change.Type {
 		case "BGP":
 			if err := updateBGPOnly(client, newCfg); err != nil {
 				return err
 			}
+		case "Node", "Hosts", "HAProxy":
+			// 节点列表一次同步
+			if nodesUpdated {
+				continue
+			}
+			if err := reconcileNodes(client, oldCfg, newCfg); err != nil {
+				return err
+			}
+			nodesUpdated = true
+		case "Harbor":
+			// 认证和 TLS 变更一次滚动更新所有节点
+			if registryUpdated {
+				continue
+			}
+			if err := rolloutRegistryConfig(newCfg); err != nil {
+				return err
+			}
+			registryUpdated = true
+		case "NodeMetadata":
+			// 所有节点一次同步
+			if nodeMetadataUpdated {
+				continue
+			}
+			if err := reconcileNodeMetadata(client, oldCfg, newCfg); err != nil {
+				return err
+			}
+			nodeMetadataUpdated = true
 		}
 	}
 
@@ -0,0 +1,321 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/kubeadm"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// diffNodes 按主机名比较新旧配置的节点列表，返回新增和删除的节点
+func diffNodes(oldCfg, newCfg *config.ClusterConfig) (added, removed []config.NodeConfig) {
+	for _, node := range newCfg.Spec.Nodes {
+		if findNode(oldCfg, node.Hostname) == nil {
+			added = append(added, node)
+		}
+	}
+	for _, node := range oldCfg.Spec.Nodes {
+		if findNode(newCfg, node.Hostname) == nil {
+			removed = append(removed, node)
+		}
+	}
+	return added, removed
+}
+
+// validateNodeChanges 检查节点变更能否通过 update 执行
+// 变更通过第一个 Master 执行，所以它必须是集群中已有的节点，且不能被删除
+func validateNodeChanges(oldCfg, newCfg *config.ClusterConfig) error {
+	if first := getFirstMasterNode(newCfg); first != nil && findNode(oldCfg, first.Hostname) == nil {
+		return fmt.Errorf("第一个 Master 节点 %s 必须是集群中已有的节点，请调整 spec.nodes 中节点的顺序", first.Hostname)
+	}
+	if first := getFirstMasterNode(oldCfg); first != nil && findNode(newCfg, first.Hostname) == nil {
+		return fmt.Errorf("不能删除第一个 Master 节点 %s", first.Hostname)
+	}
+	return nil
+}
+
+// detectNodeChanges 检测节点的新增和删除，以及随之需要更新的 hosts 和 HAProxy
+func detectNodeChanges(oldCfg, newCfg *config.ClusterConfig) []ConfigChange {
+	added, removed := diffNodes(oldCfg, newCfg)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	var changes []ConfigChange
+	for _, node := range added {
+		changes = append(changes, ConfigChange{
+			Type:              "Node",
+			Description:       fmt.Sprintf("添加 %s 节点 %s (%s)", nodeRoleName(node.Role), node.Hostname, node.IP),
+			NewValue:          node.IP,
+			AffectedComponent: "Node",
+			RequiresRestart:   false,
+		})
+	}
+	for _, node := range removed {
+		changes = append(changes, ConfigChange{
+			Type:              "Node",
+			Description:       fmt.Sprintf("删除 %s 节点 %s (%s)：驱逐 Pod、kubeadm reset、删除节点", nodeRoleName(node.Role), node.Hostname, node.IP),
+			OldValue:          node.IP,
+			AffectedComponent: "Node",
+			RequiresRestart:   false,
+		})
+	}
+
+	changes = append(changes, ConfigChange{
+		Type:              "Hosts",
+		Description:       "更新所有节点的 /etc/hosts",
+		OldValue:          fmt.Sprintf("%d 个节点", len(oldCfg.Spec.Nodes)),
+		NewValue:          fmt.Sprintf("%d 个节点", len(newCfg.Spec.Nodes)),
+		AffectedComponent: "Hosts",
+		RequiresRestart:   false,
+	})
+
+	if newCfg.Spec.HA.UsesHAProxy() && mastersChanged(added, removed) {
+		changes = append(changes, ConfigChange{
+			Type:              "HAProxy",
+			Description:       "更新 HAProxy 后端（Master 节点列表）",
+			OldValue:          strings.Join(masterIPs(oldCfg), ","),
+			NewValue:          strings.Join(masterIPs(newCfg), ","),
+			AffectedComponent: "HAProxy",
+			RequiresRestart:   true,
+		})
+	}
+
+	return changes
+}
+
+// reconcileNodes 将新配置中的节点列表同步到集群
+// 顺序：更新 hosts → 加入新节点 → 更新 HAProxy 后端 → 删除节点
+// HAProxy 在删除节点之前更新，避免请求转发到正在重置的 Master
+func reconcileNodes(client executor.CommandExecutor, oldCfg, newCfg *config.ClusterConfig) error {
+	added, removed := diffNodes(oldCfg, newCfg)
+	fillRemovedNodeSSH(removed, newCfg)
+
+	ui.Step(1, 4, "更新所有节点的 hosts 文件")
+	if err := SetupHostsFile(newCfg); err != nil {
+		return fmt.Errorf("更新 hosts 文件失败: %w", err)
+	}
+
+	ui.Step(2, 4, "加入新节点")
+	if err := joinAddedNodes(client, newCfg, added); err != nil {
+		return err
+	}
+
+	if newCfg.Spec.HA.UsesHAProxy() && mastersChanged(added, removed) {
+		ui.Step(3, 4, "更新 HAProxy 后端")
+		if err := updateHAProxyBackends(newCfg); err != nil {
+			return fmt.Errorf("更新 HAProxy 配置失败: %w", err)
+		}
+	} else {
+		ui.Step(3, 4, "Master 节点未变化，跳过 HAProxy")
+	}
+
+	ui.Step(4, 4, "删除节点")
+	return removeDeletedNodes(client, removed)
+}
+
+// joinAddedNodes 准备新节点并加入集群，Master 依次加入后再加入 Worker
+// 已在集群中的节点（上次 update 中途失败时已加入）会跳过 join
+func joinAddedNodes(client executor.CommandExecutor, cfg *config.ClusterConfig, nodes []config.NodeConfig) error {
+	if len(nodes) == 0 {
+		ui.Info("没有新增节点")
+		return nil
+	}
+
+	masterClient, err := connectFirstMaster(cfg)
+	if err != nil {
+		return err
+	}
+	defer masterClient.Close()
+
+	var masters, workers []config.NodeConfig
+	for _, node := range nodes {
+		if node.Role == "master" {
+			masters = append(masters, node)
+		} else {
+			workers = append(workers, node)
+		}
+	}
+
+	for _, group := range []struct {
+		nodes     []config.NodeConfig
+		forMaster bool
+	}{{masters, true}, {workers, false}} {
+		if len(group.nodes) == 0 {
+			continue
+		}
+
+		// Master 的 join 信息需要上传控制平面证书
+		joinInfo, err := kubeadm.GetJoinInfo(masterClient, getControlPlaneEndpoint(cfg), group.forMaster)
+		if err != nil {
+			return err
+		}
+
+		for i := range group.nodes {
+			node := &group.nodes[i]
+			if err := addClusterNode(client, masterClient, cfg, node, joinInfo); err != nil {
+				return fmt.Errorf("添加节点 %s 失败: %w", node.Hostname, err)
+			}
+		}
+	}
+	return nil
+}
+
+// addClusterNode 准备单个节点并加入集群
+func addClusterNode(client executor.CommandExecutor, masterClient *executor.SSHClient, cfg *config.ClusterConfig, node *config.NodeConfig, joinInfo *kubeadm.JoinCommand) error {
+	if _, err := client.Execute(fmt.Sprintf("kubectl get node %s", node.Hostname)); err == nil {
+		ui.Info("✓ 节点 %s 已在集群中，跳过 join", node.Hostname)
+		labelJoinedNode(masterClient, cfg, node)
+		return nil
+	}
+
+	if err := PrepareNode(cfg, node); err != nil {
+		return err
+	}
+
+	nodeClient, err := connectNode(node)
+	if err != nil {
+		return fmt.Errorf("连接节点失败: %w", err)
+	}
+	defer nodeClient.Close()
+
+	ui.SubStep("加入 %s 节点 %s...", nodeRoleName(node.Role), node.Hostname)
+	if err := joinNode(nodeClient, cfg, node, joinInfo); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("加入集群失败: %w", err)
+	}
+	ui.SubStepDone()
+
+	if err := cleanupRemotePackages(nodeClient); err != nil {
+		ui.Warning("%v", err)
+	}
+	labelJoinedNode(masterClient, cfg, node)
+	return nil
+}
+
+// labelJoinedNode 为新加入的节点设置 GPU 标签、k8s-deployer 管理标签，以及配置的标签、污点和注解
+// 失败只给出警告，不影响节点加入
+func labelJoinedNode(masterClient *executor.SSHClient, cfg *config.ClusterConfig, node *config.NodeConfig) {
+	if node.GPU {
+		ui.SubStep("标记 GPU 节点...")
+		if err := LabelGPUNode(masterClient, node.Hostname); err != nil {
+			ui.SubStepFailed()
+			ui.Warning("标记 GPU 节点失败: %v", err)
+		} else {
+			ui.SubStepDone()
+		}
+	}
+
+	labels := fmt.Sprintf("%s=true,%s=%s", DeployerLabel, DeployerVersion, DeployerToolVersion)
+	if _, err := masterClient.Execute(fmt.Sprintf("kubectl label node %s %s --overwrite", node.Hostname, labels)); err != nil {
+		ui.Warning("标记节点失败: %v", err)
+	}
+
+	meta := config.ResolveNodeMetadata(cfg, node)
+	if err := applyNodeMetadata(masterClient, node.Hostname, &meta, nil); err != nil {
+		ui.Warning("%v", err)
+	}
+}
+
+// removeDeletedNodes 驱逐 Pod、重置并删除节点
+// Master 节点的 kubeadm reset 会把自己从 etcd 集群中移除，所以在 kubectl delete node 之前执行
+func removeDeletedNodes(client executor.CommandExecutor, nodes []config.NodeConfig) error {
+	if len(nodes) == 0 {
+		ui.Info("没有需要删除的节点")
+		return nil
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
+		ui.Info("删除 %s 节点 %s (%s)", nodeRoleName(node.Role), node.Hostname, node.IP)
+
+		ui.SubStep("执行 kubectl drain...")
+		drainCmd := fmt.Sprintf("kubectl drain %s --delete-emptydir-data --ignore-daemonsets --force --timeout=300s", node.Hostname)
+		if _, err := client.Execute(drainCmd); err != nil {
+			ui.SubStepFailed()
+			ui.Warning("驱逐 Pod 失败: %v", err)
+		} else {
+			ui.SubStepDone()
+		}
+
+		if err := resetRemovedNode(node); err != nil {
+			ui.Warning("重置节点失败: %v", err)
+			ui.Warning("需要手动在节点上执行: %s", kubeadm.GetResetCommand())
+			if node.Role == "master" {
+				ui.Warning("并确认 etcd 成员已删除: etcdctl member list / etcdctl member remove <ID>")
+			}
+		}
+
+		ui.SubStep("执行 kubectl delete node...")
+		if _, err := client.Execute(fmt.Sprintf("kubectl delete node %s --ignore-not-found", node.Hostname)); err != nil {
+			ui.SubStepFailed()
+			return fmt.Errorf("删除节点 %s 失败: %w", node.Hostname, err)
+		}
+		ui.SubStepDone()
+	}
+	return nil
+}
+
+// updateHAProxyBackends 按新配置重写第一个 Master 上的 HAProxy 配置并重新加载
+func updateHAProxyBackends(cfg *config.ClusterConfig) error {
+	client, err := connectFirstMaster(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ui.SubStep("更新 HAProxy 后端...")
+	cmd := fmt.Sprintf("cat > /tmp/haproxy.cfg << 'EOF'\n%s\nEOF", generateHAProxyConfig(cfg))
+	if _, err := client.Execute(cmd); err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	if _, err := client.Execute("haproxy -c -f /tmp/haproxy.cfg && mv /tmp/haproxy.cfg /etc/haproxy/haproxy.cfg && systemctl reload haproxy"); err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	ui.SubStepDone()
+	return nil
+}
+
+// fillRemovedNodeSSH 集群保存的配置中不包含 SSH 密码，
+// 删除的节点未配置认证方式时使用第一个 Master 的 SSH 配置（与 LoadClusterConfigFromMaster 一致）
+func fillRemovedNodeSSH(nodes []config.NodeConfig, cfg *config.ClusterConfig) {
+	master := getFirstMasterNode(cfg)
+	if master == nil {
+		return
+	}
+	for i := range nodes {
+		ssh := &nodes[i].SSH
+		if ssh.KeyFile == "" && ssh.Password == "" {
+			*ssh = master.SSH
+		}
+	}
+}
+
+func mastersChanged(added, removed []config.NodeConfig) bool {
+	for _, node := range append(append([]config.NodeConfig{}, added...), removed...) {
+		if node.Role == "master" {
+			return true
+		}
+	}
+	return false
+}
+
+func masterIPs(cfg *config.ClusterConfig) []string {
+	var ips []string
+	for _, node := range getMasterNodes(cfg) {
+		ips = append(ips, node.IP)
+	}
+	return ips
+}
+
+func nodeRoleName(role string) string {
+	if role == "master" {
+		return "Master"
+	}
+	return "Worker"
+}
@@ -0,0 +1,400 @@
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/executor"
+	"stormdragon/k8s-deployer/pkg/kubeadm"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// UpgradeCluster 滚动升级集群的 Kubernetes 版本
+// 流程：第一个 Master 执行 kubeadm upgrade apply，其他 Master 执行 kubeadm upgrade node，
+// Worker 逐个 cordon → drain → 升级 → uncordon，最后更新 ConfigMap 中保存的配置
+func UpgradeCluster(cfg *config.ClusterConfig, autoConfirm bool) error {
+	targetVersion := cfg.Spec.Version
+	ui.Header(fmt.Sprintf("升级集群: %s (目标版本 %s)", cfg.Metadata.Name, targetVersion))
+
+	firstMaster := getFirstMasterNode(cfg)
+	if firstMaster == nil {
+		return fmt.Errorf("配置中没有 Master 节点")
+	}
+
+	// 连接第一个 Master（所有 kubectl 操作都在该节点执行）
+	ui.Info("连接 Master 节点: %s (%s)", firstMaster.Hostname, firstMaster.IP)
+	masterClient, err := connectNode(firstMaster)
+	if err != nil {
+		return fmt.Errorf("连接 Master 节点失败: %w", err)
+	}
+	defer masterClient.Close()
+	masterClient.SetUploadProgress(true)
+
+	// 检测当前版本
+	currentVersion, oldCfg, err := detectClusterVersion(masterClient, cfg.Metadata.Name)
+	if err != nil {
+		return err
+	}
+	ui.Info("当前版本: %s", currentVersion)
+	ui.Info("目标版本: %s", targetVersion)
+
+	if err := validateUpgradeVersion(currentVersion, targetVersion); err != nil {
+		return err
+	}
+
+	// 除版本以外的不可变字段不允许在升级时修改
+	if oldCfg != nil {
+		oldCopy := *oldCfg
+		oldCopy.Spec.Version = targetVersion
+		if err := config.ValidateImmutableFields(&oldCopy, cfg); err != nil {
+			return err
+		}
+	}
+
+	// 检查目标版本的离线包
+	pkgMgr, err := packages.NewManagerWithVersion(targetVersion)
+	if err != nil {
+		return err
+	}
+	archs, err := clusterArchs(cfg)
+	if err != nil {
+		return err
+	}
+	for _, arch := range archs {
+		missingPkgs := pkgMgr.ForArch(arch).CheckRequiredPackages([]string{"kubeadm", "kubelet", "kubectl"})
+		if len(missingPkgs) > 0 {
+			return fmt.Errorf("缺少 %s (%s) 的离线包: %s，请先运行: cd scripts && ./download-all.sh --k8s-version %s --arch %s",
+				targetVersion, arch, strings.Join(missingPkgs, ", "), targetVersion, arch)
+		}
+	}
+
+	otherMasters := getOtherMasters(cfg, firstMaster.IP)
+	workers := getWorkers(cfg)
+
+	// 显示升级计划
+	ui.Header("升级计划")
+	ui.Info("1. %s: kubeadm upgrade apply %s", firstMaster.Hostname, targetVersion)
+	for _, node := range otherMasters {
+		ui.Info("2. %s: kubeadm upgrade node", node.Hostname)
+	}
+	for _, node := range workers {
+		ui.Info("3. %s: cordon → drain → kubeadm upgrade node → 升级 kubelet → uncordon", node.Hostname)
+	}
+	ui.Info("")
+	ui.Warning("请确认镜像仓库 %s 中已同步 %s 的控制平面镜像", cfg.Spec.ImageRepository, targetVersion)
+	ui.Info("")
+
+	if !autoConfirm && !ui.WaitForConfirmation("确认开始升级？") {
+		ui.Warning("升级已取消")
+		return nil
+	}
+
+	// ========================================
+	// 阶段 1: 升级第一个 Master
+	// ========================================
+	ui.Header("阶段 1: 升级第一个 Master 节点")
+	if err := upgradeFirstMaster(masterClient, firstMaster, pkgMgr, targetVersion); err != nil {
+		return err
+	}
+
+	// ========================================
+	// 阶段 2: 升级其他 Master
+	// ========================================
+	if len(otherMasters) > 0 {
+		ui.Header("阶段 2: 升级其他 Master 节点")
+		for i := range otherMasters {
+			node := &otherMasters[i]
+			ui.Step(i+1, len(otherMasters), "升级 Master: %s", node.Hostname)
+			if err := upgradeNode(masterClient, node, pkgMgr, targetVersion); err != nil {
+				return fmt.Errorf("升级节点 %s 失败: %w", node.Hostname, err)
+			}
+		}
+	}
+
+	// ========================================
+	// 阶段 3: 逐个升级 Worker
+	// ========================================
+	if len(workers) > 0 {
+		ui.Header("阶段 3: 滚动升级 Worker 节点")
+		for i := range workers {
+			node := &workers[i]
+			ui.Step(i+1, len(workers), "升级 Worker: %s", node.Hostname)
+			if err := upgradeNode(masterClient, node, pkgMgr, targetVersion); err != nil {
+				return fmt.Errorf("升级节点 %s 失败: %w", node.Hostname, err)
+			}
+		}
+	}
+
+	// ========================================
+	// 阶段 4: 验证并保存配置
+	// ========================================
+	ui.Header("阶段 4: 验证集群")
+	if err := validateCluster(masterClient); err != nil {
+		return err
+	}
+
+	ui.Info("更新集群配置记录...")
+	if err := UpdateClusterConfigMapRemote(masterClient, cfg); err != nil {
+		ui.Warning("更新配置记录失败: %v", err)
+		ui.Warning("这不影响集群使用，但配置记录可能不同步")
+	} else {
+		ui.Success("配置记录已更新")
+	}
+
+	ui.Header("✓ 集群升级完成！")
+	ui.Info("集群版本: %s → %s", currentVersion, targetVersion)
+	return nil
+}
+
+// upgradeFirstMaster 升级第一个 Master 节点（kubeadm upgrade apply）
+func upgradeFirstMaster(client *executor.SSHClient, node *config.NodeConfig, pkgMgr *packages.Manager, version string) error {
+	ui.Step(1, 4, "安装 kubeadm %s", version)
+	if err := installKubeadmBinary(client, pkgMgr); err != nil {
+		return err
+	}
+
+	ui.Step(2, 4, "执行 kubeadm upgrade apply")
+	ui.SubStep("生成升级配置...")
+	tmpFile := "/tmp/kubeadm-upgrade.yaml"
+	cmd := fmt.Sprintf("cat > %s << 'EOF'\n%s\nEOF", tmpFile, kubeadm.GenerateUpgradeConfig(version))
+	if _, err := client.Execute(cmd); err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	ui.SubStepDone()
+
+	ui.SubStep("升级控制平面组件（可能需要几分钟）...")
+	if _, err := client.Execute(kubeadm.GetUpgradeApplyCommand(tmpFile)); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("kubeadm upgrade apply 失败: %w", err)
+	}
+	client.Execute(fmt.Sprintf("rm -f %s", tmpFile))
+	ui.SubStepDone()
+
+	ui.Step(3, 4, "升级 kubelet 和 kubectl")
+	if err := drainNode(client, node.Hostname); err != nil {
+		// 单 Master 集群可能无法完全驱逐，继续升级
+		ui.Warning("驱逐节点 %s 失败: %v", node.Hostname, err)
+	}
+	if err := installKubeletBinaries(client, pkgMgr); err != nil {
+		// 升级失败时恢复调度，避免节点长期不可用
+		uncordonNode(client, node.Hostname)
+		return err
+	}
+
+	ui.Step(4, 4, "恢复节点调度")
+	if err := waitNodeVersion(client, node.Hostname, version); err != nil {
+		return fmt.Errorf("%w（节点 %s 仍处于 cordon 状态，排查后执行 k8s-deployer node uncordon %s 恢复调度）", err, node.Hostname, node.Hostname)
+	}
+	return uncordonNode(client, node.Hostname)
+}
+
+// upgradeNode 升级单个节点（其他 Master 或 Worker）
+// masterClient 用于执行 kubectl 命令
+func upgradeNode(masterClient *executor.SSHClient, node *config.NodeConfig, pkgMgr *packages.Manager, version string) error {
+	client, err := connectNode(node)
+	if err != nil {
+		return fmt.Errorf("SSH 连接失败: %w", err)
+	}
+	defer client.Close()
+	client.SetUploadProgress(true)
+
+	if err := installKubeadmBinary(client, pkgMgr); err != nil {
+		return err
+	}
+
+	ui.SubStep("执行 kubeadm upgrade node...")
+	if _, err := client.Execute(kubeadm.GetUpgradeNodeCommand()); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("kubeadm upgrade node 失败: %w", err)
+	}
+	ui.SubStepDone()
+
+	if err := drainNode(masterClient, node.Hostname); err != nil {
+		return err
+	}
+
+	if err := installKubeletBinaries(client, pkgMgr); err != nil {
+		// 升级失败时恢复调度，避免节点长期不可用
+		uncordonNode(masterClient, node.Hostname)
+		return err
+	}
+
+	if err := waitNodeVersion(masterClient, node.Hostname, version); err != nil {
+		// kubelet 未以新版本就绪时不恢复调度，避免 Pod 调度到异常节点
+		return fmt.Errorf("%w（节点 %s 仍处于 cordon 状态，排查后执行 k8s-deployer node uncordon %s 恢复调度）", err, node.Hostname, node.Hostname)
+	}
+
+	return uncordonNode(masterClient, node.Hostname)
+}
+
+// installKubeadmBinary 上传并安装新版本 kubeadm
+func installKubeadmBinary(client *executor.SSHClient, pkgMgr *packages.Manager) error {
+	ui.SubStep("上传 kubeadm...")
+	pkgMgr, err := nodePackageManager(client, pkgMgr, "kubeadm")
+	if err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	kubeadmBin := pkgMgr.GetPackagePath("kubeadm")
+	if err := client.UploadFile(kubeadmBin, remotePackagePath(kubeadmBin)); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("上传 kubeadm 失败: %w", err)
+	}
+	if _, err := client.Execute(fmt.Sprintf("install -m 755 %s /usr/local/bin/kubeadm", remotePackagePath(kubeadmBin))); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("安装 kubeadm 失败: %w", err)
+	}
+	ui.SubStepDone()
+	return nil
+}
+
+// installKubeletBinaries 上传并安装新版本 kubelet 和 kubectl，然后重启 kubelet
+func installKubeletBinaries(client *executor.SSHClient, pkgMgr *packages.Manager) error {
+	ui.SubStep("上传 kubelet 和 kubectl...")
+	pkgMgr, err := nodePackageManager(client, pkgMgr, "kubelet", "kubectl")
+	if err != nil {
+		ui.SubStepFailed()
+		return err
+	}
+	for _, name := range []string{"kubelet", "kubectl"} {
+		localPath := pkgMgr.GetPackagePath(name)
+		if err := client.UploadFile(localPath, remotePackagePath(localPath)); err != nil {
+			ui.SubStepFailed()
+			return fmt.Errorf("上传 %s 失败: %w", name, err)
+		}
+	}
+	ui.SubStepDone()
+
+	ui.SubStep("安装并重启 kubelet...")
+	installCmd := fmt.Sprintf(`
+		systemctl stop kubelet
+		install -m 755 %s /usr/local/bin/kubelet
+		install -m 755 %s /usr/local/bin/kubectl
+		systemctl daemon-reload
+		systemctl restart kubelet
+	`, remotePackagePath(pkgMgr.GetPackagePath("kubelet")), remotePackagePath(pkgMgr.GetPackagePath("kubectl")))
+	if _, err := client.Execute(installCmd); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("安装 kubelet 失败: %w", err)
+	}
+	ui.SubStepDone()
+
+	// kubeadm、kubelet、kubectl 均已安装，删除缓存的安装包
+	if err := cleanupRemotePackages(client); err != nil {
+		ui.Warning("%v", err)
+	}
+	return nil
+}
+
+// drainNode 驱逐节点上的 Pod（会先 cordon）
+func drainNode(client *executor.SSHClient, nodeName string) error {
+	ui.SubStep("驱逐节点 %s...", nodeName)
+	drainCmd := fmt.Sprintf("kubectl drain %s --ignore-daemonsets --delete-emptydir-data --timeout=300s", nodeName)
+	if _, err := client.Execute(drainCmd); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("驱逐节点失败: %w", err)
+	}
+	ui.SubStepDone()
+	return nil
+}
+
+// uncordonNode 恢复节点调度
+func uncordonNode(client *executor.SSHClient, nodeName string) error {
+	ui.SubStep("恢复节点 %s 调度...", nodeName)
+	if _, err := client.Execute(fmt.Sprintf("kubectl uncordon %s", nodeName)); err != nil {
+		ui.SubStepFailed()
+		return fmt.Errorf("uncordon 节点失败: %w", err)
+	}
+	ui.SubStepDone()
+	return nil
+}
+
+// waitNodeVersion 等待节点 Ready 且 kubelet 版本为目标版本（最多 5 分钟）
+func waitNodeVersion(client *executor.SSHClient, nodeName, version string) error {
+	ui.SubStep("等待节点 %s 就绪...", nodeName)
+
+	query := fmt.Sprintf(
+		`kubectl get node %s -o jsonpath='{.status.nodeInfo.kubeletVersion} {.status.conditions[?(@.type=="Ready")].status}'`,
+		nodeName)
+	for i := 0; i < 60; i++ {
+		output, err := client.Execute(query)
+		if err == nil {
+			fields := strings.Fields(output)
+			if len(fields) == 2 && fields[0] == version && fields[1] == "True" {
+				ui.SubStepDone()
+				return nil
+			}
+		}
+		time.Sleep(5 * time.Second)
+	}
+
+	ui.SubStepFailed()
+	return fmt.Errorf("节点 %s 未能在 5 分钟内以 %s 版本就绪", nodeName, version)
+}
+
+// detectClusterVersion 检测集群当前的 Kubernetes 版本
+// 优先读取 k8s-deployer 保存的配置，失败时回退到 kubeadm-config
+func detectClusterVersion(client *executor.SSHClient, clusterName string) (string, *config.ClusterConfig, error) {
+	oldCfg, err := LoadClusterConfig(client, clusterName)
+	if err == nil && oldCfg.Spec.Version != "" {
+		return oldCfg.Spec.Version, oldCfg, nil
+	}
+	ui.Warning("读取已保存的集群配置失败，从 kubeadm-config 获取版本")
+
+	output, err := client.Execute(
+		"kubectl get configmap kubeadm-config -n kube-system -o jsonpath='{.data.ClusterConfiguration}' | grep kubernetesVersion")
+	if err != nil {
+		return "", nil, fmt.Errorf("获取集群当前版本失败: %w", err)
+	}
+	version := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(output), "kubernetesVersion:"))
+	if version == "" {
+		return "", nil, fmt.Errorf("无法解析集群当前版本: %s", output)
+	}
+	return version, nil, nil
+}
+
+// validateUpgradeVersion 校验升级路径
+// kubeadm 只支持同一 minor 版本内升级或升级到下一个 minor 版本
+func validateUpgradeVersion(current, target string) error {
+	cur, err := parseK8sVersion(current)
+	if err != nil {
+		return fmt.Errorf("当前版本格式不正确: %w", err)
+	}
+	tgt, err := parseK8sVersion(target)
+	if err != nil {
+		return fmt.Errorf("目标版本格式不正确: %w", err)
+	}
+
+	if cur[0] != tgt[0] {
+		return fmt.Errorf("不支持跨 major 版本升级: %s → %s", current, target)
+	}
+	if tgt[1] < cur[1] || (tgt[1] == cur[1] && tgt[2] <= cur[2]) {
+		return fmt.Errorf("目标版本 %s 必须高于当前版本 %s", target, current)
+	}
+	if tgt[1] > cur[1]+1 {
+		return fmt.Errorf("不支持跳过 minor 版本升级: %s → %s，请先升级到 v%d.%d.x", current, target, cur[0], cur[1]+1)
+	}
+	return nil
+}
+
+// parseK8sVersion 解析 vX.Y.Z 格式的版本号
+func parseK8sVersion(version string) ([3]int, error) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	if len(fields) != 3 {
+		return parts, fmt.Errorf("版本应为 vX.Y.Z 格式: %s", version)
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, fmt.Errorf("版本应为 vX.Y.Z 格式: %s", version)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
@@ -77,15 +77,25 @@ func ExpandHomePath(path string) string {
 	return path
 }
 
+// configDirOverride 通过 --config-dir 指定的配置目录
+var configDirOverride string
+
+// SetConfigDir 设置配置目录（空字符串表示使用默认的 ~/.k8s-deployer）
+func SetConfigDir(dir string) {
+	configDirOverride = ExpandHomePath(dir)
+}
+
 // GetConfigDir 获取配置目录
 func GetConfigDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+	configDir := configDirOverride
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(home, ".k8s-deployer")
 	}
 	
-	configDir := filepath.Join(home, ".k8s-deployer")
-	
 	// 确保配置目录存在
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return "", err
@@ -103,3 +113,17 @@ func GetConfigDir() (string, error) {
 	return configDir, nil
 }
 
+// GetClusterDir 获取集群的本地数据目录（~/.k8s-deployer/clusters/<name>）
+func GetClusterDir(clusterName string) (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	clusterDir := filepath.Join(configDir, "clusters", clusterName)
+	if err := os.MkdirAll(clusterDir, 0700); err != nil {
+		return "", err
+	}
+
+	return clusterDir, nil
+}
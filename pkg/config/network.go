@@ -0,0 +1,71 @@
+package config
+
+import (
+	"net"
+	"strings"
+)
+
+// SplitCIDRs 拆分逗号分隔的网段（双栈集群的 podSubnet / serviceSubnet，与 kubeadm 格式一致）
+func SplitCIDRs(cidrs string) []string {
+	var result []string
+	for _, cidr := range strings.Split(cidrs, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			result = append(result, cidr)
+		}
+	}
+	return result
+}
+
+// IsIPv6 判断地址（IP 或 CIDR）是否为 IPv6
+func IsIPv6(addr string) bool {
+	if ip, _, err := net.ParseCIDR(addr); err == nil {
+		return ip.To4() == nil
+	}
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.To4() == nil
+}
+
+// ipFamily 返回地址的地址族名称（用于错误信息）
+func ipFamily(addr string) string {
+	if IsIPv6(addr) {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
+// CIDRsByFamily 按地址族拆分网段，某个地址族未配置时返回空字符串
+func CIDRsByFamily(cidrs string) (ipv4, ipv6 string) {
+	for _, cidr := range SplitCIDRs(cidrs) {
+		if IsIPv6(cidr) {
+			ipv6 = cidr
+		} else {
+			ipv4 = cidr
+		}
+	}
+	return ipv4, ipv6
+}
+
+// IPv4Enabled 集群是否使用 IPv4（Pod 网段包含 IPv4 网段）
+func (n *NetworkConfig) IPv4Enabled() bool {
+	ipv4, _ := CIDRsByFamily(n.PodSubnet)
+	return ipv4 != ""
+}
+
+// IPv6Enabled 集群是否使用 IPv6（Pod 网段包含 IPv6 网段）
+func (n *NetworkConfig) IPv6Enabled() bool {
+	_, ipv6 := CIDRsByFamily(n.PodSubnet)
+	return ipv6 != ""
+}
+
+// DualStack 集群是否为双栈
+func (n *NetworkConfig) DualStack() bool {
+	return n.IPv4Enabled() && n.IPv6Enabled()
+}
+
+// NodeIPs 返回节点的所有地址（ip 和双栈的 secondaryIP）
+func (n *NodeConfig) NodeIPs() []string {
+	if n.SecondaryIP == "" {
+		return []string{n.IP}
+	}
+	return []string{n.IP, n.SecondaryIP}
+}
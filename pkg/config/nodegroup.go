@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NodeMetadata 节点最终生效的标签、污点和注解（节点组与节点配置合并后的结果）
+type NodeMetadata struct {
+	Labels      map[string]string
+	Taints      []TaintConfig
+	Annotations map[string]string
+}
+
+// ResolveNodeMetadata 合并节点组和节点上配置的标签、污点和注解
+// 同名的标签/注解以节点为准，污点按 key+effect 去重
+func ResolveNodeMetadata(cfg *ClusterConfig, node *NodeConfig) NodeMetadata {
+	meta := NodeMetadata{
+		Labels:      make(map[string]string),
+		Annotations: make(map[string]string),
+	}
+
+	if group := FindNodeGroup(cfg, node.Group); group != nil {
+		mergeNodeMetadata(&meta, group.Labels, group.Taints, group.Annotations)
+	}
+	mergeNodeMetadata(&meta, node.Labels, node.Taints, node.Annotations)
+	return meta
+}
+
+func mergeNodeMetadata(meta *NodeMetadata, labels map[string]string, taints []TaintConfig, annotations map[string]string) {
+	for k, v := range labels {
+		meta.Labels[k] = v
+	}
+	for k, v := range annotations {
+		meta.Annotations[k] = v
+	}
+	for _, taint := range taints {
+		replaced := false
+		for i := range meta.Taints {
+			if meta.Taints[i].Key == taint.Key && meta.Taints[i].Effect == taint.Effect {
+				meta.Taints[i] = taint
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			meta.Taints = append(meta.Taints, taint)
+		}
+	}
+}
+
+// FindNodeGroup 按名称查找节点组（不存在时返回 nil）
+func FindNodeGroup(cfg *ClusterConfig, name string) *NodeGroupConfig {
+	if name == "" {
+		return nil
+	}
+	for i := range cfg.Spec.NodeGroups {
+		if cfg.Spec.NodeGroups[i].Name == name {
+			return &cfg.Spec.NodeGroups[i]
+		}
+	}
+	return nil
+}
+
+// String 返回 kubectl taint 使用的格式 key[=value]:effect
+func (t TaintConfig) String() string {
+	if t.Value == "" {
+		return t.Key + ":" + t.Effect
+	}
+	return fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect)
+}
+
+// SortedKeys 返回 map 的有序 key（用于稳定输出）
+func SortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	// 标签/注解 key: [prefix/]name，prefix 为 DNS 子域名，name 最长 63 个字符
+	metadataNameRegex   = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`)
+	metadataPrefixRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	labelValueRegex     = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+)
+
+// validateMetadataKey 验证标签、注解和污点的 key
+func validateMetadataKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) > 253 || !metadataPrefixRegex.MatchString(prefix) {
+			return fmt.Errorf("key %q 的前缀不是合法的 DNS 子域名", key)
+		}
+	}
+	if len(name) > 63 || !metadataNameRegex.MatchString(name) {
+		return fmt.Errorf("key %q 格式不正确（最长 63 个字符，只能包含字母、数字、'-'、'_'、'.'）", key)
+	}
+	return nil
+}
+
+// validateLabelValue 验证标签值和污点值
+func validateLabelValue(key, value string) error {
+	if len(value) > 63 || !labelValueRegex.MatchString(value) {
+		return fmt.Errorf("%s 的值 %q 格式不正确（最长 63 个字符，只能包含字母、数字、'-'、'_'、'.'）", key, value)
+	}
+	return nil
+}
+
+// validateNodeMetadata 验证一组标签、污点和注解，field 用于错误提示
+func validateNodeMetadata(field string, labels map[string]string, taints []TaintConfig, annotations map[string]string) error {
+	for _, k := range SortedKeys(labels) {
+		if err := validateMetadataKey(k); err != nil {
+			return fmt.Errorf("%s.labels: %w", field, err)
+		}
+		if err := validateLabelValue(k, labels[k]); err != nil {
+			return fmt.Errorf("%s.labels: %w", field, err)
+		}
+	}
+	for _, k := range SortedKeys(annotations) {
+		if err := validateMetadataKey(k); err != nil {
+			return fmt.Errorf("%s.annotations: %w", field, err)
+		}
+	}
+	for i, taint := range taints {
+		if err := validateMetadataKey(taint.Key); err != nil {
+			return fmt.Errorf("%s.taints[%d]: %w", field, i, err)
+		}
+		if err := validateLabelValue(taint.Key, taint.Value); err != nil {
+			return fmt.Errorf("%s.taints[%d]: %w", field, i, err)
+		}
+		switch taint.Effect {
+		case "NoSchedule", "PreferNoSchedule", "NoExecute":
+		default:
+			return fmt.Errorf("%s.taints[%d]: effect 只能是 NoSchedule、PreferNoSchedule 或 NoExecute，当前为 %q", field, i, taint.Effect)
+		}
+	}
+	return nil
+}
+
+// validateNodeGroups 验证节点组定义和节点对节点组的引用
+func validateNodeGroups(cfg *ClusterConfig) error {
+	names := make(map[string]bool)
+	for i, group := range cfg.Spec.NodeGroups {
+		if group.Name == "" {
+			return fmt.Errorf("spec.nodeGroups[%d].name 不能为空", i)
+		}
+		if names[group.Name] {
+			return fmt.Errorf("节点组名称重复: %s", group.Name)
+		}
+		names[group.Name] = true
+
+		field := fmt.Sprintf("spec.nodeGroups[%s]", group.Name)
+		if err := validateNodeMetadata(field, group.Labels, group.Taints, group.Annotations); err != nil {
+			return err
+		}
+	}
+
+	for i, node := range cfg.Spec.Nodes {
+		if node.Group != "" && !names[node.Group] {
+			return fmt.Errorf("节点 %d 引用的节点组不存在: %s", i, node.Group)
+		}
+		field := fmt.Sprintf("spec.nodes[%d]", i)
+		if err := validateNodeMetadata(field, node.Labels, node.Taints, node.Annotations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
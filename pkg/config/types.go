@@ -1,5 +1,7 @@
 package config
 
+import "net"
+
 // ClusterConfig 集群配置
 type ClusterConfig struct {
 	APIVersion string          `yaml:"apiVersion"`
@@ -25,19 +27,63 @@ type ClusterSpec struct {
 	BGP             BGPConfig           `yaml:"bgp"`              // BGP 配置
 	GatewayAPI      GatewayAPIConfig    `yaml:"gatewayAPI"`       // Gateway API 配置
 	Envoy           EnvoyConfig         `yaml:"envoy"`            // Envoy L7 代理配置
+	Bastion         *BastionConfig      `yaml:"bastion,omitempty"` // 跳板机（可选，所有节点通过跳板机连接）
+	Certificates    CertificatesConfig  `yaml:"certificates"`     // 证书有效期配置
+	NodeGroups      []NodeGroupConfig   `yaml:"nodeGroups,omitempty"` // 节点组模板（节点通过 group 引用）
 	Nodes           []NodeConfig        `yaml:"nodes"`            // 节点配置
 }
 
 // NetworkConfig 网络配置
 type NetworkConfig struct {
-	PodSubnet     string `yaml:"podSubnet"`     // Pod 网段
-	ServiceSubnet string `yaml:"serviceSubnet"` // Service 网段
+	PodSubnet     string `yaml:"podSubnet"`     // Pod 网段，双栈时用逗号分隔 IPv4 和 IPv6 网段
+	ServiceSubnet string `yaml:"serviceSubnet"` // Service 网段，双栈时用逗号分隔 IPv4 和 IPv6 网段
 }
 
+// 控制平面高可用模式
+const (
+	HAModeVIP      = "vip"      // 在 Master 节点上提供 VIP（默认）
+	HAModeExternal = "external" // 使用已有的外部负载均衡器（如 F5），不部署 VIP
+)
+
+// HA VIP 提供者
+const (
+	HAProviderKeepalived = "keepalived" // Keepalived + HAProxy（默认）
+	HAProviderKubeVIP    = "kube-vip"   // kube-vip 静态 Pod（不需要安装系统软件包）
+)
+
 // HAConfig 高可用配置
 type HAConfig struct {
-	Enabled bool   `yaml:"enabled"` // 是否启用高可用
-	VIP     string `yaml:"vip"`     // 虚拟 IP
+	Enabled  bool   `yaml:"enabled"`            // 是否启用高可用
+	Mode     string `yaml:"mode,omitempty"`     // 高可用模式: vip / external（默认 vip）
+	VIP      string `yaml:"vip,omitempty"`      // 虚拟 IP（mode: vip，与 Master 节点 IP 的地址族一致）
+	Provider string `yaml:"provider,omitempty"` // VIP 提供者: keepalived / kube-vip（mode: vip，默认 keepalived）
+	Endpoint string `yaml:"endpoint,omitempty"` // 外部负载均衡器地址 host:port（mode: external，转发到各 Master 的 6443 端口）
+}
+
+// External 是否使用外部负载均衡器
+func (h *HAConfig) External() bool {
+	return h.Enabled && h.Mode == HAModeExternal
+}
+
+// UsesKubeVIP 是否由 kube-vip 提供 VIP（启用 BGP 时使用 BGP 模式，否则使用 ARP 模式）
+func (h *HAConfig) UsesKubeVIP() bool {
+	return h.Enabled && !h.External() && h.Provider == HAProviderKubeVIP
+}
+
+// UsesHAProxy 是否需要在 Master 节点上部署 Keepalived + HAProxy
+func (h *HAConfig) UsesHAProxy() bool {
+	return h.Enabled && !h.External() && !h.UsesKubeVIP()
+}
+
+// ControlPlaneEndpoint 返回控制平面地址（host:port）：外部负载均衡器、VIP，未启用高可用时使用 masterIP
+func (h *HAConfig) ControlPlaneEndpoint(masterIP string) string {
+	switch {
+	case h.External():
+		return h.Endpoint
+	case h.Enabled:
+		return net.JoinHostPort(h.VIP, "6443")
+	}
+	return net.JoinHostPort(masterIP, "6443")
 }
 
 // HarborConfig Harbor 认证配置
@@ -45,6 +91,7 @@ type HarborConfig struct {
 	Username string `yaml:"username"` // Harbor 用户名（可选）
 	Password string `yaml:"password"` // Harbor 密码（可选）
 	Insecure bool   `yaml:"insecure"` // 是否跳过 TLS 验证（默认 false）
+	CAFile   string `yaml:"caFile"`   // Harbor CA 证书（本地路径，可选，配置后使用 HTTPS 访问）
 }
 
 // BGPConfig BGP 配置
@@ -52,7 +99,7 @@ type BGPConfig struct {
 	Enabled         bool            `yaml:"enabled"`         // 是否启用 BGP
 	LocalASN        int             `yaml:"localASN"`        // 本地 AS 号
 	Peers           []BGPPeerConfig `yaml:"peers"`           // BGP 对等体列表
-	LoadBalancerIPs []string        `yaml:"loadBalancerIPs"` // LoadBalancer IP 池
+	LoadBalancerIPs []string        `yaml:"loadBalancerIPs"` // LoadBalancer IP 池（支持 IPv4 和 IPv6）
 }
 
 // BGPPeerConfig BGP 对等体配置
@@ -95,13 +142,46 @@ type EnvoyConfig struct {
 	Enabled bool `yaml:"enabled"` // 是否启用 Envoy (Gateway API 需要)
 }
 
+// DefaultCertificateValidity 证书默认有效期（100 年）
+const DefaultCertificateValidity = "876000h"
+
+// CertificatesConfig 证书有效期配置（Go duration 格式，如 8760h）
+type CertificatesConfig struct {
+	CAValidity string `yaml:"caValidity"` // CA 证书有效期（只在创建集群时生效，默认 876000h）
+	Validity   string `yaml:"validity"`   // 其他证书有效期（创建集群和 certs renew 时生效，默认 876000h）
+}
+
 // NodeConfig 节点配置
 type NodeConfig struct {
 	Role     string    `yaml:"role"`     // 角色: master / worker
-	IP       string    `yaml:"ip"`       // IP 地址
+	IP       string    `yaml:"ip"`       // IP 地址（IPv4 或 IPv6）
 	Hostname string    `yaml:"hostname"` // 主机名（可选，自动生成）
 	GPU      bool      `yaml:"gpu"`      // 是否为 GPU 节点
 	SSH      SSHConfig `yaml:"ssh"`      // SSH 配置
+
+	// SecondaryIP 双栈集群中节点另一地址族的地址（可选），kubelet 使用 --node-ip=<ip>,<secondaryIP>
+	SecondaryIP string `yaml:"secondaryIP,omitempty"`
+
+	// 节点元数据，与引用的节点组合并（同名 key 以节点为准）
+	Group       string            `yaml:"group,omitempty"`       // 节点组名称（spec.nodeGroups）
+	Labels      map[string]string `yaml:"labels,omitempty"`      // 节点标签
+	Taints      []TaintConfig     `yaml:"taints,omitempty"`      // 节点污点
+	Annotations map[string]string `yaml:"annotations,omitempty"` // 节点注解
+}
+
+// NodeGroupConfig 节点组模板，定义一组节点共用的标签、污点和注解
+type NodeGroupConfig struct {
+	Name        string            `yaml:"name"`                  // 节点组名称
+	Labels      map[string]string `yaml:"labels,omitempty"`      // 节点标签
+	Taints      []TaintConfig     `yaml:"taints,omitempty"`      // 节点污点
+	Annotations map[string]string `yaml:"annotations,omitempty"` // 节点注解
+}
+
+// TaintConfig 节点污点
+type TaintConfig struct {
+	Key    string `yaml:"key"`             // 污点 key
+	Value  string `yaml:"value,omitempty"` // 污点值（可选）
+	Effect string `yaml:"effect"`          // NoSchedule / PreferNoSchedule / NoExecute
 }
 
 // SSHConfig SSH 连接配置
@@ -110,6 +190,22 @@ type SSHConfig struct {
 	Port     int    `yaml:"port"`     // SSH 端口
 	KeyFile  string `yaml:"keyFile"`  // SSH 私钥文件路径（可选）
 	Password string `yaml:"password"` // SSH 密码（可选，不推荐）
+
+	// HostKeyCheck 主机密钥校验模式: strict / tofu / insecure（默认 tofu）
+	// known_hosts 保存在 ~/.k8s-deployer/clusters/<name>/known_hosts
+	HostKeyCheck string `yaml:"hostKeyCheck,omitempty"`
+
+	// Bastion 跳板机（可选，覆盖集群级别的 spec.bastion）
+	Bastion *BastionConfig `yaml:"bastion,omitempty"`
+}
+
+// BastionConfig 跳板机配置，节点连接通过跳板机转发
+type BastionConfig struct {
+	Host     string `yaml:"host"`     // 跳板机地址
+	Port     int    `yaml:"port"`     // SSH 端口（默认 22）
+	User     string `yaml:"user"`     // SSH 用户名
+	KeyFile  string `yaml:"keyFile"`  // SSH 私钥文件路径（可选）
+	Password string `yaml:"password"` // SSH 密码（可选）
 }
 
 // DefaultConfig 返回默认配置
@@ -127,6 +223,10 @@ func DefaultConfig() *ClusterConfig {
 			HA: HAConfig{
 				Enabled: false,
 			},
+			Certificates: CertificatesConfig{
+				CAValidity: DefaultCertificateValidity,
+				Validity:   DefaultCertificateValidity,
+			},
 		},
 	}
 }
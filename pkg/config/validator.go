@@ -1,11 +1,14 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"net"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // ValidateConfig 验证集群配置
@@ -25,11 +28,21 @@ func ValidateConfig(cfg *ClusterConfig) error {
 		return err
 	}
 
+	// 验证节点组和节点元数据
+	if err := validateNodeGroups(cfg); err != nil {
+		return err
+	}
+
 	// 验证 BGP 配置
 	if err := validateBGP(&cfg.Spec.BGP); err != nil {
 		return err
 	}
 
+	// 验证地址族和地址不与集群网段重叠
+	if err := validateAddresses(cfg); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -82,16 +95,58 @@ func validateSpec(cfg *ClusterConfig) error {
 		return err
 	}
 
+	// 验证跳板机配置
+	if err := validateBastion(cfg.Spec.Bastion, "spec.bastion"); err != nil {
+		return err
+	}
+
+	// 验证 Harbor 配置
+	if err := validateHarbor(&cfg.Spec.Harbor); err != nil {
+		return err
+	}
+
+	// 验证证书有效期
+	if err := validateCertificates(&cfg.Spec.Certificates); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// validateNetworking 验证网络配置
+// validateCertificates 验证证书有效期（未配置时使用默认值）
+func validateCertificates(certs *CertificatesConfig) error {
+	if certs.CAValidity == "" {
+		certs.CAValidity = DefaultCertificateValidity
+	}
+	if certs.Validity == "" {
+		certs.Validity = DefaultCertificateValidity
+	}
+
+	caValidity, err := time.ParseDuration(certs.CAValidity)
+	if err != nil {
+		return fmt.Errorf("spec.certificates.caValidity 格式不正确（如 87600h）: %w", err)
+	}
+	validity, err := time.ParseDuration(certs.Validity)
+	if err != nil {
+		return fmt.Errorf("spec.certificates.validity 格式不正确（如 8760h）: %w", err)
+	}
+	if validity < 24*time.Hour {
+		return fmt.Errorf("spec.certificates.validity 不能小于 24h")
+	}
+	if validity > caValidity {
+		return fmt.Errorf("spec.certificates.validity (%s) 不能超过 caValidity (%s)", certs.Validity, certs.CAValidity)
+	}
+	return nil
+}
+
+// validateNetworking 验证网络配置（单栈或双栈）
 func validateNetworking(net *NetworkConfig) error {
 	// 验证 Pod 网段
 	if net.PodSubnet == "" {
 		return fmt.Errorf("spec.networking.podSubnet 不能为空")
 	}
-	if _, _, err := parseAndValidateCIDR(net.PodSubnet); err != nil {
+	podNets, err := parseSubnets(net.PodSubnet)
+	if err != nil {
 		return fmt.Errorf("Pod 网段格式不正确: %w", err)
 	}
 
@@ -99,20 +154,72 @@ func validateNetworking(net *NetworkConfig) error {
 	if net.ServiceSubnet == "" {
 		return fmt.Errorf("spec.networking.serviceSubnet 不能为空")
 	}
-	if _, _, err := parseAndValidateCIDR(net.ServiceSubnet); err != nil {
+	svcNets, err := parseSubnets(net.ServiceSubnet)
+	if err != nil {
 		return fmt.Errorf("Service 网段格式不正确: %w", err)
 	}
 
-	// 验证网段不重叠
-	podIP, podNet, _ := parseAndValidateCIDR(net.PodSubnet)
-	svcIP, svcNet, _ := parseAndValidateCIDR(net.ServiceSubnet)
-	if podNet.Contains(svcIP) || svcNet.Contains(podIP) {
-		return fmt.Errorf("Pod 网段和 Service 网段不能重叠")
+	// Pod 和 Service 网段需要使用相同的地址族
+	podIPv4, podIPv6 := CIDRsByFamily(net.PodSubnet)
+	svcIPv4, svcIPv6 := CIDRsByFamily(net.ServiceSubnet)
+	if (podIPv4 == "") != (svcIPv4 == "") || (podIPv6 == "") != (svcIPv6 == "") {
+		return fmt.Errorf("Pod 网段 (%s) 和 Service 网段 (%s) 的地址族不一致", net.PodSubnet, net.ServiceSubnet)
+	}
+
+	// 每个节点分配 /64 的 IPv6 Pod 网段（kube-controller-manager 默认值），集群网段最多比节点网段短 16 位
+	if podIPv6 != "" {
+		if err := validateIPv6PodSubnet(podIPv6); err != nil {
+			return err
+		}
+	}
+
+	// 验证网段不重叠（比较所有地址族的网段）
+	for _, podNet := range podNets {
+		for _, svcNet := range svcNets {
+			if cidrsOverlap(podNet, svcNet) {
+				return fmt.Errorf("Pod 网段 %s 和 Service 网段 %s 不能重叠", podNet, svcNet)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseSubnets 解析逗号分隔的网段，双栈时需要一个 IPv4 和一个 IPv6 网段
+func parseSubnets(cidrs string) ([]*net.IPNet, error) {
+	parts := SplitCIDRs(cidrs)
+	if len(parts) > 2 {
+		return nil, fmt.Errorf("最多配置两个网段（IPv4 和 IPv6）: %s", cidrs)
+	}
+
+	var nets []*net.IPNet
+	for _, cidr := range parts {
+		_, ipNet, err := parseAndValidateCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	if len(parts) == 2 && IsIPv6(parts[0]) == IsIPv6(parts[1]) {
+		return nil, fmt.Errorf("双栈需要一个 IPv4 网段和一个 IPv6 网段: %s", cidrs)
 	}
+	return nets, nil
+}
 
+// validateIPv6PodSubnet 验证 IPv6 Pod 网段的前缀长度
+func validateIPv6PodSubnet(cidr string) error {
+	_, ipNet, _ := net.ParseCIDR(cidr)
+	if ones, _ := ipNet.Mask.Size(); ones < 48 || ones >= 64 {
+		return fmt.Errorf("IPv6 Pod 网段 %s 的前缀长度需要在 /48 到 /63 之间（每个节点分配 /64）", cidr)
+	}
 	return nil
 }
 
+// cidrsOverlap 判断两个网段是否重叠（不同地址族的网段不会重叠）
+func cidrsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
 // validateHA 验证高可用配置
 func validateHA(cfg *ClusterConfig) error {
 	if !cfg.Spec.HA.Enabled {
@@ -131,6 +238,17 @@ func validateHA(cfg *ClusterConfig) error {
 		return fmt.Errorf("高可用模式至少需要 3 个 Master 节点，当前只有 %d 个", masterCount)
 	}
 
+	// 验证高可用模式（未配置时使用 vip）
+	switch cfg.Spec.HA.Mode {
+	case "":
+		cfg.Spec.HA.Mode = HAModeVIP
+	case HAModeVIP:
+	case HAModeExternal:
+		return validateExternalEndpoint(cfg.Spec.HA.Endpoint)
+	default:
+		return fmt.Errorf("spec.ha.mode 不正确: %s（必须是 %s 或 %s）", cfg.Spec.HA.Mode, HAModeVIP, HAModeExternal)
+	}
+
 	// 验证 VIP
 	if cfg.Spec.HA.VIP == "" {
 		return fmt.Errorf("启用高可用模式时，spec.ha.vip 不能为空")
@@ -140,6 +258,42 @@ func validateHA(cfg *ClusterConfig) error {
 		return fmt.Errorf("VIP 地址格式不正确: %s", cfg.Spec.HA.VIP)
 	}
 
+	// 验证 VIP 提供者（未配置时使用 keepalived）
+	switch cfg.Spec.HA.Provider {
+	case "":
+		cfg.Spec.HA.Provider = HAProviderKeepalived
+	case HAProviderKeepalived, HAProviderKubeVIP:
+	default:
+		return fmt.Errorf("spec.ha.provider 不正确: %s（必须是 %s 或 %s）", cfg.Spec.HA.Provider, HAProviderKeepalived, HAProviderKubeVIP)
+	}
+
+	// Keepalived 在 Master 节点的网卡上绑定 VIP，地址族需要与 Master 节点 IP 一致
+	for _, node := range cfg.Spec.Nodes {
+		if node.Role == "master" && net.ParseIP(node.IP) != nil && IsIPv6(node.IP) != IsIPv6(cfg.Spec.HA.VIP) {
+			return fmt.Errorf("VIP %s 是 %s 地址，与 Master 节点 %s 的 IP %s 地址族不一致",
+				cfg.Spec.HA.VIP, ipFamily(cfg.Spec.HA.VIP), node.Hostname, node.IP)
+		}
+	}
+
+	return nil
+}
+
+// validateExternalEndpoint 验证外部负载均衡器地址（host:port，IPv6 地址需要加方括号）
+func validateExternalEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("spec.ha.mode 为 %s 时，spec.ha.endpoint 不能为空", HAModeExternal)
+	}
+
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return fmt.Errorf("spec.ha.endpoint 格式不正确（应为 host:port）: %s", endpoint)
+	}
+	if host == "" {
+		return fmt.Errorf("spec.ha.endpoint 缺少主机地址: %s", endpoint)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("spec.ha.endpoint 端口不正确: %s", port)
+	}
 	return nil
 }
 
@@ -154,33 +308,22 @@ func validateNodes(cfg *ClusterConfig) error {
 	ipMap := make(map[string]bool)
 	hostnameMap := make(map[string]bool)
 
-	for i, node := range cfg.Spec.Nodes {
-		// 验证角色
-		if node.Role != "master" && node.Role != "worker" {
-			return fmt.Errorf("节点 %d 的角色不正确，只能是 'master' 或 'worker'", i)
+	for i := range cfg.Spec.Nodes {
+		node := &cfg.Spec.Nodes[i]
+		if err := validateNode(node, i); err != nil {
+			return err
 		}
 
 		if node.Role == "master" {
 			hasMaster = true
 		}
 
-		// 验证 IP
-		if node.IP == "" {
-			return fmt.Errorf("节点 %d 的 IP 地址不能为空", i)
-		}
-		if net.ParseIP(node.IP) == nil {
-			return fmt.Errorf("节点 %d 的 IP 地址格式不正确: %s", i, node.IP)
-		}
-
 		// 检查 IP 重复
-		if ipMap[node.IP] {
-			return fmt.Errorf("节点 IP 地址重复: %s", node.IP)
-		}
-		ipMap[node.IP] = true
-
-		// 验证主机名
-		if node.Hostname == "" {
-			return fmt.Errorf("节点 %d 的主机名不能为空", i)
+		for _, ip := range node.NodeIPs() {
+			if ipMap[ip] {
+				return fmt.Errorf("节点 IP 地址重复: %s", ip)
+			}
+			ipMap[ip] = true
 		}
 
 		// 检查主机名重复
@@ -188,31 +331,97 @@ func validateNodes(cfg *ClusterConfig) error {
 			return fmt.Errorf("节点主机名重复: %s", node.Hostname)
 		}
 		hostnameMap[node.Hostname] = true
+	}
 
-		// 验证主机名格式
-		hostnameRegex := regexp.MustCompile(`^[a-z0-9-]+$`)
-		if !hostnameRegex.MatchString(node.Hostname) {
-			return fmt.Errorf("节点 %d 的主机名格式不正确（只能包含小写字母、数字和连字符）: %s", i, node.Hostname)
-		}
+	if !hasMaster {
+		return fmt.Errorf("至少需要配置一个 Master 节点")
+	}
 
-		// 验证 SSH 配置
-		if err := validateSSH(&node.SSH, i); err != nil {
-			return err
-		}
+	return nil
+}
+
+// validateNode 验证单个节点的角色、地址、主机名和 SSH 配置（不检查与其他节点重复）
+func validateNode(node *NodeConfig, i int) error {
+	// 验证角色
+	if node.Role != "master" && node.Role != "worker" {
+		return fmt.Errorf("节点 %d 的角色不正确，只能是 'master' 或 'worker'", i)
+	}
 
-		// Master 节点不应该是 GPU 节点
-		if node.Role == "master" && node.GPU {
-			return fmt.Errorf("节点 %d: Master 节点不应该配置为 GPU 节点", i)
+	// 验证 IP
+	if node.IP == "" {
+		return fmt.Errorf("节点 %d 的 IP 地址不能为空", i)
+	}
+	if net.ParseIP(node.IP) == nil {
+		return fmt.Errorf("节点 %d 的 IP 地址格式不正确: %s", i, node.IP)
+	}
+
+	// 验证双栈的第二个地址
+	if node.SecondaryIP != "" {
+		if net.ParseIP(node.SecondaryIP) == nil {
+			return fmt.Errorf("节点 %d 的 secondaryIP 格式不正确: %s", i, node.SecondaryIP)
+		}
+		if IsIPv6(node.SecondaryIP) == IsIPv6(node.IP) {
+			return fmt.Errorf("节点 %d 的 secondaryIP (%s) 需要与 ip (%s) 属于不同的地址族", i, node.SecondaryIP, node.IP)
 		}
 	}
 
-	if !hasMaster {
-		return fmt.Errorf("至少需要配置一个 Master 节点")
+	// 验证主机名
+	if node.Hostname == "" {
+		return fmt.Errorf("节点 %d 的主机名不能为空", i)
+	}
+	if !hostnameRegex.MatchString(node.Hostname) {
+		return fmt.Errorf("节点 %d 的主机名格式不正确（只能包含小写字母、数字和连字符）: %s", i, node.Hostname)
+	}
+
+	// 验证 SSH 配置
+	if err := validateSSH(&node.SSH, i); err != nil {
+		return err
 	}
 
+	// Master 节点不应该是 GPU 节点
+	if node.Role == "master" && node.GPU {
+		return fmt.Errorf("节点 %d: Master 节点不应该配置为 GPU 节点", i)
+	}
 	return nil
 }
 
+// hostnameRegex 节点主机名格式
+var hostnameRegex = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// ValidateNewNode 验证要加入集群的新节点：与 ValidateConfig 对节点的检查相同，
+// 并检查 IP 和主机名不与已有节点重复（已有节点来自集群中保存的配置，不再重新验证）
+func ValidateNewNode(cfg *ClusterConfig, node *NodeConfig) error {
+	i := len(cfg.Spec.Nodes)
+	if err := validateNode(node, i); err != nil {
+		return err
+	}
+
+	for _, existing := range cfg.Spec.Nodes {
+		if existing.Hostname == node.Hostname {
+			return fmt.Errorf("节点主机名重复: %s", node.Hostname)
+		}
+		for _, ip := range existing.NodeIPs() {
+			for _, newIP := range node.NodeIPs() {
+				if ip == newIP {
+					return fmt.Errorf("IP %s 已被节点 %s 使用", newIP, existing.Hostname)
+				}
+			}
+		}
+	}
+
+	// Keepalived / kube-vip 在 Master 节点的网卡上绑定 VIP，地址族需要一致
+	if node.Role == "master" && cfg.Spec.HA.Enabled && !cfg.Spec.HA.External() && IsIPv6(node.IP) != IsIPv6(cfg.Spec.HA.VIP) {
+		return fmt.Errorf("VIP %s 是 %s 地址，与 Master 节点 %s 的 IP %s 地址族不一致",
+			cfg.Spec.HA.VIP, ipFamily(cfg.Spec.HA.VIP), node.Hostname, node.IP)
+	}
+
+	// 地址族已在集群网段中启用，且不与 Pod / Service 网段重叠
+	check := *cfg
+	check.Spec.Nodes = []NodeConfig{*node}
+	check.Spec.HA.Enabled, check.Spec.BGP.Enabled = false, false
+	return validateAddresses(&check)
+}
+
 // validateSSH 验证 SSH 配置
 func validateSSH(ssh *SSHConfig, nodeIndex int) error {
 	// 验证用户名
@@ -238,14 +447,55 @@ func validateSSH(ssh *SSHConfig, nodeIndex int) error {
 		}
 	}
 
+	// 验证主机密钥校验模式
+	switch ssh.HostKeyCheck {
+	case "", "strict", "tofu", "insecure":
+	default:
+		return fmt.Errorf("节点 %d 的 hostKeyCheck 不正确: %s（必须是 strict、tofu 或 insecure）", nodeIndex, ssh.HostKeyCheck)
+	}
+
+	// 验证节点级别的跳板机
+	if err := validateBastion(ssh.Bastion, fmt.Sprintf("节点 %d 的 ssh.bastion", nodeIndex)); err != nil {
+		return err
+	}
+
 	// 如果同时提供密钥和密码，给出警告（但不报错）
 	if ssh.KeyFile != "" && ssh.Password != "" {
-		fmt.Printf("警告: 节点 %d 同时配置了密钥和密码，将优先使用密钥认证\n", nodeIndex)
+		fmt.Fprintf(os.Stderr, "警告: 节点 %d 同时配置了密钥和密码，将优先使用密钥认证\n", nodeIndex)
 	}
 
 	return nil
 }
 
+// validateBastion 验证跳板机配置（未配置时跳过，端口默认 22）
+func validateBastion(bastion *BastionConfig, field string) error {
+	if bastion == nil {
+		return nil
+	}
+	if bastion.Host == "" {
+		return fmt.Errorf("%s.host 不能为空", field)
+	}
+	if bastion.User == "" {
+		return fmt.Errorf("%s.user 不能为空", field)
+	}
+	if bastion.Port == 0 {
+		bastion.Port = 22
+	}
+	if bastion.Port < 0 || bastion.Port > 65535 {
+		return fmt.Errorf("%s.port 不正确: %d", field, bastion.Port)
+	}
+	if bastion.KeyFile == "" && bastion.Password == "" {
+		return fmt.Errorf("%s 必须提供 SSH 密钥文件或密码", field)
+	}
+	if bastion.KeyFile != "" {
+		keyPath := expandPath(bastion.KeyFile)
+		if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+			return fmt.Errorf("%s 的 SSH 密钥文件不存在: %s", field, keyPath)
+		}
+	}
+	return nil
+}
+
 // parseAndValidateCIDR 解析并验证 CIDR
 func parseAndValidateCIDR(cidr string) (net.IP, *net.IPNet, error) {
 	ip, ipNet, err := net.ParseCIDR(cidr)
@@ -266,6 +516,23 @@ func expandPath(path string) string {
 	return path
 }
 
+// validateHarbor 验证 Harbor 配置
+func validateHarbor(harbor *HarborConfig) error {
+	if (harbor.Username == "") != (harbor.Password == "") {
+		return fmt.Errorf("spec.harbor.username 和 spec.harbor.password 需要同时配置")
+	}
+	if harbor.CAFile == "" {
+		return nil
+	}
+	if harbor.Insecure {
+		return fmt.Errorf("spec.harbor.caFile 和 spec.harbor.insecure 不能同时配置")
+	}
+	if _, err := os.Stat(expandPath(harbor.CAFile)); err != nil {
+		return fmt.Errorf("Harbor CA 证书不存在: %s", harbor.CAFile)
+	}
+	return nil
+}
+
 // validateBGP 验证 BGP 配置
 func validateBGP(bgp *BGPConfig) error {
 	if !bgp.Enabled {
@@ -320,42 +587,136 @@ func validateBGP(bgp *BGPConfig) error {
 	return nil
 }
 
-// validateIPRange 验证 IP 范围格式
+// validateIPRange 验证 IP 范围格式（IPv4 或 IPv6）
 func validateIPRange(ipRange string) error {
+	_, _, err := parseIPRange(ipRange)
+	return err
+}
+
+// parseIPRange 解析 IP 范围，返回起始和结束 IP
+func parseIPRange(ipRange string) (net.IP, net.IP, error) {
 	parts := strings.Split(ipRange, "-")
 	if len(parts) != 2 {
-		return fmt.Errorf("IP 范围格式应为: 起始IP-结束IP，例如: 10.0.4.150-10.0.4.199")
+		return nil, nil, fmt.Errorf("IP 范围格式应为: 起始IP-结束IP，例如: 10.0.4.150-10.0.4.199")
 	}
 
 	startIP := net.ParseIP(strings.TrimSpace(parts[0]))
 	endIP := net.ParseIP(strings.TrimSpace(parts[1]))
 
 	if startIP == nil {
-		return fmt.Errorf("起始 IP 格式不正确: %s", parts[0])
+		return nil, nil, fmt.Errorf("起始 IP 格式不正确: %s", parts[0])
 	}
 	if endIP == nil {
-		return fmt.Errorf("结束 IP 格式不正确: %s", parts[1])
+		return nil, nil, fmt.Errorf("结束 IP 格式不正确: %s", parts[1])
 	}
 
-	// 转换为 IPv4
-	startIPv4 := startIP.To4()
-	endIPv4 := endIP.To4()
-	if startIPv4 == nil || endIPv4 == nil {
-		return fmt.Errorf("目前仅支持 IPv4 地址范围")
+	if (startIP.To4() == nil) != (endIP.To4() == nil) {
+		return nil, nil, fmt.Errorf("起始 IP (%s) 和结束 IP (%s) 的地址族不一致", parts[0], parts[1])
 	}
 
 	// 验证起始 IP <= 结束 IP
-	if ipToInt(startIPv4) > ipToInt(endIPv4) {
-		return fmt.Errorf("起始 IP (%s) 必须小于或等于结束 IP (%s)", parts[0], parts[1])
+	if compareIP(startIP, endIP) > 0 {
+		return nil, nil, fmt.Errorf("起始 IP (%s) 必须小于或等于结束 IP (%s)", parts[0], parts[1])
 	}
 
-	return nil
+	return startIP, endIP, nil
+}
+
+// compareIP 比较两个同一地址族的 IP
+func compareIP(a, b net.IP) int {
+	return bytes.Compare(a.To16(), b.To16())
+}
+
+// addressRange 返回单个 IP、CIDR 或 IP 范围的起始和结束 IP
+func addressRange(entry string) (net.IP, net.IP, error) {
+	if strings.Contains(entry, "-") {
+		return parseIPRange(entry)
+	}
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		last := make(net.IP, len(ipNet.IP))
+		for i := range ipNet.IP {
+			last[i] = ipNet.IP[i] | ^ipNet.Mask[i]
+		}
+		return ipNet.IP, last, nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, nil, fmt.Errorf("IP 格式不正确: %s", entry)
+	}
+	return ip, ip, nil
+}
+
+// rangeOverlapsCIDR 判断 IP 范围是否与网段重叠
+func rangeOverlapsCIDR(start, end net.IP, ipNet *net.IPNet) bool {
+	if (start.To4() == nil) != (ipNet.IP.To4() == nil) {
+		return false
+	}
+	netStart, netEnd, _ := addressRange(ipNet.String())
+	return compareIP(start, netEnd) <= 0 && compareIP(netStart, end) <= 0
 }
 
-// ipToInt 将 IP 转换为整数（用于比较）
-func ipToInt(ip net.IP) uint32 {
-	ip = ip.To4()
-	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+// validateAddresses 验证节点 IP、VIP 和 LoadBalancer IP 的地址族已在集群网段中启用，且不与 Pod / Service 网段重叠
+func validateAddresses(cfg *ClusterConfig) error {
+	networking := &cfg.Spec.Networking
+	podNets, _ := parseSubnets(networking.PodSubnet)
+	svcNets, _ := parseSubnets(networking.ServiceSubnet)
+	clusterNets := append(podNets, svcNets...)
+
+	familyEnabled := func(addr string) bool {
+		if IsIPv6(addr) {
+			return networking.IPv6Enabled()
+		}
+		return networking.IPv4Enabled()
+	}
+
+	// checkAddress 检查地址（单个 IP、CIDR 或 IP 范围）
+	checkAddress := func(field, addr string) error {
+		start, end, err := addressRange(addr)
+		if err != nil {
+			return fmt.Errorf("%s 格式不正确: %w", field, err)
+		}
+		if !familyEnabled(start.String()) {
+			return fmt.Errorf("%s (%s) 是 %s 地址，但 Pod 网段 (%s) 未配置 %s 网段",
+				field, addr, ipFamily(start.String()), networking.PodSubnet, ipFamily(start.String()))
+		}
+		for _, ipNet := range clusterNets {
+			if rangeOverlapsCIDR(start, end, ipNet) {
+				return fmt.Errorf("%s (%s) 与集群网段 %s 重叠", field, addr, ipNet)
+			}
+		}
+		return nil
+	}
+
+	for _, node := range cfg.Spec.Nodes {
+		if node.SecondaryIP != "" && !networking.DualStack() {
+			return fmt.Errorf("节点 %s 配置了 secondaryIP，但集群网段不是双栈（podSubnet: %s）", node.Hostname, networking.PodSubnet)
+		}
+		for _, ip := range node.NodeIPs() {
+			if err := checkAddress(fmt.Sprintf("节点 %s 的 IP", node.Hostname), ip); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.Spec.HA.Enabled && !cfg.Spec.HA.External() {
+		if err := checkAddress("VIP", cfg.Spec.HA.VIP); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Spec.BGP.Enabled {
+		for i, entry := range cfg.Spec.BGP.LoadBalancerIPs {
+			if err := checkAddress(fmt.Sprintf("LoadBalancer IP %d", i), entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 // ValidateImmutableFields 验证不可变字段（用于更新时检查）
@@ -388,12 +749,70 @@ func ValidateImmutableFields(oldCfg, newCfg *ClusterConfig) error {
 	// 4. Kubernetes 版本不可直接修改（需要专门的升级流程）
 	if oldCfg.Spec.Version != newCfg.Spec.Version {
 		errors = append(errors, fmt.Sprintf(
-			"Kubernetes 版本不可通过 update 命令修改，请使用 upgrade 命令 (当前: %s, 尝试修改为: %s)",
+			"Kubernetes 版本不可通过 update 命令修改，请使用 cluster upgrade 命令 (当前: %s, 尝试修改为: %s)",
 			oldCfg.Spec.Version,
 			newCfg.Spec.Version,
 		))
 	}
 
+	// 5. 高可用模式、外部负载均衡器地址和 VIP 提供者不可变（旧版本保存的配置中没有这些字段，按 vip + keepalived 处理）
+	if oldCfg.Spec.HA.Enabled && newCfg.Spec.HA.Enabled {
+		oldMode, newMode := oldCfg.Spec.HA.Mode, newCfg.Spec.HA.Mode
+		if oldMode == "" {
+			oldMode = HAModeVIP
+		}
+		if newMode == "" {
+			newMode = HAModeVIP
+		}
+		if oldMode != newMode {
+			errors = append(errors, fmt.Sprintf(
+				"高可用模式不可修改 (当前: %s, 尝试修改为: %s)",
+				oldMode, newMode,
+			))
+		}
+		if oldMode == HAModeExternal && newMode == HAModeExternal && oldCfg.Spec.HA.Endpoint != newCfg.Spec.HA.Endpoint {
+			errors = append(errors, fmt.Sprintf(
+				"外部负载均衡器地址不可修改 (当前: %s, 尝试修改为: %s)",
+				oldCfg.Spec.HA.Endpoint, newCfg.Spec.HA.Endpoint,
+			))
+		}
+
+		oldProvider, newProvider := oldCfg.Spec.HA.Provider, newCfg.Spec.HA.Provider
+		if oldProvider == "" {
+			oldProvider = HAProviderKeepalived
+		}
+		if newProvider == "" {
+			newProvider = HAProviderKeepalived
+		}
+		if oldProvider != newProvider {
+			errors = append(errors, fmt.Sprintf(
+				"高可用 VIP 提供者不可修改 (当前: %s, 尝试修改为: %s)",
+				oldProvider, newProvider,
+			))
+		}
+	}
+
+	// 6. 已有节点的 IP 和角色不可变（需要先删除节点再以新配置添加）
+	for _, oldNode := range oldCfg.Spec.Nodes {
+		for _, newNode := range newCfg.Spec.Nodes {
+			if newNode.Hostname != oldNode.Hostname {
+				continue
+			}
+			if newNode.IP != oldNode.IP || newNode.SecondaryIP != oldNode.SecondaryIP {
+				errors = append(errors, fmt.Sprintf(
+					"节点 %s 的 IP 不可修改 (当前: %s, 尝试修改为: %s)",
+					oldNode.Hostname, strings.Join(oldNode.NodeIPs(), ","), strings.Join(newNode.NodeIPs(), ","),
+				))
+			}
+			if newNode.Role != oldNode.Role {
+				errors = append(errors, fmt.Sprintf(
+					"节点 %s 的角色不可修改 (当前: %s, 尝试修改为: %s)",
+					oldNode.Hostname, oldNode.Role, newNode.Role,
+				))
+			}
+		}
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("检测到不可变配置被修改:\n  - %s",
 			strings.Join(errors, "\n  - "))
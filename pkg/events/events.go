@@ -0,0 +1,182 @@
+package events
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	PhaseStarted  Type = "phase.started"  // 阶段开始
+	PhaseFinished Type = "phase.finished" // 阶段结束（status: succeeded/failed/skipped）
+	Header        Type = "header"         // 标题
+	Step          Type = "step"           // 步骤 [current/total]
+	SubStep       Type = "substep"        // 子步骤（status: started/succeeded/failed）
+	NodeStep      Type = "node.step"      // 节点上的步骤（并发操作时的节点日志）
+	CommandResult Type = "command.result" // 命令执行结果
+	Info          Type = "info"           // 信息
+	Success       Type = "success"        // 成功
+	Warning       Type = "warning"        // 警告
+	Error         Type = "error"          // 错误
+	Table         Type = "table"          // 表格（columns + rows）
+	Text          Type = "text"           // 原样输出的文本
+)
+
+// 事件状态
+const (
+	StatusStarted   = "started"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+)
+
+// Event 事件
+// Phase 为空时发布时会填充为当前正在执行的阶段
+type Event struct {
+	Time     time.Time  `json:"time"`
+	Type     Type       `json:"type"`
+	Phase    string     `json:"phase,omitempty"`
+	Node     string     `json:"node,omitempty"`
+	Step     string     `json:"step,omitempty"`
+	Status   string     `json:"status,omitempty"`
+	Message  string     `json:"message,omitempty"`
+	Command  string     `json:"command,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Duration float64    `json:"durationSeconds,omitempty"`
+	Current  int        `json:"current,omitempty"`
+	Total    int        `json:"total,omitempty"`
+	Columns  []string   `json:"columns,omitempty"`
+	Rows     [][]string `json:"rows,omitempty"`
+}
+
+// Sink 事件输出（控制台、JSON、日志文件等）
+// Handle 在发布事件的 goroutine 中按顺序调用，实现需要尽快返回
+type Sink interface {
+	Handle(e Event)
+}
+
+var (
+	mu           sync.Mutex
+	sinks        []Sink
+	currentPhase string
+)
+
+// SetSinks 替换所有 sink
+func SetSinks(s ...Sink) {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = append([]Sink(nil), s...)
+}
+
+// AddSink 添加一个 sink
+func AddSink(s Sink) {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = append(sinks, s)
+}
+
+// Publish 发布事件到所有 sink
+func Publish(e Event) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	switch e.Type {
+	case PhaseStarted:
+		currentPhase = e.Phase
+	case PhaseFinished:
+		currentPhase = ""
+	default:
+		if e.Phase == "" {
+			e.Phase = currentPhase
+		}
+	}
+
+	for _, s := range sinks {
+		s.Handle(e)
+	}
+}
+
+// Close 关闭实现了 io.Closer 的 sink（如 JSON 文件），并清空 sink 列表
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var errs []error
+	for _, s := range sinks {
+		if c, ok := s.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	sinks = nil
+	return errors.Join(errs...)
+}
+
+// StartPhase 发布阶段开始事件，返回开始时间（用于 FinishPhase 计算耗时）
+func StartPhase(name, title string) time.Time {
+	start := time.Now()
+	Publish(Event{Time: start, Type: PhaseStarted, Phase: name, Message: title, Status: StatusStarted})
+	return start
+}
+
+// FinishPhase 发布阶段结束事件，err 不为 nil 时状态为 failed，并带上错误中的节点和步骤
+func FinishPhase(name string, start time.Time, err error) {
+	e := Event{
+		Type:     PhaseFinished,
+		Phase:    name,
+		Status:   StatusSucceeded,
+		Duration: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		e.Status = StatusFailed
+		e.Error = err.Error()
+		e.Node, e.Step = ErrorLocation(err)
+	}
+	Publish(e)
+}
+
+// SkipPhase 发布阶段跳过事件
+func SkipPhase(name, reason string) {
+	Publish(Event{Type: PhaseFinished, Phase: name, Status: StatusSkipped, Message: reason})
+}
+
+// NodeError 带有节点和步骤信息的错误，错误信息与原错误相同
+type NodeError struct {
+	Node string
+	Step string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// WrapNode 为错误附加节点和步骤信息（err 为 nil 时返回 nil）
+func WrapNode(node, step string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &NodeError{Node: node, Step: step, Err: err}
+}
+
+// ErrorLocation 返回错误链中第一个 NodeError 的节点和步骤
+func ErrorLocation(err error) (node, step string) {
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) {
+		return nodeErr.Node, nodeErr.Step
+	}
+	return "", ""
+}
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONSink 以换行分隔的 JSON（NDJSON）输出事件，每行一个事件
+type JSONSink struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewJSONSink 创建输出到 w 的 JSON sink
+func NewJSONSink(w io.Writer) *JSONSink {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONSink{enc: enc}
+}
+
+// OpenJSONFile 创建输出到文件的 JSON sink（追加写入）
+func OpenJSONFile(path string) (*JSONSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	sink := NewJSONSink(file)
+	sink.closer = file
+	return sink, nil
+}
+
+// Handle 写入一行 JSON
+func (s *JSONSink) Handle(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.enc.Encode(e)
+}
+
+// Close 关闭文件（输出到 stdout 等时不做任何事）
+func (s *JSONSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
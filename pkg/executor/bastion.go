@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// BastionOptions 跳板机连接选项
+type BastionOptions struct {
+	Host     string
+	Port     int
+	User     string
+	KeyFile  string // 私钥文件（优先使用）
+	Password string // 密码
+}
+
+// key 跳板机连接的缓存键（相同跳板机和用户共享一个连接）
+func (b *BastionOptions) key() string {
+	return fmt.Sprintf("%s@%s:%d#%s", b.User, b.Host, b.Port, b.KeyFile)
+}
+
+func (b *BastionOptions) addr() string {
+	return net.JoinHostPort(b.Host, strconv.Itoa(b.Port))
+}
+
+var (
+	bastionMu      sync.Mutex
+	defaultBastion *BastionOptions            // 集群级别的跳板机
+	bastionClients = map[string]*ssh.Client{} // 共享的跳板机连接
+)
+
+// SetDefaultBastion 设置默认跳板机（节点未单独配置时使用，nil 表示直连）
+func SetDefaultBastion(bastion *BastionOptions) {
+	bastionMu.Lock()
+	defer bastionMu.Unlock()
+	defaultBastion = bastion
+}
+
+// resolveBastion 计算实际使用的跳板机
+func resolveBastion(bastion *BastionOptions) *BastionOptions {
+	if bastion != nil {
+		return bastion
+	}
+	bastionMu.Lock()
+	defer bastionMu.Unlock()
+	return defaultBastion
+}
+
+// dialSSH 建立 SSH 连接，配置了跳板机时通过跳板机转发
+func dialSSH(addr string, config *ssh.ClientConfig, bastion *BastionOptions) (*ssh.Client, error) {
+	if bastion == nil {
+		return ssh.Dial("tcp", addr, config)
+	}
+
+	jump, err := getBastionClient(bastion)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := jump.Dial("tcp", addr)
+	if err != nil {
+		// 目标节点不可达（宕机、地址错误、端口拒绝）时跳板机连接仍然可用，其他节点的会话共用该连接，不能关闭
+		if bastionAlive(jump) {
+			return nil, fmt.Errorf("通过跳板机 %s 连接 %s 失败: %w", bastion.addr(), addr, err)
+		}
+		// 跳板机连接已断开，重建后再试一次
+		dropBastionClient(bastion, jump)
+		if jump, err = getBastionClient(bastion); err != nil {
+			return nil, err
+		}
+		if conn, err = jump.Dial("tcp", addr); err != nil {
+			return nil, fmt.Errorf("通过跳板机 %s 连接 %s 失败: %w", bastion.addr(), addr, err)
+		}
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// getBastionClient 获取共享的跳板机连接（不存在时建立）
+// 建立连接期间持有锁，并发的节点连接会等待并复用同一个跳板机连接
+func getBastionClient(bastion *BastionOptions) (*ssh.Client, error) {
+	bastionMu.Lock()
+	defer bastionMu.Unlock()
+
+	if client, ok := bastionClients[bastion.key()]; ok {
+		return client, nil
+	}
+
+	auth, err := authMethods(bastion.KeyFile, bastion.Password)
+	if err != nil {
+		return nil, fmt.Errorf("跳板机 %s: %w", bastion.addr(), err)
+	}
+
+	mode, knownHosts := resolveHostKeyPolicy("", "")
+	hostKeyCallback, err := newHostKeyCallback(mode, knownHosts, "跳板机 "+bastion.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := ssh.Dial("tcp", bastion.addr(), &ssh.ClientConfig{
+		User:            bastion.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("连接跳板机 %s 失败: %w", bastion.addr(), err)
+	}
+
+	bastionClients[bastion.key()] = client
+	return client, nil
+}
+
+// bastionAlive 发送 keepalive 请求检查跳板机连接是否仍然可用
+func bastionAlive(client *ssh.Client) bool {
+	_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+	return err == nil
+}
+
+// dropBastionClient 移除失效的跳板机连接
+func dropBastionClient(bastion *BastionOptions, client *ssh.Client) {
+	bastionMu.Lock()
+	defer bastionMu.Unlock()
+
+	if bastionClients[bastion.key()] == client {
+		delete(bastionClients, bastion.key())
+		client.Close()
+	}
+}
+
+// CloseBastions 关闭所有共享的跳板机连接
+func CloseBastions() {
+	bastionMu.Lock()
+	defer bastionMu.Unlock()
+
+	for key, client := range bastionClients {
+		client.Close()
+		delete(bastionClients, key)
+	}
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// LocalNodeName 本地命令在命令日志和事件中使用的节点名称
+const LocalNodeName = "local"
+
+// 命令日志：每个节点一个文件，记录每条命令、退出码、耗时、标准输出和标准错误
+// 日志中可能包含 join token 等敏感信息，文件权限为 0600
+var (
+	cmdLogMu     sync.Mutex
+	cmdLogDir    string
+	cmdLogFiles  = make(map[string]*os.File)
+	cmdLogFailed string // 最后一条失败命令所在节点的日志文件
+)
+
+// SetCommandLogDir 设置命令日志目录（空字符串表示不记录），目录在第一次写入时创建
+func SetCommandLogDir(dir string) {
+	cmdLogMu.Lock()
+	defer cmdLogMu.Unlock()
+	cmdLogDir = dir
+}
+
+// CommandLogDir 返回命令日志目录
+func CommandLogDir() string {
+	cmdLogMu.Lock()
+	defer cmdLogMu.Unlock()
+	return cmdLogDir
+}
+
+// CommandLogPath 返回节点的命令日志文件路径
+func CommandLogPath(node string) string {
+	cmdLogMu.Lock()
+	defer cmdLogMu.Unlock()
+	return commandLogPath(node)
+}
+
+// FailedCommandLog 返回最后一条失败命令所在节点的日志文件（没有失败的命令时为空）
+func FailedCommandLog() string {
+	cmdLogMu.Lock()
+	defer cmdLogMu.Unlock()
+	return cmdLogFailed
+}
+
+// CloseCommandLogs 关闭所有命令日志文件
+func CloseCommandLogs() {
+	cmdLogMu.Lock()
+	defer cmdLogMu.Unlock()
+	for node, file := range cmdLogFiles {
+		file.Close()
+		delete(cmdLogFiles, node)
+	}
+}
+
+func commandLogPath(node string) string {
+	if cmdLogDir == "" {
+		return ""
+	}
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, node)
+	return filepath.Join(cmdLogDir, name+".log")
+}
+
+// logCommand 将命令的执行结果追加到节点的命令日志
+func logCommand(node, command string, start time.Time, stdout, stderr string, err error) {
+	cmdLogMu.Lock()
+	defer cmdLogMu.Unlock()
+
+	if cmdLogDir == "" {
+		return
+	}
+	file, ok := cmdLogFiles[node]
+	if !ok {
+		if mkErr := os.MkdirAll(cmdLogDir, 0700); mkErr != nil {
+			return
+		}
+		var openErr error
+		file, openErr = os.OpenFile(commandLogPath(node), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if openErr != nil {
+			return
+		}
+		cmdLogFiles[node] = file
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "==== %s  耗时 %.2fs  退出码 %s\n", start.Format("2006-01-02 15:04:05"), time.Since(start).Seconds(), exitCode(err))
+	fmt.Fprintf(&b, "$ %s\n", strings.TrimSpace(command))
+	if stdout != "" {
+		fmt.Fprintf(&b, "---- stdout\n%s\n", strings.TrimRight(stdout, "\n"))
+	}
+	if stderr != "" {
+		fmt.Fprintf(&b, "---- stderr\n%s\n", strings.TrimRight(stderr, "\n"))
+	}
+	if err != nil {
+		fmt.Fprintf(&b, "---- error\n%v\n", err)
+		cmdLogFailed = commandLogPath(node)
+	}
+	b.WriteString("\n")
+	file.WriteString(MaskSecrets(b.String()))
+}
+
+// exitCode 返回命令的退出码（被中断或未能执行时返回说明）
+func exitCode(err error) string {
+	if err == nil {
+		return "0"
+	}
+	if IsInterrupted(err) {
+		return "中断"
+	}
+	var sshErr *ssh.ExitError
+	if errors.As(err, &sshErr) {
+		return fmt.Sprint(sshErr.ExitStatus())
+	}
+	var execErr *exec.ExitError
+	if errors.As(err, &execErr) {
+		return fmt.Sprint(execErr.ExitCode())
+	}
+	return "-"
+}
@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/events"
+)
+
+// signalGracePeriod 取消命令时发送 SIGTERM 后等待退出的时间，超时后发送 SIGKILL
+const signalGracePeriod = 5 * time.Second
+
+var (
+	contextMu   sync.Mutex
+	baseCtx     context.Context // Execute 等不带 context 的方法使用的 context
+	interrupted []*InterruptedError
+)
+
+// InterruptedError 命令因 context 取消或超时被中断
+type InterruptedError struct {
+	Node    string // 节点名称（本地命令为空）
+	Command string // 被中断的命令（截断后）
+	Err     error  // context.Canceled 或 context.DeadlineExceeded
+}
+
+func (e *InterruptedError) Error() string {
+	reason := "已取消"
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		reason = "执行超时"
+	}
+	if e.Node != "" {
+		return fmt.Sprintf("节点 %s 上的命令%s: %s", e.Node, reason, e.Command)
+	}
+	return fmt.Sprintf("命令%s: %s", reason, e.Command)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// IsInterrupted 判断错误是否由命令被中断引起
+func IsInterrupted(err error) bool {
+	var interruptedErr *InterruptedError
+	return errors.As(err, &interruptedErr)
+}
+
+// SetContext 设置 Execute 等方法使用的 context（nil 表示不可取消）
+// 部署流程在收到 SIGINT 时取消该 context，所有正在执行的远程命令都会被中断
+func SetContext(ctx context.Context) {
+	contextMu.Lock()
+	defer contextMu.Unlock()
+	baseCtx = ctx
+	interrupted = nil
+}
+
+// currentContext 返回当前设置的 context
+func currentContext() context.Context {
+	contextMu.Lock()
+	defer contextMu.Unlock()
+	if baseCtx == nil {
+		return context.Background()
+	}
+	return baseCtx
+}
+
+// InterruptedCommands 返回自上次 SetContext 以来被中断的命令
+func InterruptedCommands() []*InterruptedError {
+	contextMu.Lock()
+	defer contextMu.Unlock()
+	return append([]*InterruptedError(nil), interrupted...)
+}
+
+// newInterruptedError 记录并返回被中断的命令
+func newInterruptedError(node, command string, err error) *InterruptedError {
+	e := &InterruptedError{Node: node, Command: shortCommand(command), Err: err}
+	contextMu.Lock()
+	interrupted = append(interrupted, e)
+	contextMu.Unlock()
+	return e
+}
+
+// shortCommand 截取命令的第一行用于提示
+func shortCommand(command string) string {
+	command = strings.TrimSpace(command)
+	if i := strings.IndexByte(command, '\n'); i >= 0 {
+		command = strings.TrimSpace(command[:i]) + " ..."
+	}
+	if len(command) > 80 {
+		command = command[:77] + "..."
+	}
+	return command
+}
+
+// publishCommandResult 发布命令执行结果事件（隐藏已注册的 secret）
+func publishCommandResult(node, command string, start time.Time, err error) {
+	e := events.Event{
+		Type:     events.CommandResult,
+		Node:     node,
+		Command:  shortCommand(MaskSecrets(command)),
+		Status:   events.StatusSucceeded,
+		Duration: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		e.Status = events.StatusFailed
+		e.Error = MaskSecrets(err.Error())
+	}
+	events.Publish(e)
+}
@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyMode SSH 主机密钥校验模式
+type HostKeyMode string
+
+const (
+	// HostKeyStrict 只接受 known_hosts 中已记录的主机密钥
+	HostKeyStrict HostKeyMode = "strict"
+	// HostKeyTOFU 首次连接时记录主机密钥，之后严格校验（默认）
+	HostKeyTOFU HostKeyMode = "tofu"
+	// HostKeyInsecure 不校验主机密钥（不推荐）
+	HostKeyInsecure HostKeyMode = "insecure"
+)
+
+// defaultKnownHostsFile 未指定集群时使用的 known_hosts 文件
+const defaultKnownHostsFile = "~/.k8s-deployer/known_hosts"
+
+var (
+	hostKeyMu        sync.Mutex
+	hostKeyOverride  HostKeyMode // 命令行指定的模式，优先于节点配置
+	knownHostsFile   string      // 当前使用的 known_hosts 文件
+	knownHostsWriteM sync.Mutex  // TOFU 写入 known_hosts 的锁
+)
+
+// HostKeyError 主机密钥校验失败（密钥不一致或未知主机），不应降级重试
+type HostKeyError struct {
+	msg string
+}
+
+func (e *HostKeyError) Error() string {
+	return e.msg
+}
+
+// isHostKeyError 判断错误是否由主机密钥校验失败引起
+func isHostKeyError(err error) bool {
+	var hostKeyErr *HostKeyError
+	return errors.As(err, &hostKeyErr)
+}
+
+// ParseHostKeyMode 解析主机密钥校验模式，空字符串返回空模式（使用默认值）
+func ParseHostKeyMode(mode string) (HostKeyMode, error) {
+	switch HostKeyMode(mode) {
+	case "", HostKeyStrict, HostKeyTOFU, HostKeyInsecure:
+		return HostKeyMode(mode), nil
+	default:
+		return "", fmt.Errorf("无效的主机密钥校验模式: %s（可选: strict, tofu, insecure）", mode)
+	}
+}
+
+// SetHostKeyModeOverride 设置全局主机密钥校验模式（覆盖节点配置，空字符串表示不覆盖）
+func SetHostKeyModeOverride(mode HostKeyMode) {
+	hostKeyMu.Lock()
+	defer hostKeyMu.Unlock()
+	hostKeyOverride = mode
+}
+
+// SetKnownHostsFile 设置 known_hosts 文件路径（通常为每个集群单独的文件）
+func SetKnownHostsFile(path string) {
+	hostKeyMu.Lock()
+	defer hostKeyMu.Unlock()
+	knownHostsFile = path
+}
+
+// resolveHostKeyPolicy 计算实际使用的校验模式和 known_hosts 文件
+func resolveHostKeyPolicy(mode HostKeyMode, file string) (HostKeyMode, string) {
+	hostKeyMu.Lock()
+	defer hostKeyMu.Unlock()
+
+	if hostKeyOverride != "" {
+		mode = hostKeyOverride
+	}
+	if mode == "" {
+		mode = HostKeyTOFU
+	}
+	if file == "" {
+		file = knownHostsFile
+	}
+	if file == "" {
+		file = defaultKnownHostsFile
+	}
+	return mode, expandPath(file)
+}
+
+// newHostKeyCallback 根据校验模式创建主机密钥回调
+// nodeName 用于错误提示（为空时使用地址）
+func newHostKeyCallback(mode HostKeyMode, file, nodeName string) (ssh.HostKeyCallback, error) {
+	if mode == HostKeyInsecure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	if err := ensureKnownHostsFile(file); err != nil {
+		return nil, err
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		name := nodeName
+		if name == "" {
+			name = hostname
+		}
+
+		err := checkKnownHost(file, hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return fmt.Errorf("校验节点 %s 的主机密钥失败: %w", name, err)
+		}
+
+		// 已记录的密钥与当前不一致
+		if len(keyErr.Want) > 0 {
+			want := keyErr.Want[0]
+			return &HostKeyError{msg: fmt.Sprintf("节点 %s (%s) 的 SSH 主机密钥与记录不一致！\n"+
+				"  当前密钥: %s %s\n"+
+				"  记录位置: %s:%d\n"+
+				"可能存在中间人攻击；如果确认节点已重装系统，请删除该行后重试",
+				name, hostname, key.Type(), ssh.FingerprintSHA256(key), want.Filename, want.Line)}
+		}
+
+		// 未知主机
+		if mode == HostKeyStrict {
+			return &HostKeyError{msg: fmt.Sprintf("节点 %s (%s) 的 SSH 主机密钥未记录在 %s 中（strict 模式）\n"+
+				"  当前密钥: %s %s\n"+
+				"请确认指纹后添加到 known_hosts，或使用 tofu 模式首次连接时自动记录",
+				name, hostname, file, key.Type(), ssh.FingerprintSHA256(key))}
+		}
+		return trustHostKey(file, hostname, remote, key)
+	}, nil
+}
+
+// checkKnownHost 使用 known_hosts 文件校验主机密钥（每次重新读取，保证 TOFU 新记录生效）
+func checkKnownHost(file, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %w", file, err)
+	}
+	return callback(hostname, remote, key)
+}
+
+// trustHostKey 首次连接时记录主机密钥（TOFU）
+func trustHostKey(file, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsWriteM.Lock()
+	defer knownHostsWriteM.Unlock()
+
+	// 并发连接同一主机时，可能已被其他连接记录
+	if err := checkKnownHost(file, hostname, remote, key); err == nil {
+		return nil
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", file, err)
+	}
+	defer f.Close()
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", file, err)
+	}
+	return nil
+}
+
+// ensureKnownHostsFile 确保 known_hosts 文件存在
+func ensureKnownHostsFile(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("创建 known_hosts 目录失败: %w", err)
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("创建 known_hosts 文件失败: %w", err)
+	}
+	return f.Close()
+}
@@ -1,37 +1,61 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // LocalExecutor 本地命令执行器
-type LocalExecutor struct{}
+type LocalExecutor struct {
+	recorder *RecordingExecutor // 记录模式（--dry-run）下不执行本地命令，只记录操作
+}
 
-// NewLocalExecutor 创建本地执行器
+// NewLocalExecutor 创建本地执行器，记录模式下返回的执行器只记录命令
 func NewLocalExecutor() *LocalExecutor {
+	if recorder := ActiveRecorder(); recorder != nil {
+		return &LocalExecutor{recorder: NewRecordingExecutor(recorder, PlanLocalNode)}
+	}
 	return &LocalExecutor{}
 }
 
 // Execute 在本地执行命令
 func (e *LocalExecutor) Execute(command string) (string, error) {
+	return e.ExecuteContext(currentContext(), command)
+}
+
+// ExecuteContext 在本地执行命令，ctx 取消或超时时结束进程并返回 InterruptedError
+func (e *LocalExecutor) ExecuteContext(ctx context.Context, command string) (string, error) {
+	if e.recorder != nil {
+		return e.recorder.ExecuteContext(ctx, command)
+	}
+
 	var cmd *exec.Cmd
 	
 	// 根据操作系统选择不同的 shell
 	if runtime.GOOS == "windows" {
 		// Windows 使用 PowerShell
-		cmd = exec.Command("powershell", "-Command", command)
+		cmd = exec.CommandContext(ctx, "powershell", "-Command", command)
 	} else {
 		// Unix/Linux 使用 sh
-		cmd = exec.Command("sh", "-c", command)
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
 	}
 
+	start := time.Now()
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		err = newInterruptedError("", command, ctx.Err())
+	} else if err != nil {
+		err = fmt.Errorf("命令执行失败: %w\n标准错误: %s", err, string(output))
+	}
+	logCommand(LocalNodeName, command, start, string(output), "", err)
+	publishCommandResult(LocalNodeName, command, start, err)
 	if err != nil {
-		return string(output), fmt.Errorf("命令执行失败: %w\n标准错误: %s", err, string(output))
+		return string(output), err
 	}
 
 	return strings.TrimSpace(string(output)), nil
@@ -39,12 +63,18 @@ func (e *LocalExecutor) Execute(command string) (string, error) {
 
 // ExecuteWithOutput 执行命令并实时输出
 func (e *LocalExecutor) ExecuteWithOutput(command string) error {
+	if e.recorder != nil {
+		_, err := e.recorder.Execute(command)
+		return err
+	}
+
 	var cmd *exec.Cmd
 	
+	ctx := currentContext()
 	if runtime.GOOS == "windows" {
-		cmd = exec.Command("powershell", "-Command", command)
+		cmd = exec.CommandContext(ctx, "powershell", "-Command", command)
 	} else {
-		cmd = exec.Command("sh", "-c", command)
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
 	}
 	
 	cmd.Stdout = os.Stdout
@@ -65,6 +95,7 @@ func (e *LocalExecutor) UploadFile(localPath, remotePath string) error {
 // CommandExecutor 统一的命令执行接口
 type CommandExecutor interface {
 	Execute(command string) (string, error)
+	ExecuteContext(ctx context.Context, command string) (string, error)
 	Close() error
 }
 
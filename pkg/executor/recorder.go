@@ -0,0 +1,321 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// 计划步骤类型
+const (
+	PlanCommand = "command" // 远程命令
+	PlanUpload  = "upload"  // 上传文件
+	PlanFile    = "file"    // 写入渲染后的文件（heredoc）
+	PlanLocal   = "local"   // 本地操作
+)
+
+// PlanLocalNode 本地操作使用的节点名称
+const PlanLocalNode = "local"
+
+// heredocPattern 匹配 "cat > <path> << 'EOF' ... EOF" 形式的文件写入命令
+var heredocPattern = regexp.MustCompile(`(?s)^\s*cat\s+>\s*(\S+)\s*<<\s*'?EOF'?\n(.*)\nEOF\s*$`)
+
+// PlanStep 执行计划中的一个操作
+type PlanStep struct {
+	Phase       string `json:"phase,omitempty"`
+	Node        string `json:"node"`
+	Type        string `json:"type"`
+	Command     string `json:"command,omitempty"`
+	Path        string `json:"path,omitempty"`        // 上传或写入的目标路径
+	Source      string `json:"source,omitempty"`      // 上传的本地文件
+	Size        int64  `json:"size,omitempty"`        // 上传文件大小
+	Content     string `json:"content,omitempty"`     // 渲染后的文件内容
+	Description string `json:"description,omitempty"` // 本地操作说明
+}
+
+// outputRule 模拟命令输出的规则
+type outputRule struct {
+	node   string // 只匹配该节点的命令，为空时匹配所有节点
+	match  string
+	prefix bool // 只匹配命令开头
+	output string
+	fail   bool
+}
+
+// Recorder 记录所有操作而不连接任何主机，用于 --dry-run
+// 由于不执行命令，需要解析输出的命令通过 AddOutput/AddFailure 返回模拟结果
+type Recorder struct {
+	mu      sync.Mutex
+	phase   string
+	steps   []PlanStep
+	rules   []outputRule
+	secrets []string
+}
+
+// NewRecorder 创建记录器
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// SetPhase 设置后续操作所属的阶段
+func (r *Recorder) SetPhase(phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = phase
+}
+
+// AddOutput 包含 match 的命令返回 output
+func (r *Recorder) AddOutput(match, output string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, outputRule{match: match, output: output})
+}
+
+// AddNodeOutput 节点 node 上包含 match 的命令返回 output（优先于之后添加的 AddOutput 规则）
+func (r *Recorder) AddNodeOutput(node, match, output string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, outputRule{node: node, match: match, output: output})
+}
+
+// AddFailure 以 prefix 开头的命令返回失败（如 test -f，模拟全新的节点）
+func (r *Recorder) AddFailure(prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, outputRule{match: prefix, prefix: true, fail: true})
+}
+
+// AddSecret 记录时将 secret 替换为 ******（如 SSH 密码）
+func (r *Recorder) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = append(r.secrets, secret)
+}
+
+// RecordLocal 记录本地操作（如更新本地 /etc/hosts、写入 kubeconfig）
+func (r *Recorder) RecordLocal(description, path, content string) {
+	r.add(PlanStep{Node: PlanLocalNode, Type: PlanLocal, Description: description, Path: path, Content: content})
+}
+
+// Steps 返回记录的所有操作
+func (r *Recorder) Steps() []PlanStep {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PlanStep(nil), r.steps...)
+}
+
+func (r *Recorder) add(step PlanStep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	step.Phase = r.phase
+	step.Command = r.mask(step.Command)
+	step.Content = r.mask(step.Content)
+	r.steps = append(r.steps, step)
+}
+
+func (r *Recorder) mask(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, "******")
+	}
+	return MaskSecrets(s)
+}
+
+// recordCommand 记录命令并返回模拟结果
+func (r *Recorder) recordCommand(node, command string) (string, error) {
+	if m := heredocPattern.FindStringSubmatch(command); m != nil {
+		r.add(PlanStep{Node: node, Type: PlanFile, Path: m[1], Content: m[2]})
+		return "", nil
+	}
+	r.add(PlanStep{Node: node, Type: PlanCommand, Command: strings.TrimSpace(command)})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trimmed := strings.TrimSpace(command)
+	for _, rule := range r.rules {
+		if rule.node != "" && rule.node != node {
+			continue
+		}
+		matched := strings.Contains(command, rule.match)
+		if rule.prefix {
+			matched = strings.HasPrefix(trimmed, rule.match)
+		}
+		if !matched {
+			continue
+		}
+		if rule.fail {
+			return "", fmt.Errorf("dry-run: 模拟命令失败")
+		}
+		return rule.output, nil
+	}
+	return "", nil
+}
+
+// recordUpload 记录文件上传
+func (r *Recorder) recordUpload(node, localPath, remotePath string) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("读取本地文件失败: %w", err)
+	}
+	r.add(PlanStep{Node: node, Type: PlanUpload, Source: localPath, Path: remotePath, Size: info.Size()})
+	return nil
+}
+
+// planFile 执行计划的 JSON 格式
+type planFile struct {
+	Cluster     string     `json:"cluster"`
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Steps       []PlanStep `json:"steps"`
+}
+
+// WriteJSON 将执行计划写入 JSON 文件
+func (r *Recorder) WriteJSON(path, clusterName string) error {
+	data, err := json.MarshalIndent(planFile{
+		Cluster:     clusterName,
+		GeneratedAt: time.Now(),
+		Steps:       r.Steps(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化执行计划失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("写入执行计划失败: %w", err)
+	}
+	return nil
+}
+
+// PrintPlan 按阶段和节点打印执行计划
+func (r *Recorder) PrintPlan() {
+	steps := r.Steps()
+
+	var phases []string
+	byPhase := make(map[string][]PlanStep)
+	for _, step := range steps {
+		if _, ok := byPhase[step.Phase]; !ok {
+			phases = append(phases, step.Phase)
+		}
+		byPhase[step.Phase] = append(byPhase[step.Phase], step)
+	}
+
+	for _, phase := range phases {
+		ui.Header(fmt.Sprintf("阶段: %s", phase))
+
+		// 并发执行的阶段中各节点的操作交错记录，按节点分组显示
+		var nodes []string
+		byNode := make(map[string][]PlanStep)
+		for _, step := range byPhase[phase] {
+			if _, ok := byNode[step.Node]; !ok {
+				nodes = append(nodes, step.Node)
+			}
+			byNode[step.Node] = append(byNode[step.Node], step)
+		}
+
+		for _, node := range nodes {
+			ui.Info("[%s]", node)
+			for _, step := range byNode[node] {
+				printPlanStep(step)
+			}
+			ui.Info("")
+		}
+	}
+
+	ui.Info("共 %d 个操作", len(steps))
+}
+
+func printPlanStep(step PlanStep) {
+	switch step.Type {
+	case PlanUpload:
+		ui.Info("  上传 %s -> %s (%d 字节)", step.Source, step.Path, step.Size)
+	case PlanFile:
+		ui.Info("  写入 %s:", step.Path)
+		printIndented(step.Content, "      ")
+	case PlanLocal:
+		if step.Path != "" {
+			ui.Info("  本地: %s (%s)", step.Description, step.Path)
+		} else {
+			ui.Info("  本地: %s", step.Description)
+		}
+		if step.Content != "" {
+			printIndented(step.Content, "      ")
+		}
+	default:
+		lines := strings.Split(step.Command, "\n")
+		ui.Info("  $ %s", strings.TrimSpace(lines[0]))
+		for _, line := range lines[1:] {
+			if strings.TrimSpace(line) != "" {
+				ui.Info("    %s", strings.TrimSpace(line))
+			}
+		}
+	}
+}
+
+func printIndented(content, indent string) {
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		ui.Info("%s%s", indent, line)
+	}
+}
+
+var (
+	recorderMu     sync.Mutex
+	activeRecorder *Recorder
+)
+
+// SetRecorder 启用或关闭记录模式（nil 表示关闭）
+// 启用后新建的 SSH 连接不会连接主机，所有操作都记录到 Recorder
+func SetRecorder(r *Recorder) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	activeRecorder = r
+}
+
+// ActiveRecorder 返回当前的记录器（未启用时返回 nil）
+func ActiveRecorder() *Recorder {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	return activeRecorder
+}
+
+// RecordingExecutor 只记录命令、不执行的 CommandExecutor
+type RecordingExecutor struct {
+	recorder *Recorder
+	node     string
+}
+
+// NewRecordingExecutor 创建记录指定节点操作的执行器
+func NewRecordingExecutor(recorder *Recorder, node string) *RecordingExecutor {
+	return &RecordingExecutor{recorder: recorder, node: node}
+}
+
+// Execute 记录命令并返回模拟结果
+func (e *RecordingExecutor) Execute(command string) (string, error) {
+	return e.recorder.recordCommand(e.node, command)
+}
+
+// ExecuteContext 记录命令并返回模拟结果
+func (e *RecordingExecutor) ExecuteContext(ctx context.Context, command string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", newInterruptedError(e.node, command, err)
+	}
+	return e.recorder.recordCommand(e.node, command)
+}
+
+// UploadFile 记录文件上传
+func (e *RecordingExecutor) UploadFile(localPath, remotePath string) error {
+	return e.recorder.recordUpload(e.node, localPath, remotePath)
+}
+
+// Close 无需关闭
+func (e *RecordingExecutor) Close() error {
+	return nil
+}
+
+var _ CommandExecutor = (*RecordingExecutor)(nil)
@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	secretsMu sync.Mutex
+	secrets   []string
+)
+
+// AddSecret 注册需要隐藏的敏感信息（如 SSH 密码、sudo 密码、镜像仓库密码）
+// 命令日志、命令执行结果事件和 dry-run 执行计划中的 secret 会被替换为 ******
+func AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// MaskSecrets 将 s 中已注册的 secret 替换为 ******
+func MaskSecrets(s string) string {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "******")
+	}
+	return s
+}
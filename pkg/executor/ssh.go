@@ -2,11 +2,16 @@ package executor
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +27,29 @@ type SSHClient struct {
 	// 保留原始认证信息，用于降级重连
 	keyFile  string
 	password string
+	// 主机密钥校验设置，重连时沿用
+	nodeName    string
+	hostKeyMode HostKeyMode
+	bastion     *BastionOptions
+
+	showProgress bool // 上传文件时显示进度条
+
+	recorder *RecordingExecutor // 记录模式（--dry-run）下不连接主机，只记录操作
+}
+
+// ClientOptions SSH 连接选项
+type ClientOptions struct {
+	Host     string
+	Port     int
+	User     string
+	KeyFile  string // 私钥文件（优先使用）
+	Password string // 密码
+
+	NodeName       string      // 节点名称，用于错误提示（可选）
+	HostKeyMode    HostKeyMode // 主机密钥校验模式（为空时使用全局设置）
+	KnownHostsFile string      // known_hosts 文件（为空时使用全局设置）
+
+	Bastion *BastionOptions // 跳板机（为空时使用全局设置，都为空则直连）
 }
 
 // NewSSHClient 创建新的 SSH 客户端
@@ -32,13 +60,72 @@ func NewSSHClient(host string, port int, user, keyFile string) (*SSHClient, erro
 
 // NewSSHClientWithPassword 创建新的 SSH 客户端（支持密码）
 func NewSSHClientWithPassword(host string, port int, user, keyFile, password string) (*SSHClient, error) {
+	return NewSSHClientWithOptions(ClientOptions{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		KeyFile:  keyFile,
+		Password: password,
+	})
+}
+
+// NewSSHClientWithOptions 根据连接选项创建 SSH 客户端
+func NewSSHClientWithOptions(opts ClientOptions) (*SSHClient, error) {
+	if recorder := ActiveRecorder(); recorder != nil {
+		node := opts.NodeName
+		if node == "" {
+			node = opts.Host
+		}
+		return &SSHClient{
+			Host:     opts.Host,
+			Port:     opts.Port,
+			User:     opts.User,
+			nodeName: opts.NodeName,
+			recorder: NewRecordingExecutor(recorder, node),
+		}, nil
+	}
+
+	mode, knownHosts := resolveHostKeyPolicy(opts.HostKeyMode, opts.KnownHostsFile)
+	hostKeyCallback, err := newHostKeyCallback(mode, knownHosts, opts.NodeName)
+	if err != nil {
+		return nil, err
+	}
+	
+	auth, err := authMethods(opts.KeyFile, opts.Password)
+	if err != nil {
+		return nil, err
+	}
+	
 	config := &ssh.ClientConfig{
-		User:            user,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 生产环境应该验证 host key
+		User:            opts.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
 		Timeout:         30 * time.Second,
 	}
 	
-	// 优先使用密钥认证
+	// 连接（配置了跳板机时通过跳板机转发）
+	bastion := resolveBastion(opts.Bastion)
+	addr := net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))
+	client, err := dialSSH(addr, config, bastion)
+	if err != nil {
+		return nil, fmt.Errorf("SSH 连接失败: %w", err)
+	}
+	
+	return &SSHClient{
+		Host:        opts.Host,
+		Port:        opts.Port,
+		User:        opts.User,
+		client:      client,
+		keyFile:     opts.KeyFile,
+		password:    opts.Password,
+		nodeName:    opts.NodeName,
+		hostKeyMode: opts.HostKeyMode,
+		bastion:     opts.Bastion,
+	}, nil
+}
+
+// authMethods 根据密钥或密码生成认证方式（优先使用密钥）
+func authMethods(keyFile, password string) ([]ssh.AuthMethod, error) {
 	if keyFile != "" {
 		keyPath := expandPath(keyFile)
 		key, err := os.ReadFile(keyPath)
@@ -51,47 +138,40 @@ func NewSSHClientWithPassword(host string, port int, user, keyFile, password str
 			return nil, fmt.Errorf("解析私钥失败: %w", err)
 		}
 		
-		config.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
-	} else if password != "" {
-		// 使用密码认证
-		config.Auth = []ssh.AuthMethod{ssh.Password(password)}
-	} else {
-		return nil, fmt.Errorf("必须提供 SSH 密钥或密码")
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
 	}
-	
-	// 连接
-	addr := fmt.Sprintf("%s:%d", host, port)
-	client, err := ssh.Dial("tcp", addr, config)
-	if err != nil {
-		return nil, fmt.Errorf("SSH 连接失败: %w", err)
+	if password != "" {
+		// 使用密码认证
+		return []ssh.AuthMethod{ssh.Password(password)}, nil
 	}
-	
-	return &SSHClient{
-		Host:     host,
-		Port:     port,
-		User:     user,
-		client:   client,
-		keyFile:  keyFile,
-		password: password,
-	}, nil
+	return nil, fmt.Errorf("必须提供 SSH 密钥或密码")
 }
 
-// Execute 执行远程命令
+// Execute 执行远程命令（使用 SetContext 设置的 context，取消时中断命令）
 func (c *SSHClient) Execute(command string) (string, error) {
-	session, err := c.client.NewSession()
-	if err != nil {
-		return "", fmt.Errorf("创建 SSH session 失败: %w", err)
-	}
-	defer session.Close()
+	return c.ExecuteContext(currentContext(), command)
+}
+
+// ExecuteWithTimeout 执行远程命令，超过 timeout 后中断
+func (c *SSHClient) ExecuteWithTimeout(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(currentContext(), timeout)
+	defer cancel()
+	return c.ExecuteContext(ctx, command)
+}
 
+// ExecuteContext 执行远程命令，ctx 取消或超时时向远程进程发送信号并返回 InterruptedError
+func (c *SSHClient) ExecuteContext(ctx context.Context, command string) (string, error) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	session.Stdout = &stdout
-	session.Stderr = &stderr
 
-	err = session.Run(command)
+	start := time.Now()
+	err := c.runContext(ctx, command, &stdout, &stderr)
+	if err != nil && !IsInterrupted(err) {
+		err = fmt.Errorf("命令执行失败: %w\n标准错误: %s", err, stderr.String())
+	}
+	c.finishCommand(command, start, stdout.String(), stderr.String(), err)
 	if err != nil {
-		return "", fmt.Errorf("命令执行失败: %w\n标准错误: %s", err, stderr.String())
+		return "", err
 	}
 
 	return stdout.String(), nil
@@ -99,68 +179,84 @@ func (c *SSHClient) Execute(command string) (string, error) {
 
 // ExecuteWithOutput 执行命令并实时输出
 func (c *SSHClient) ExecuteWithOutput(command string, output io.Writer) error {
-	session, err := c.client.NewSession()
-	if err != nil {
-		return fmt.Errorf("创建 SSH session 失败: %w", err)
-	}
-	defer session.Close()
+	var captured bytes.Buffer
+	w := io.MultiWriter(output, &captured)
 
-	session.Stdout = output
-	session.Stderr = output
-
-	return session.Run(command)
+	start := time.Now()
+	err := c.runContext(currentContext(), command, w, w)
+	c.finishCommand(command, start, captured.String(), "", err)
+	return err
 }
 
-// UploadFile 上传文件到远程服务器
-func (c *SSHClient) UploadFile(localPath, remotePath string) error {
-	// 读取本地文件
-	data, err := os.ReadFile(localPath)
-	if err != nil {
-		return fmt.Errorf("读取本地文件失败: %w", err)
+// finishCommand 记录命令日志并发布命令执行结果事件（dry-run 时不记录）
+func (c *SSHClient) finishCommand(command string, start time.Time, stdout, stderr string, err error) {
+	if c.recorder != nil {
+		return
 	}
-
-	// 获取文件权限
-	fileInfo, err := os.Stat(localPath)
-	if err != nil {
-		return fmt.Errorf("获取文件信息失败: %w", err)
+	node := c.nodeName
+	if node == "" {
+		node = c.Host
 	}
-	mode := fileInfo.Mode().Perm()
+	logCommand(node, command, start, stdout, stderr, err)
+	publishCommandResult(node, command, start, err)
+}
 
-	// 创建远程目录
-	remoteDir := filepath.Dir(remotePath)
-	if _, err := c.Execute(fmt.Sprintf("mkdir -p %s", remoteDir)); err != nil {
-		return fmt.Errorf("创建远程目录失败: %w", err)
+// runContext 在新的 session 中执行命令，ctx 结束时先发送 SIGTERM，超过 signalGracePeriod 后发送 SIGKILL 并关闭 session
+func (c *SSHClient) runContext(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return newInterruptedError(c.nodeName, command, err)
+	}
+	if c.recorder != nil {
+		output, err := c.recorder.ExecuteContext(ctx, command)
+		io.WriteString(stdout, output)
+		return err
 	}
 
-	// 使用 SCP 上传文件
 	session, err := c.client.NewSession()
 	if err != nil {
 		return fmt.Errorf("创建 SSH session 失败: %w", err)
 	}
 	defer session.Close()
 
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if err := session.Start(command); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
 	go func() {
-		w, _ := session.StdinPipe()
-		defer w.Close()
-		
-		// SCP 协议
-		fmt.Fprintf(w, "C%#o %d %s\n", mode, len(data), filepath.Base(remotePath))
-		w.Write(data)
-		fmt.Fprint(w, "\x00")
+		done <- session.Wait()
 	}()
 
-	// 执行 SCP 命令
-	if err := session.Run(fmt.Sprintf("scp -t %s", remotePath)); err != nil {
-		return fmt.Errorf("SCP 上传失败: %w", err)
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
 	}
 
-	return nil
+	// 部分 sshd 不支持 signal 请求，最终通过关闭 session 结束等待
+	session.Signal(ssh.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(signalGracePeriod):
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		<-done
+	}
+	return newInterruptedError(c.nodeName, command, ctx.Err())
 }
 
 // DownloadFile 从远程服务器下载文件
+// 文件以流的方式写入本地临时文件，sha256 与远程一致后再重命名（支持大文件和二进制文件）
 func (c *SSHClient) DownloadFile(remotePath, localPath string) error {
-	// 读取远程文件内容
-	content, err := c.Execute(fmt.Sprintf("cat %s", remotePath))
+	if c.recorder != nil {
+		c.recorder.recorder.RecordLocal(fmt.Sprintf("下载 %s:%s", c.recorder.node, remotePath), localPath, "")
+		return nil
+	}
+
+	remoteSum, err := c.RemoteSHA256(remotePath)
 	if err != nil {
 		return fmt.Errorf("读取远程文件失败: %w", err)
 	}
@@ -171,8 +267,32 @@ func (c *SSHClient) DownloadFile(remotePath, localPath string) error {
 		return fmt.Errorf("创建本地目录失败: %w", err)
 	}
 
-	// 写入本地文件
-	if err := os.WriteFile(localPath, []byte(content), 0644); err != nil {
+	tmp, err := os.CreateTemp(localDir, "."+filepath.Base(localPath)+".*")
+	if err != nil {
+		return fmt.Errorf("写入本地文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	var stderr bytes.Buffer
+	err = c.runContext(currentContext(), fmt.Sprintf("cat %s", remotePath), io.MultiWriter(tmp, h), &stderr)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if IsInterrupted(err) {
+			return err
+		}
+		return fmt.Errorf("读取远程文件失败: %w\n标准错误: %s", err, stderr.String())
+	}
+
+	if localSum := hex.EncodeToString(h.Sum(nil)); localSum != remoteSum {
+		return fmt.Errorf("下载后校验和不一致: %s (远程 %s, 本地 %s)", remotePath, remoteSum, localSum)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("写入本地文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), localPath); err != nil {
 		return fmt.Errorf("写入本地文件失败: %w", err)
 	}
 
@@ -242,7 +362,7 @@ func TestConnectionWithPassword(host string, port int, user, keyFile, password s
 
 // ExecuteLocalCommand 执行本地命令
 func ExecuteLocalCommand(command string) (string, error) {
-	cmd := exec.Command("sh", "-c", command)
+	cmd := exec.CommandContext(currentContext(), "sh", "-c", command)
 	
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -262,13 +382,28 @@ func ExecuteLocalCommand(command string) (string, error) {
 // 1. root + SSH 密钥（如果已配置）
 // 2. 原始用户 + 密码（降级方案）
 func NewSSHClientSmart(host string, port int, user, keyFile, password string) (*SSHClient, error) {
+	return newSSHClientSmart(ClientOptions{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		KeyFile:  keyFile,
+		Password: password,
+	})
+}
+
+// newSSHClientSmart 按 NewSSHClientSmart 的顺序尝试连接，保留主机密钥校验设置
+func newSSHClientSmart(opts ClientOptions) (*SSHClient, error) {
+	keyFile, password := opts.KeyFile, opts.Password
+	
 	// 尝试 1: root + SSH 密钥（假设已提权）
 	rootKeyFile := "/root/.ssh/id_rsa"
 	if keyFile == "" {
 		keyFile = rootKeyFile
 	}
 	
-	client, err := NewSSHClientWithPassword(host, port, "root", rootKeyFile, "")
+	attempt := opts
+	attempt.User, attempt.KeyFile, attempt.Password = "root", rootKeyFile, ""
+	client, err := NewSSHClientWithOptions(attempt)
 	if err == nil {
 		// root 密钥连接成功
 		// 但保留原始认证信息，以备后续降级使用
@@ -276,10 +411,15 @@ func NewSSHClientSmart(host string, port int, user, keyFile, password string) (*
 		client.password = password
 		return client, nil
 	}
+	if isHostKeyError(err) {
+		return nil, err
+	}
 	
 	// 尝试 2: 原始用户 + 密钥（如果提供了）
 	if keyFile != "" && keyFile != rootKeyFile {
-		client, err = NewSSHClientWithPassword(host, port, user, keyFile, "")
+		attempt = opts
+		attempt.KeyFile, attempt.Password = keyFile, ""
+		client, err = NewSSHClientWithOptions(attempt)
 		if err == nil {
 			client.password = password
 			return client, nil
@@ -288,7 +428,9 @@ func NewSSHClientSmart(host string, port int, user, keyFile, password string) (*
 	
 	// 尝试 3: 原始用户 + 密码（降级方案）
 	if password != "" {
-		client, err = NewSSHClientWithPassword(host, port, user, "", password)
+		attempt = opts
+		attempt.KeyFile = ""
+		client, err = NewSSHClientWithOptions(attempt)
 		if err == nil {
 			client.keyFile = keyFile
 			return client, nil
@@ -311,27 +453,43 @@ func (c *SSHClient) ExecuteWithSudo(command string) (string, error) {
 	
 	// 需要 sudo 提权
 	if c.password != "" {
-		// 使用密码 sudo
+		// 使用密码 sudo（密码在命令日志和事件中隐藏）
+		password := strings.ReplaceAll(c.password, "'", "'\\''")
+		AddSecret(c.password)
+		AddSecret(password)
 		sudoCmd := fmt.Sprintf("echo '%s' | sudo -S bash -c '%s'", 
-			c.password, 
+			password, 
 			strings.ReplaceAll(command, "'", "'\\''"))
 		return c.Execute(sudoCmd)
 	}
 	
 	// 尝试无密码 sudo
 	return c.Execute(fmt.Sprintf("sudo bash -c '%s'", 
-		strings.ReplaceAll(command, "'", "'\\'")))
+		strings.ReplaceAll(command, "'", "'\\''")))
 }
 
 // Reconnect 重新连接（用于连接失效时）
 func (c *SSHClient) Reconnect() error {
+	if c.recorder != nil {
+		return nil
+	}
+
 	// 关闭旧连接
 	if c.client != nil {
 		c.client.Close()
 	}
 	
 	// 尝试重新连接
-	newClient, err := NewSSHClientSmart(c.Host, c.Port, c.User, c.keyFile, c.password)
+	newClient, err := newSSHClientSmart(ClientOptions{
+		Host:        c.Host,
+		Port:        c.Port,
+		User:        c.User,
+		KeyFile:     c.keyFile,
+		Password:    c.password,
+		NodeName:    c.nodeName,
+		HostKeyMode: c.hostKeyMode,
+		Bastion:     c.bastion,
+	})
 	if err != nil {
 		return fmt.Errorf("重新连接失败: %w", err)
 	}
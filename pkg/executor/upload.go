@@ -0,0 +1,148 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// progressThreshold 超过该大小的文件上传时显示进度条
+const progressThreshold = 1 << 20
+
+// SetUploadProgress 设置上传文件时是否显示进度条（并发上传时应关闭，避免输出混乱）
+func (c *SSHClient) SetUploadProgress(show bool) {
+	c.showProgress = show
+}
+
+// UploadFile 上传文件到远程服务器
+// 文件通过 SFTP 以流的方式发送并保留权限；远程文件的 sha256 与本地一致时跳过传输
+func (c *SSHClient) UploadFile(localPath, remotePath string) error {
+	if c.recorder != nil {
+		return c.recorder.UploadFile(localPath, remotePath)
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("读取本地文件失败: %w", err)
+	}
+	defer f.Close()
+
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	mode := fileInfo.Mode().Perm()
+
+	localSum, err := fileSHA256(f)
+	if err != nil {
+		return fmt.Errorf("计算本地文件校验和失败: %w", err)
+	}
+
+	// 远程文件内容一致时只同步权限
+	if remoteSum, err := c.RemoteSHA256(remotePath); err == nil && remoteSum == localSum {
+		if _, err := c.Execute(fmt.Sprintf("chmod %o %s", mode, remotePath)); err != nil {
+			return fmt.Errorf("设置远程文件权限失败: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("读取本地文件失败: %w", err)
+	}
+
+	// 创建远程目录
+	remoteDir := path.Dir(remotePath)
+	if _, err := c.Execute(fmt.Sprintf("mkdir -p %s", remoteDir)); err != nil {
+		return fmt.Errorf("创建远程目录失败: %w", err)
+	}
+
+	if err := c.sftpUpload(f, fileInfo.Size(), mode, remotePath); err != nil {
+		return err
+	}
+
+	// 上传后校验内容
+	remoteSum, err := c.RemoteSHA256(remotePath)
+	if err != nil {
+		return fmt.Errorf("校验远程文件失败: %w", err)
+	}
+	if remoteSum != localSum {
+		return fmt.Errorf("上传后校验和不一致: %s (本地 %s, 远程 %s)", remotePath, localSum, remoteSum)
+	}
+
+	return nil
+}
+
+// sftpUpload 使用 SFTP 流式上传（只依赖节点的 sftp-server 子系统，不需要 scp 命令）
+// 先写入同目录的临时文件再重命名，中断时不会留下不完整的目标文件
+func (c *SSHClient) sftpUpload(r io.Reader, size int64, mode os.FileMode, remotePath string) error {
+	client, err := sftp.NewClient(c.client)
+	if err != nil {
+		return fmt.Errorf("创建 SFTP 会话失败: %w", err)
+	}
+	defer client.Close()
+
+	if c.showProgress && size >= progressThreshold {
+		bar := ui.NewProgressBar(int(size), fmt.Sprintf("上传 %s", path.Base(remotePath)))
+		defer bar.Finish()
+		r = io.TeeReader(r, bar)
+	}
+
+	tmpPath := remotePath + ".uploading"
+	dst, err := client.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("SFTP 创建远程文件失败: %w", err)
+	}
+	if _, err := dst.ReadFrom(r); err != nil {
+		dst.Close()
+		client.Remove(tmpPath)
+		return fmt.Errorf("SFTP 上传失败: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		client.Remove(tmpPath)
+		return fmt.Errorf("SFTP 上传失败: %w", err)
+	}
+
+	if err := client.Chmod(tmpPath, mode); err != nil {
+		client.Remove(tmpPath)
+		return fmt.Errorf("设置远程文件权限失败: %w", err)
+	}
+	// PosixRename 覆盖已存在的文件（需要 OpenSSH 的 posix-rename 扩展），不支持时先删除再重命名
+	if err := client.PosixRename(tmpPath, remotePath); err != nil {
+		client.Remove(remotePath)
+		if err := client.Rename(tmpPath, remotePath); err != nil {
+			client.Remove(tmpPath)
+			return fmt.Errorf("重命名远程文件失败: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RemoteSHA256 计算远程文件的 sha256（文件不存在时返回错误）
+func (c *SSHClient) RemoteSHA256(remotePath string) (string, error) {
+	output, err := c.Execute(fmt.Sprintf("sha256sum %s", remotePath))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("sha256sum 输出为空")
+	}
+	return fields[0], nil
+}
+
+// fileSHA256 计算本地文件的 sha256
+func fileSHA256(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
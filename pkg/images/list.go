@@ -0,0 +1,77 @@
+package images
+
+import (
+	"fmt"
+
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/packages"
+)
+
+// Image 集群需要的镜像
+type Image struct {
+	packages.Image
+	Component string // 使用该镜像的组件（离线包清单中的名称）
+}
+
+// List 返回集群配置需要推送到 spec.imageRepository 的所有镜像
+// 镜像名称和 tag 来自离线包清单，按集群配置中启用的组件筛选
+func List(cfg *config.ClusterConfig, pkgMgr *packages.Manager) ([]Image, error) {
+	components := []string{"kubeadm", "cilium-chart"}
+	if metalLBEnabled(cfg) {
+		components = append(components, "metallb-chart")
+	}
+	if gpuEnabled(cfg) {
+		components = append(components, "nvidia-container-toolkit")
+	}
+	if cfg.Spec.HA.UsesKubeVIP() {
+		components = append(components, "kube-vip")
+	}
+
+	var list []Image
+	seen := make(map[string]bool)
+	for _, component := range components {
+		a := pkgMgr.Artifact(component)
+		if a == nil {
+			return nil, fmt.Errorf("离线包清单中没有 %s", component)
+		}
+		for _, image := range a.Images {
+			if !imageEnabled(cfg, image.Name) || seen[image.Ref()] {
+				continue
+			}
+			seen[image.Ref()] = true
+			list = append(list, Image{Image: image, Component: component})
+		}
+	}
+	return list, nil
+}
+
+// imageEnabled 按集群配置判断组件中的镜像是否需要（与 Cilium values 模板的条件一致）
+func imageEnabled(cfg *config.ClusterConfig, name string) bool {
+	switch name {
+	case "kube-proxy":
+		// kubeadm init 跳过 kube-proxy，由 Cilium 替代
+		return false
+	case "hubble-relay":
+		return cfg.Spec.Hubble.Enabled
+	case "hubble-ui", "hubble-ui-backend":
+		return cfg.Spec.Hubble.Enabled && cfg.Spec.Hubble.UI.Enabled
+	case "cilium-envoy":
+		return cfg.Spec.GatewayAPI.Enabled && cfg.Spec.Envoy.Enabled
+	}
+	return true
+}
+
+// metalLBEnabled 是否安装 MetalLB（与 cluster.InstallMetalLB 的条件一致）
+func metalLBEnabled(cfg *config.ClusterConfig) bool {
+	return cfg.Spec.BGP.Enabled || cfg.Spec.LoadBalancer.Mode == "l2"
+}
+
+// gpuEnabled 是否有 GPU 节点
+func gpuEnabled(cfg *config.ClusterConfig) bool {
+	for _, node := range cfg.Spec.Nodes {
+		if node.GPU {
+			return true
+		}
+	}
+	return false
+}
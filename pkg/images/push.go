@@ -0,0 +1,148 @@
+package images
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/packages"
+	"stormdragon/k8s-deployer/pkg/ui"
+)
+
+// Pusher 推送镜像到镜像仓库
+type Pusher struct {
+	repository string // 镜像仓库地址（主机[/项目]，不含协议）
+	nameOpts   []name.Option
+	remoteOpts []remote.Option
+}
+
+// NewPusher 创建推送到 imageRepository 的 Pusher，协议与节点上 containerd 的 hosts.toml 一致：
+//   - 配置 caFile: 使用 HTTPS，并用该 CA 校验证书
+//   - 其他情况: 先尝试 HTTPS（跳过证书校验），失败时使用 HTTP
+func NewPusher(ctx context.Context, imageRepository string, harbor config.HarborConfig) (*Pusher, error) {
+	repository := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(imageRepository, "http://"), "https://"), "/")
+	if repository == "" {
+		return nil, fmt.Errorf("未配置 spec.imageRepository")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	var nameOpts []name.Option
+	if harbor.CAFile != "" {
+		caData, err := os.ReadFile(config.ExpandHomePath(harbor.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("读取 Harbor CA 证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("Harbor CA 证书 %s 格式不正确", harbor.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	} else {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+
+	var auth authn.Authenticator = authn.Anonymous
+	if harbor.Username != "" {
+		auth = authn.FromConfig(authn.AuthConfig{Username: harbor.Username, Password: harbor.Password})
+	}
+
+	return &Pusher{
+		repository: repository,
+		nameOpts:   nameOpts,
+		remoteOpts: []remote.Option{
+			remote.WithContext(ctx),
+			remote.WithTransport(transport),
+			remote.WithAuth(auth),
+		},
+	}, nil
+}
+
+// Reference 返回镜像在镜像仓库中的完整名称
+func (p *Pusher) Reference(img Image) string {
+	return p.repository + "/" + img.Ref()
+}
+
+// Push 推送镜像，镜像仓库中已有相同 digest 的镜像时跳过（skipped 为 true）
+func (p *Pusher) Push(img Image, artifact *Artifact) (skipped bool, err error) {
+	ref, err := name.NewTag(p.Reference(img), p.nameOpts...)
+	if err != nil {
+		return false, fmt.Errorf("镜像名称 %s 不正确: %w", p.Reference(img), err)
+	}
+
+	digest, err := artifact.Digest()
+	if err != nil {
+		return false, fmt.Errorf("读取 %s 失败: %w", artifact.From, err)
+	}
+	if desc, err := remote.Head(ref, p.remoteOpts...); err == nil && desc.Digest == digest {
+		return true, nil
+	}
+
+	if artifact.Index != nil {
+		err = remote.WriteIndex(ref, artifact.Index, p.remoteOpts...)
+	} else {
+		err = remote.Write(ref, artifact.Image, p.remoteOpts...)
+	}
+	if err != nil {
+		return false, fmt.Errorf("推送 %s 失败: %w", ref, err)
+	}
+	return false, nil
+}
+
+// PushAll 将集群需要的所有镜像从本地目录推送到 spec.imageRepository
+// 本地找不到的镜像会跳过，最后返回错误
+func PushAll(ctx context.Context, cfg *config.ClusterConfig, pkgMgr *packages.Manager, dir string) error {
+	list, err := List(cfg, pkgMgr)
+	if err != nil {
+		return err
+	}
+	source, err := OpenSource(dir)
+	if err != nil {
+		return err
+	}
+	pusher, err := NewPusher(ctx, cfg.Spec.ImageRepository, cfg.Spec.Harbor)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	pushed, skipped := 0, 0
+	for _, img := range list {
+		artifact, err := source.Find(img)
+		if err != nil {
+			return err
+		}
+		if artifact == nil {
+			ui.Warning("找不到 %s 的本地镜像（%s 或 OCI layout 中的 %s）", img.Ref(), img.ArchiveName(), img.Source)
+			missing = append(missing, img.Ref())
+			continue
+		}
+
+		ui.SubStep("推送 %s...", pusher.Reference(img))
+		alreadyExists, err := pusher.Push(img, artifact)
+		if err != nil {
+			ui.SubStepFailed()
+			return err
+		}
+		ui.SubStepDone()
+		if alreadyExists {
+			skipped++
+		} else {
+			pushed++
+		}
+	}
+
+	ui.Info("推送 %d 个镜像，%d 个已存在", pushed, skipped)
+	if len(missing) > 0 {
+		return fmt.Errorf("%d 个镜像没有本地文件: %s", len(missing), strings.Join(missing, ", "))
+	}
+	return nil
+}
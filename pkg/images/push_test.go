@@ -0,0 +1,179 @@
+package images
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"stormdragon/k8s-deployer/pkg/config"
+	"stormdragon/k8s-deployer/pkg/packages"
+)
+
+// newTestRegistry 启动内存镜像仓库，返回 Pusher 使用的仓库地址（主机/项目）
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://") + "/library"
+}
+
+// writeArchive 在 dir 中生成镜像 tar 包（<名称>_<tag>.tar），返回写入的镜像
+func writeArchive(t *testing.T, dir string, img Image) v1.Image {
+	t.Helper()
+	image, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewTag(img.Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tarball.WriteToFile(filepath.Join(dir, img.ArchiveName()), ref, image); err != nil {
+		t.Fatal(err)
+	}
+	return image
+}
+
+func testImage() Image {
+	return Image{
+		Image:     packages.Image{Name: "pause", Tag: "3.10.1", Source: "registry.k8s.io/pause:3.10.1"},
+		Component: "kubeadm",
+	}
+}
+
+func TestSourceFindArchive(t *testing.T) {
+	dir := t.TempDir()
+	img := testImage()
+	writeArchive(t, dir, img)
+
+	source, err := OpenSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	artifact, err := source.Find(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if artifact == nil || artifact.Image == nil {
+		t.Fatalf("Find(%s) 没有找到镜像 tar 包", img.Ref())
+	}
+	if want := filepath.Join(dir, img.ArchiveName()); artifact.From != want {
+		t.Errorf("From = %q, want %q", artifact.From, want)
+	}
+
+	missing := img
+	missing.Tag = "3.9"
+	if artifact, err := source.Find(missing); err != nil || artifact != nil {
+		t.Errorf("Find(%s) = %v, %v, want nil, nil", missing.Ref(), artifact, err)
+	}
+}
+
+func TestPusherPush(t *testing.T) {
+	repository := newTestRegistry(t)
+	dir := t.TempDir()
+	img := testImage()
+	writeArchive(t, dir, img)
+
+	source, err := OpenSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact, err := source.Find(img)
+	if err != nil || artifact == nil {
+		t.Fatalf("Find(%s) = %v, %v", img.Ref(), artifact, err)
+	}
+
+	pusher, err := NewPusher(context.Background(), "http://"+repository, config.HarborConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pusher.Reference(img), repository+"/pause:3.10.1"; got != want {
+		t.Errorf("Reference = %q, want %q", got, want)
+	}
+
+	skipped, err := pusher.Push(img, artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped {
+		t.Error("第一次推送不应跳过")
+	}
+
+	// 镜像仓库中的 digest 与本地一致
+	ref, err := name.NewTag(pusher.Reference(img), name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := remote.Head(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := artifact.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Digest != digest {
+		t.Errorf("镜像仓库中的 digest = %s, want %s", desc.Digest, digest)
+	}
+}
+
+func TestPusherSkipsUnchangedDigest(t *testing.T) {
+	repository := newTestRegistry(t)
+	img := testImage()
+	pusher, err := NewPusher(context.Background(), repository, config.HarborConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeArchive(t, dir, img)
+	source, err := OpenSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact, err := source.Find(img)
+	if err != nil || artifact == nil {
+		t.Fatalf("Find(%s) = %v, %v", img.Ref(), artifact, err)
+	}
+
+	if _, err := pusher.Push(img, artifact); err != nil {
+		t.Fatal(err)
+	}
+	skipped, err := pusher.Push(img, artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !skipped {
+		t.Error("digest 相同时应跳过推送")
+	}
+
+	// 同一个 tag 的镜像内容变化后重新推送
+	changedDir := t.TempDir()
+	writeArchive(t, changedDir, img)
+	changedSource, err := OpenSource(changedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := changedSource.Find(img)
+	if err != nil || changed == nil {
+		t.Fatalf("Find(%s) = %v, %v", img.Ref(), changed, err)
+	}
+	skipped, err = pusher.Push(img, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped {
+		t.Error("digest 变化后不应跳过推送")
+	}
+}
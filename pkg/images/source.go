@@ -0,0 +1,101 @@
+package images
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// ociRefAnnotation OCI layout 中记录镜像名称的注解（skopeo copy oci:<dir>:<ref> 写入）
+const ociRefAnnotation = "org.opencontainers.image.ref.name"
+
+// Source 本地镜像来源，目录中可以包含：
+//   - 镜像 tar 包：<名称>_<tag>.tar（docker-archive 格式，docker save / skopeo docker-archive）
+//   - OCI layout：目录中有 oci-layout 和 index.json，按 ref.name 注解匹配 <名称>:<tag> 或上游镜像
+type Source struct {
+	dir    string
+	layout layout.Path // 不是 OCI layout 时为空
+}
+
+// Artifact 本地找到的镜像（单架构镜像或多架构镜像索引）
+type Artifact struct {
+	Image v1.Image
+	Index v1.ImageIndex
+	From  string // 来源（tar 包路径或 OCI layout 中的名称）
+}
+
+// Digest 返回镜像 manifest 的 digest
+func (a *Artifact) Digest() (v1.Hash, error) {
+	if a.Index != nil {
+		return a.Index.Digest()
+	}
+	return a.Image.Digest()
+}
+
+// OpenSource 打开本地镜像目录
+func OpenSource(dir string) (*Source, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("打开镜像目录失败: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s 不是目录", dir)
+	}
+
+	source := &Source{dir: dir}
+	if _, err := os.Stat(filepath.Join(dir, "oci-layout")); err == nil {
+		if source.layout, err = layout.FromPath(dir); err != nil {
+			return nil, fmt.Errorf("读取 OCI layout %s 失败: %w", dir, err)
+		}
+	}
+	return source, nil
+}
+
+// Find 查找镜像，找不到时返回 nil
+// 优先使用镜像 tar 包，然后查找 OCI layout
+func (s *Source) Find(img Image) (*Artifact, error) {
+	archive := filepath.Join(s.dir, img.ArchiveName())
+	if _, err := os.Stat(archive); err == nil {
+		image, err := tarball.ImageFromPath(archive, nil)
+		if err != nil {
+			return nil, fmt.Errorf("读取镜像 tar 包 %s 失败: %w", archive, err)
+		}
+		return &Artifact{Image: image, From: archive}, nil
+	}
+
+	if s.layout == "" {
+		return nil, nil
+	}
+	index, err := s.layout.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("读取 OCI layout 失败: %w", err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("读取 OCI layout 失败: %w", err)
+	}
+	for _, desc := range manifest.Manifests {
+		ref := desc.Annotations[ociRefAnnotation]
+		if ref == "" || (ref != img.Ref() && ref != img.Source) {
+			continue
+		}
+		from := fmt.Sprintf("%s (%s)", s.dir, ref)
+		if desc.MediaType.IsIndex() {
+			child, err := index.ImageIndex(desc.Digest)
+			if err != nil {
+				return nil, fmt.Errorf("读取 %s 失败: %w", from, err)
+			}
+			return &Artifact{Index: child, From: from}, nil
+		}
+		image, err := index.Image(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", from, err)
+		}
+		return &Artifact{Image: image, From: from}, nil
+	}
+	return nil, nil
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"net"
+	"strings"
 	"text/template"
 
 	"stormdragon/k8s-deployer/pkg/config"
@@ -12,33 +14,105 @@ import (
 //go:embed templates/kubeadm-init.yaml.tpl
 var kubeadmInitTemplate string
 
+//go:embed templates/kubeadm-join.yaml.tpl
+var kubeadmJoinTemplate string
+
+// controlPlaneTaint kubeadm 默认给 Master 节点添加的污点
+var controlPlaneTaint = config.TaintConfig{Key: "node-role.kubernetes.io/control-plane", Effect: "NoSchedule"}
+
 // InitConfig kubeadm init 配置参数
 type InitConfig struct {
 	Version              string
 	ImageRepository      string
 	ControlPlaneEndpoint string
 	ClusterName          string
-	VIP                  string
+	VIP                  string // 写入 certSANs 的 VIP 或外部负载均衡器地址
 	LocalIP              string
 	PodSubnet            string
 	ServiceSubnet        string
 	MasterIPs            []string
+
+	CACertificateValidity string // CA 证书有效期
+	CertificateValidity   string // 其他证书有效期
+
+	NodeRegistration // 第一个 Master 的标签和污点
+}
+
+// NodeRegistration kubeadm nodeRegistration 中的节点标签、污点和地址
+type NodeRegistration struct {
+	Taints           []config.TaintConfig // 为空时使用 kubeadm 默认值（Master 带 control-plane 污点）
+	NodeLabels       string               // kubelet --node-labels（只包含 kubelet 允许自行设置的标签）
+	NodeIP           string               // kubelet --node-ip（双栈或 IPv6 节点），为空时由 kubelet 自动选择
+	AdvertiseAddress string               // Master 节点 API Server 的监听地址（节点 IP）
+}
+
+// NewNodeRegistration 根据节点（及其节点组）配置生成 nodeRegistration
+// kubelet 不能自行设置 kubernetes.io / k8s.io 下的大部分标签，这些标签在节点加入后通过 kubectl 设置
+func NewNodeRegistration(clusterConfig *config.ClusterConfig, node *config.NodeConfig) NodeRegistration {
+	meta := config.ResolveNodeMetadata(clusterConfig, node)
+
+	var labels []string
+	for _, key := range config.SortedKeys(meta.Labels) {
+		if KubeletCanSetLabel(key) {
+			labels = append(labels, key+"="+meta.Labels[key])
+		}
+	}
+
+	reg := NodeRegistration{NodeLabels: strings.Join(labels, ","), AdvertiseAddress: node.IP}
+	// kubelet 未指定 --node-ip 时优先使用 IPv4 地址，双栈和 IPv6 节点需要显式指定
+	if node.SecondaryIP != "" || config.IsIPv6(node.IP) {
+		reg.NodeIP = strings.Join(node.NodeIPs(), ",")
+	}
+	if len(meta.Taints) > 0 {
+		// 配置了污点时 kubeadm 不再添加默认污点，Master 需要显式保留
+		if node.Role == "master" && !hasTaint(meta.Taints, controlPlaneTaint) {
+			reg.Taints = append(reg.Taints, controlPlaneTaint)
+		}
+		reg.Taints = append(reg.Taints, meta.Taints...)
+	}
+	return reg
+}
+
+func hasTaint(taints []config.TaintConfig, taint config.TaintConfig) bool {
+	for _, t := range taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect {
+			return true
+		}
+	}
+	return false
+}
+
+// KubeletCanSetLabel 判断 kubelet 是否可以通过 --node-labels 设置该标签（NodeRestriction 准入限制）
+func KubeletCanSetLabel(key string) bool {
+	prefix := ""
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix = key[:i]
+	}
+	restricted := prefix == "kubernetes.io" || strings.HasSuffix(prefix, ".kubernetes.io") ||
+		prefix == "k8s.io" || strings.HasSuffix(prefix, ".k8s.io")
+	if !restricted {
+		return true
+	}
+	return prefix == "kubelet.kubernetes.io" || strings.HasSuffix(prefix, ".kubelet.kubernetes.io") ||
+		prefix == "node.kubernetes.io" || strings.HasSuffix(prefix, ".node.kubernetes.io")
 }
 
 // GenerateInitConfig 生成 kubeadm init 配置
 func GenerateInitConfig(clusterConfig *config.ClusterConfig, localIP string) (string, error) {
-	// 收集所有 master 节点 IP
+	// 收集所有 master 节点 IP（包括双栈的第二个地址）
 	var masterIPs []string
 	for _, node := range clusterConfig.Spec.Nodes {
 		if node.Role == "master" {
-			masterIPs = append(masterIPs, node.IP)
+			masterIPs = append(masterIPs, node.NodeIPs()...)
 		}
 	}
 
-	// 确定控制平面端点
-	controlPlaneEndpoint := localIP + ":6443"
-	if clusterConfig.Spec.HA.Enabled {
-		controlPlaneEndpoint = clusterConfig.Spec.HA.VIP + ":6443"
+	// 确定控制平面端点（IPv6 地址需要加方括号）
+	controlPlaneEndpoint := clusterConfig.Spec.HA.ControlPlaneEndpoint(localIP)
+	vip := clusterConfig.Spec.HA.VIP
+	if clusterConfig.Spec.HA.External() {
+		// 外部负载均衡器地址可能是域名，同样需要加入 API Server 证书
+		vip, _, _ = net.SplitHostPort(controlPlaneEndpoint)
 	}
 
 	// 构建配置参数
@@ -47,11 +121,20 @@ func GenerateInitConfig(clusterConfig *config.ClusterConfig, localIP string) (st
 		ImageRepository:      clusterConfig.Spec.ImageRepository,
 		ControlPlaneEndpoint: controlPlaneEndpoint,
 		ClusterName:          clusterConfig.Metadata.Name,
-		VIP:                  clusterConfig.Spec.HA.VIP,
+		VIP:                  vip,
 		LocalIP:              localIP,
 		PodSubnet:            clusterConfig.Spec.Networking.PodSubnet,
 		ServiceSubnet:        clusterConfig.Spec.Networking.ServiceSubnet,
 		MasterIPs:            masterIPs,
+
+		CACertificateValidity: certificateValidity(clusterConfig.Spec.Certificates.CAValidity),
+		CertificateValidity:   certificateValidity(clusterConfig.Spec.Certificates.Validity),
+	}
+	for i := range clusterConfig.Spec.Nodes {
+		if node := &clusterConfig.Spec.Nodes[i]; node.IP == localIP {
+			params.NodeRegistration = NewNodeRegistration(clusterConfig, node)
+			break
+		}
 	}
 
 	// 渲染模板
@@ -68,6 +151,14 @@ func GenerateInitConfig(clusterConfig *config.ClusterConfig, localIP string) (st
 	return buf.String(), nil
 }
 
+// certificateValidity 未配置时使用默认有效期（旧版本保存的集群配置中没有该字段）
+func certificateValidity(validity string) string {
+	if validity == "" {
+		return config.DefaultCertificateValidity
+	}
+	return validity
+}
+
 // JoinCommand join 命令结构
 type JoinCommand struct {
 	APIServerEndpoint string
@@ -76,31 +167,30 @@ type JoinCommand struct {
 	CACertHash        string
 }
 
-// GenerateMasterJoinCommand 生成 master 节点 join 命令
-func GenerateMasterJoinCommand(cmd *JoinCommand) string {
-	return fmt.Sprintf(`kubeadm join %s \
-  --token %s \
-  --discovery-token-ca-cert-hash %s \
-  --control-plane \
-  --certificate-key %s \
-  --cri-socket unix:///var/run/containerd/containerd.sock`,
-		cmd.APIServerEndpoint,
-		cmd.Token,
-		cmd.CACertHash,
-		cmd.CertificateKey,
-	)
-}
-
-// GenerateWorkerJoinCommand 生成 worker 节点 join 命令
-func GenerateWorkerJoinCommand(cmd *JoinCommand) string {
-	return fmt.Sprintf(`kubeadm join %s \
-  --token %s \
-  --discovery-token-ca-cert-hash %s \
-  --cri-socket unix:///var/run/containerd/containerd.sock`,
-		cmd.APIServerEndpoint,
-		cmd.Token,
-		cmd.CACertHash,
-	)
+// joinConfigParams kubeadm join 配置模板参数
+type joinConfigParams struct {
+	*JoinCommand
+	NodeRegistration
+}
+
+// GenerateJoinConfig 生成 kubeadm join 使用的 JoinConfiguration
+// CertificateKey 不为空时生成 Master 节点的配置
+func GenerateJoinConfig(cmd *JoinCommand, reg NodeRegistration) (string, error) {
+	tmpl, err := template.New("kubeadm-join").Parse(kubeadmJoinTemplate)
+	if err != nil {
+		return "", fmt.Errorf("解析模板失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, joinConfigParams{JoinCommand: cmd, NodeRegistration: reg}); err != nil {
+		return "", fmt.Errorf("渲染模板失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// GetJoinCommand 获取 kubeadm join 命令
+func GetJoinCommand(configFile string) string {
+	return fmt.Sprintf("kubeadm join --config %s", configFile)
 }
 
 // GetInitCommand 获取 kubeadm init 命令
@@ -122,3 +212,37 @@ func GetResetCommand() string {
 	return "kubeadm reset -f --cri-socket unix:///var/run/containerd/containerd.sock"
 }
 
+
+// GenerateUpgradeConfig 生成 kubeadm upgrade apply 使用的 UpgradeConfiguration
+// 集群初始化时跳过了 kube-proxy（由 Cilium 替代），升级时同样需要跳过
+func GenerateUpgradeConfig(version string) string {
+	return fmt.Sprintf(`apiVersion: kubeadm.k8s.io/v1beta4
+kind: UpgradeConfiguration
+apply:
+  kubernetesVersion: %s
+  certificateRenewal: true
+  etcdUpgrade: true
+  skipPhases:
+  - addon/kube-proxy
+`, version)
+}
+
+// GetUpgradeApplyCommand 获取 kubeadm upgrade apply 命令（第一个 Master 节点执行）
+func GetUpgradeApplyCommand(configFile string) string {
+	return fmt.Sprintf("kubeadm upgrade apply --config %s --yes", configFile)
+}
+
+// GetUpgradeNodeCommand 获取 kubeadm upgrade node 命令（其他 Master 和 Worker 节点执行）
+func GetUpgradeNodeCommand() string {
+	return "kubeadm upgrade node"
+}
+
+// GetCertsRenewCommand 获取 kubeadm certs renew 命令（使用配置文件中的证书有效期）
+func GetCertsRenewCommand(configFile string) string {
+	return fmt.Sprintf("kubeadm certs renew all --config %s", configFile)
+}
+
+// GetKubeletKubeconfigCommand 获取为节点生成 kubelet kubeconfig 的命令（Master 节点执行）
+func GetKubeletKubeconfigCommand(configFile, nodeName string) string {
+	return fmt.Sprintf("kubeadm kubeconfig user --config %s --org system:nodes --client-name system:node:%s", configFile, nodeName)
+}
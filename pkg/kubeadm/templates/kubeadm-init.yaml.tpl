@@ -5,13 +5,13 @@ imageRepository: {{.ImageRepository}}
 controlPlaneEndpoint: "{{.ControlPlaneEndpoint}}"
 clusterName: {{.ClusterName}}
 certificatesDir: /etc/kubernetes/pki
-caCertificateValidityPeriod: 876000h0m0s
-certificateValidityPeriod: 876000h0m0s
+caCertificateValidityPeriod: {{.CACertificateValidity}}
+certificateValidityPeriod: {{.CertificateValidity}}
 encryptionAlgorithm: RSA-2048
 networking:
   dnsDomain: cluster.local
-  podSubnet: {{.PodSubnet}}
-  serviceSubnet: {{.ServiceSubnet}}
+  podSubnet: "{{.PodSubnet}}"
+  serviceSubnet: "{{.ServiceSubnet}}"
 apiServer:
   certSANs:{{if .VIP}}
   - "{{.VIP}}"{{end}}
@@ -27,13 +27,23 @@ etcd:
 apiVersion: kubeadm.k8s.io/v1beta4
 kind: InitConfiguration
 localAPIEndpoint:
-  advertiseAddress: {{.LocalIP}}
+  advertiseAddress: "{{.LocalIP}}"
   bindPort: 6443
 nodeRegistration:
   criSocket: unix:///run/containerd/containerd.sock
+{{- if .Taints}}
+  taints:{{range .Taints}}
+  - key: "{{.Key}}"{{if .Value}}
+    value: "{{.Value}}"{{end}}
+    effect: {{.Effect}}{{end}}
+{{- end}}
   kubeletExtraArgs:
   - name: cgroup-driver
-    value: systemd
+    value: systemd{{if .NodeLabels}}
+  - name: node-labels
+    value: "{{.NodeLabels}}"{{end}}{{if .NodeIP}}
+  - name: node-ip
+    value: "{{.NodeIP}}"{{end}}
 ---
 apiVersion: kubelet.config.k8s.io/v1beta1
 kind: KubeletConfiguration
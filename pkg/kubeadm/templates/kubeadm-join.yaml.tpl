@@ -0,0 +1,34 @@
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: JoinConfiguration
+discovery:
+  bootstrapToken:
+    apiServerEndpoint: "{{.APIServerEndpoint}}"
+    token: "{{.Token}}"
+    caCertHashes:
+    - "{{.CACertHash}}"
+nodeRegistration:
+  criSocket: unix:///var/run/containerd/containerd.sock
+{{- if .Taints}}
+  taints:{{range .Taints}}
+  - key: "{{.Key}}"{{if .Value}}
+    value: "{{.Value}}"{{end}}
+    effect: {{.Effect}}{{end}}
+{{- end}}
+{{- if or .NodeLabels .NodeIP}}
+  kubeletExtraArgs:
+{{- if .NodeLabels}}
+  - name: node-labels
+    value: "{{.NodeLabels}}"
+{{- end}}
+{{- if .NodeIP}}
+  - name: node-ip
+    value: "{{.NodeIP}}"
+{{- end}}
+{{- end}}
+{{- if .CertificateKey}}
+controlPlane:
+  localAPIEndpoint:
+    advertiseAddress: "{{.AdvertiseAddress}}"
+    bindPort: 6443
+  certificateKey: "{{.CertificateKey}}"
+{{- end}}
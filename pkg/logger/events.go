@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+
+	"stormdragon/k8s-deployer/pkg/events"
+)
+
+// EventSink 将事件写入日志（需要先调用 InitLogger 或 InitFileLogger）
+// 错误和警告分别使用 ERROR/WARN 级别，命令执行结果使用 DEBUG 级别（失败的命令使用 WARN）
+type EventSink struct{}
+
+// NewEventSink 创建日志事件输出
+func NewEventSink() *EventSink {
+	return &EventSink{}
+}
+
+// Handle 写入一条日志
+func (s *EventSink) Handle(e events.Event) {
+	if Logger == nil {
+		return
+	}
+
+	fields := []zap.Field{zap.String("type", string(e.Type))}
+	if e.Phase != "" {
+		fields = append(fields, zap.String("phase", e.Phase))
+	}
+	if e.Node != "" {
+		fields = append(fields, zap.String("node", e.Node))
+	}
+	if e.Step != "" {
+		fields = append(fields, zap.String("step", e.Step))
+	}
+	if e.Status != "" {
+		fields = append(fields, zap.String("status", e.Status))
+	}
+	if e.Command != "" {
+		fields = append(fields, zap.String("command", e.Command))
+	}
+	if e.Error != "" {
+		fields = append(fields, zap.String("error", e.Error))
+	}
+	if e.Duration > 0 {
+		fields = append(fields, zap.Float64("durationSeconds", e.Duration))
+	}
+	if e.Type == events.Table {
+		fields = append(fields, zap.Strings("columns", e.Columns), zap.Any("rows", e.Rows))
+	}
+
+	msg := e.Message
+	if msg == "" {
+		msg = e.Command
+	}
+
+	switch {
+	case e.Type == events.Error, e.Type == events.PhaseFinished && e.Status == events.StatusFailed:
+		Logger.Error(msg, fields...)
+	case e.Type == events.Warning, e.Status == events.StatusFailed:
+		Logger.Warn(msg, fields...)
+	case e.Type == events.CommandResult:
+		Logger.Debug(msg, fields...)
+	default:
+		Logger.Info(msg, fields...)
+	}
+}
+
+// Close 刷新日志缓冲区
+func (s *EventSink) Close() error {
+	Sync()
+	return nil
+}
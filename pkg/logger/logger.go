@@ -53,26 +53,10 @@ func InitLogger(verbose bool, logFile string) error {
 
 	// 文件输出（如果指定）
 	if logFile != "" {
-		// 确保日志目录存在
-		logDir := filepath.Dir(logFile)
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			return err
-		}
-
-		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		fileCore, err := newFileCore(logFile, encoderConfig, level)
 		if err != nil {
 			return err
 		}
-
-		// 文件使用 JSON 格式
-		fileEncoderConfig := encoderConfig
-		fileEncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder // 文件不需要颜色
-		fileEncoder := zapcore.NewJSONEncoder(fileEncoderConfig)
-		fileCore := zapcore.NewCore(
-			fileEncoder,
-			zapcore.AddSync(file),
-			level,
-		)
 		cores = append(cores, fileCore)
 	}
 
@@ -86,6 +70,56 @@ func InitLogger(verbose bool, logFile string) error {
 	return nil
 }
 
+// InitFileLogger 初始化只输出到文件的日志（终端输出由 ui 负责）
+func InitFileLogger(logFile string, verbose bool) error {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+	}
+
+	level := zapcore.InfoLevel
+	if verbose {
+		level = zapcore.DebugLevel
+	}
+
+	core, err := newFileCore(logFile, encoderConfig, level)
+	if err != nil {
+		return err
+	}
+
+	Logger = zap.New(core)
+	SugaredLogger = Logger.Sugar()
+	return nil
+}
+
+// newFileCore 创建 JSON 格式的文件日志核心
+func newFileCore(logFile string, encoderConfig zapcore.EncoderConfig, level zapcore.Level) (zapcore.Core, error) {
+	// 确保日志目录存在
+	logDir := filepath.Dir(logFile)
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// 文件使用 JSON 格式
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder // 文件不需要颜色
+	return zapcore.NewCore(
+		zapcore.NewJSONEncoder(encoderConfig),
+		zapcore.AddSync(file),
+		level,
+	), nil
+}
+
 // Sync 刷新日志缓冲区
 func Sync() {
 	if Logger != nil {
@@ -4,61 +4,133 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// DefaultArch 默认架构
+const DefaultArch = "amd64"
+
+// SupportedArchs 支持的节点架构
+var SupportedArchs = []string{"amd64", "arm64"}
+
+// ArchFromMachine 将 uname -m 的输出转换为离线包使用的架构名（x86_64 -> amd64，aarch64 -> arm64）
+func ArchFromMachine(machine string) (string, error) {
+	switch strings.TrimSpace(machine) {
+	case "x86_64", "amd64":
+		return "amd64", nil
+	case "aarch64", "arm64":
+		return "arm64", nil
+	}
+	return "", fmt.Errorf("不支持的架构 %s（支持 %s）", strings.TrimSpace(machine), strings.Join(SupportedArchs, ", "))
+}
+
+// k8sComponents 按 Kubernetes 版本匹配的组件
+var k8sComponents = map[string]bool{"kubectl": true, "kubeadm": true, "kubelet": true}
+
 // Manager 包管理器
+// 离线包的路径、版本和镜像通过 packages/manifest.yaml 查找
 type Manager struct {
-	PackageDir string // packages 目录路径
-	K8sVersion string // Kubernetes 版本
+	PackageDir string    // packages 目录路径
+	K8sVersion string    // Kubernetes 版本（为空时使用清单中的第一个版本）
+	Arch       string    // 架构
+	Manifest   *Manifest // 离线包清单
 }
 
 // NewManager 创建包管理器
-func NewManager() *Manager {
-	// 获取当前工作目录
-	cwd, _ := os.Getwd()
-	return &Manager{
-		PackageDir: filepath.Join(cwd, "packages"),
-		K8sVersion: "v1.34.2", // 默认版本
-	}
+func NewManager() (*Manager, error) {
+	return NewManagerWithVersion("")
 }
 
 // NewManagerWithVersion 创建指定版本的包管理器
-func NewManagerWithVersion(k8sVersion string) *Manager {
+func NewManagerWithVersion(k8sVersion string) (*Manager, error) {
+	// 获取当前工作目录
 	cwd, _ := os.Getwd()
+	return NewManagerForDir(filepath.Join(cwd, "packages"), k8sVersion)
+}
+
+// NewManagerForDir 使用指定的 packages 目录创建包管理器
+func NewManagerForDir(packageDir, k8sVersion string) (*Manager, error) {
+	manifest, err := LoadManifest(filepath.Join(packageDir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
 	return &Manager{
-		PackageDir: filepath.Join(cwd, "packages"),
+		PackageDir: packageDir,
 		K8sVersion: k8sVersion,
+		Arch:       DefaultArch,
+		Manifest:   manifest,
+	}, nil
+}
+
+// ForArch 返回使用指定架构的包管理器（共用离线包清单）
+func (m *Manager) ForArch(arch string) *Manager {
+	c := *m
+	c.Arch = arch
+	return &c
+}
+
+// Artifact 查找组件在清单中的条目，不存在时返回 nil
+func (m *Manager) Artifact(pkgName string) *Artifact {
+	version := ""
+	if k8sComponents[pkgName] {
+		version = m.K8sVersion
 	}
+	return m.Manifest.Find(pkgName, version, m.Arch)
 }
 
-// GetPackagePath 获取包的完整路径
+// Artifacts 返回当前 Kubernetes 版本和架构使用的所有条目
+func (m *Manager) Artifacts() []Artifact {
+	var artifacts []Artifact
+	for _, a := range m.Manifest.Artifacts {
+		if a.Arch != "" && a.Arch != m.Arch {
+			continue
+		}
+		if k8sComponents[a.Name] && m.K8sVersion != "" && a.Version != m.K8sVersion {
+			continue
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts
+}
+
+// GetPackagePath 获取包的完整路径（清单中没有该组件时返回空字符串）
 func (m *Manager) GetPackagePath(pkgName string) string {
-	var relPath string
-
-	switch pkgName {
-	case "containerd":
-		relPath = "containerd/containerd-2.2.0-linux-amd64.tar.gz"
-	case "runc":
-		relPath = "containerd/runc.amd64"
-	case "cni-plugins":
-		relPath = "containerd/cni-plugins-linux-amd64-v1.8.0.tgz"
-	case "kubectl":
-		relPath = fmt.Sprintf("kubernetes/%s/kubectl", m.K8sVersion)
-	case "kubeadm":
-		relPath = fmt.Sprintf("kubernetes/%s/kubeadm", m.K8sVersion)
-	case "kubelet":
-		relPath = fmt.Sprintf("kubernetes/%s/kubelet", m.K8sVersion)
-	case "helm":
-		relPath = "helm/linux-amd64/helm"
-	case "cilium-chart":
-		relPath = "cilium/cilium-1.18.4.tgz"
-	case "metallb-chart":
-		relPath = "metallb/metallb-0.15.2.tgz"
-	default:
+	a := m.Artifact(pkgName)
+	if a == nil {
 		return ""
 	}
+	return filepath.Join(m.PackageDir, a.Path)
+}
 
-	return filepath.Join(m.PackageDir, relPath)
+// Images 返回组件需要的镜像（名称 -> 名称:tag）
+func (m *Manager) Images(pkgName string) map[string]string {
+	images := make(map[string]string)
+	if a := m.Artifact(pkgName); a != nil {
+		for _, image := range a.Images {
+			images[image.Name] = image.Ref()
+		}
+	}
+	return images
+}
+
+// Image 返回组件需要的指定镜像
+func (m *Manager) Image(pkgName, imageName string) (Image, bool) {
+	if a := m.Artifact(pkgName); a != nil {
+		for _, image := range a.Images {
+			if image.Name == imageName {
+				return image, true
+			}
+		}
+	}
+	return Image{}, false
+}
+
+// Version 返回组件的版本（清单中没有该组件时返回空字符串）
+func (m *Manager) Version(pkgName string) string {
+	if a := m.Artifact(pkgName); a != nil {
+		return a.Version
+	}
+	return ""
 }
 
 // Exists 检查包是否存在
@@ -88,10 +160,14 @@ func (m *Manager) CheckRequiredPackages(required []string) []string {
 func (m *Manager) ListAvailable() []string {
 	var available []string
 
-	pkgNames := []string{"containerd", "runc", "cni-plugins", "kubectl", "kubeadm", "kubelet", "helm"}
-	for _, name := range pkgNames {
-		if m.Exists(name) {
-			available = append(available, name)
+	seen := make(map[string]bool)
+	for _, a := range m.Manifest.Artifacts {
+		if seen[a.Name] {
+			continue
+		}
+		seen[a.Name] = true
+		if m.Exists(a.Name) {
+			available = append(available, a.Name)
 		}
 	}
 
@@ -0,0 +1,131 @@
+package packages
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile 离线包清单文件名（位于 packages 目录）
+const ManifestFile = "manifest.yaml"
+
+// ImagesDir 镜像 tar 包目录（相对于 packages 目录）
+const ImagesDir = "images"
+
+// SystemDir 系统软件包离线源目录（相对于 packages 目录），按发行版分子目录，如 system/ubuntu-24.04、system/el9
+const SystemDir = "system"
+
+// Manifest 离线包清单
+type Manifest struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Artifacts  []Artifact `yaml:"artifacts"`
+}
+
+// Artifact 离线包中的一个文件
+type Artifact struct {
+	Name     string  `yaml:"name"`
+	Version  string  `yaml:"version,omitempty"`
+	Arch     string  `yaml:"arch,omitempty"` // 为空表示与架构无关（如 Helm chart）
+	Path     string  `yaml:"path"`           // 相对于 packages 目录
+	SHA256   string  `yaml:"sha256,omitempty"`
+	Optional bool    `yaml:"optional,omitempty"`
+	Images   []Image `yaml:"images,omitempty"`
+}
+
+// Image 组件需要的镜像
+type Image struct {
+	Name   string `yaml:"name"`             // 镜像仓库（spec.imageRepository）中的名称
+	Tag    string `yaml:"tag"`              // 镜像 tag
+	Source string `yaml:"source,omitempty"` // 上游镜像（同步镜像时使用）
+}
+
+// Ref 返回镜像仓库中的镜像名称和 tag（如 cilium:v1.18.4）
+func (i Image) Ref() string {
+	return i.Name + ":" + i.Tag
+}
+
+// ArchiveName 返回镜像 tar 包（docker-archive 格式）的文件名
+func (i Image) ArchiveName() string {
+	return i.Name + "_" + i.Tag + ".tar"
+}
+
+// ArchivePath 返回镜像 tar 包相对于 packages 目录的路径
+func (i Image) ArchivePath() string {
+	return filepath.Join(ImagesDir, i.ArchiveName())
+}
+
+// LoadManifest 读取离线包清单
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取离线包清单失败: %w", err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析离线包清单 %s 失败: %w", path, err)
+	}
+	for i, a := range manifest.Artifacts {
+		if a.Name == "" || a.Path == "" {
+			return nil, fmt.Errorf("离线包清单 %s 第 %d 项缺少 name 或 path", path, i+1)
+		}
+	}
+	return &manifest, nil
+}
+
+// Find 查找组件的文件，version 或 arch 为空时不按其过滤
+func (m *Manifest) Find(name, version, arch string) *Artifact {
+	for i := range m.Artifacts {
+		a := &m.Artifacts[i]
+		if a.Name != name {
+			continue
+		}
+		if version != "" && a.Version != version {
+			continue
+		}
+		if arch != "" && a.Arch != "" && a.Arch != arch {
+			continue
+		}
+		return a
+	}
+	return nil
+}
+
+// Merge 将 other 中的条目合并到清单：名称、版本和架构都相同的条目被替换，其余条目追加到末尾
+func (m *Manifest) Merge(other *Manifest) {
+	for _, a := range other.Artifacts {
+		replaced := false
+		for i := range m.Artifacts {
+			e := &m.Artifacts[i]
+			if e.Name == a.Name && e.Version == a.Version && e.Arch == a.Arch {
+				*e = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			m.Artifacts = append(m.Artifacts, a)
+		}
+	}
+}
+
+// Save 写入离线包清单（与 packages/manifest.yaml 一样使用两个空格缩进）
+func (m *Manifest) Save(path string) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入离线包清单失败: %w", err)
+	}
+	return nil
+}
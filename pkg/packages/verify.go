@@ -0,0 +1,164 @@
+package packages
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 校验状态
+const (
+	VerifyOK        = "ok"        // 文件存在且 sha256 一致
+	VerifyUnchecked = "unchecked" // 文件存在，清单中没有记录 sha256
+	VerifyMissing   = "missing"   // 文件不存在
+	VerifyMismatch  = "mismatch"  // sha256 不一致
+)
+
+// VerifyResult 单个文件的校验结果
+type VerifyResult struct {
+	Artifact Artifact
+	Status   string
+	Size     int64
+	SHA256   string // 实际的 sha256（文件存在时）
+}
+
+// Verify 校验清单中所有文件是否存在以及 sha256 是否与清单一致
+func (m *Manager) Verify() []VerifyResult {
+	results := make([]VerifyResult, 0, len(m.Manifest.Artifacts))
+	for _, a := range m.Manifest.Artifacts {
+		results = append(results, m.verifyArtifact(a))
+	}
+	return results
+}
+
+// VerifyPackages 校验当前 Kubernetes 版本和架构下指定组件的文件（清单中没有的组件不返回结果）
+func (m *Manager) VerifyPackages(pkgNames []string) []VerifyResult {
+	var results []VerifyResult
+	for _, name := range pkgNames {
+		if a := m.Artifact(name); a != nil {
+			results = append(results, m.verifyArtifact(*a))
+		}
+	}
+	return results
+}
+
+// verifyArtifact 校验单个文件
+func (m *Manager) verifyArtifact(a Artifact) VerifyResult {
+	result := VerifyResult{Artifact: a, Status: VerifyMissing}
+
+	path := filepath.Join(m.PackageDir, a.Path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return result
+	}
+	result.Size = info.Size()
+	sum, err := FileSHA256(path)
+	if err != nil {
+		return result
+	}
+	result.SHA256 = sum
+	switch {
+	case a.SHA256 == "":
+		result.Status = VerifyUnchecked
+	case strings.EqualFold(a.SHA256, sum):
+		result.Status = VerifyOK
+	default:
+		result.Status = VerifyMismatch
+	}
+	return result
+}
+
+// FileSHA256 计算文件的 sha256
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+var (
+	itemLine   = regexp.MustCompile(`^\s*-\s+name:`)
+	pathLine   = regexp.MustCompile(`^(\s+)path:\s*"?([^"\s]+)"?\s*$`)
+	sha256Line = regexp.MustCompile(`^(\s+)sha256:\s*("")?\s*$`)
+)
+
+// WriteChecksums 将 sha256 写入清单中没有记录 sha256 的条目（sums 以 path 为键）
+// 按行修改，保留清单中的注释和格式；已记录的 sha256 不会被覆盖
+func WriteChecksums(manifestPath string, sums map[string]string) (int, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(string(data), "\n")
+
+	// 按条目（"- name:" 开始）分组处理
+	var out []string
+	updated := 0
+	flush := func(item []string) {
+		path, pathIdx, shaIdx := "", -1, -1
+		indent := ""
+		for i, line := range item {
+			if m := pathLine.FindStringSubmatch(line); m != nil {
+				path, pathIdx, indent = m[2], i, m[1]
+			} else if sha256Line.MatchString(line) {
+				shaIdx = i
+			}
+		}
+		sum, ok := sums[path]
+		if pathIdx < 0 || !ok {
+			out = append(out, item...)
+			return
+		}
+		if shaIdx >= 0 {
+			item[shaIdx] = fmt.Sprintf("%ssha256: %s", sha256Line.FindStringSubmatch(item[shaIdx])[1], sum)
+		} else if !hasSHA256(item) {
+			item = append(item[:pathIdx+1], append([]string{fmt.Sprintf("%ssha256: %s", indent, sum)}, item[pathIdx+1:]...)...)
+		} else {
+			out = append(out, item...)
+			return
+		}
+		updated++
+		out = append(out, item...)
+	}
+
+	var item []string
+	for _, line := range lines {
+		if itemLine.MatchString(line) {
+			flush(item)
+			item = nil
+		}
+		if item == nil && !itemLine.MatchString(line) {
+			out = append(out, line)
+			continue
+		}
+		item = append(item, line)
+	}
+	flush(item)
+
+	if updated == 0 {
+		return 0, nil
+	}
+	return updated, os.WriteFile(manifestPath, []byte(strings.Join(out, "\n")), 0644)
+}
+
+// hasSHA256 条目中是否已有非空的 sha256
+func hasSHA256(item []string) bool {
+	for _, line := range item {
+		if strings.HasPrefix(strings.TrimSpace(line), "sha256:") {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,8 @@ package ui
 import (
 	"fmt"
 	"sync"
-	"time"
+
+	"stormdragon/k8s-deployer/pkg/events"
 )
 
 // NodeProgress 节点进度跟踪
@@ -124,70 +125,25 @@ func (t *ConcurrentProgressTracker) Finish() {
 }
 
 // SimpleProgressLogger 简化的进度日志（不需要复杂的终端控制）
-type SimpleProgressLogger struct {
-	nodePrefix map[string]string
-	mu         sync.Mutex
-}
+// 日志作为 node.step 事件发布，终端中每个节点使用固定的颜色
+type SimpleProgressLogger struct{}
 
 // NewSimpleProgressLogger 创建简化进度日志
 func NewSimpleProgressLogger(nodeNames []string) *SimpleProgressLogger {
-	logger := &SimpleProgressLogger{
-		nodePrefix: make(map[string]string),
-	}
-	
-	// 为每个节点分配颜色前缀
-	colors := []string{
-		"\033[36m", // 青色
-		"\033[33m", // 黄色
-		"\033[32m", // 绿色
-		"\033[35m", // 紫色
-		"\033[34m", // 蓝色
-		"\033[31m", // 红色
-		"\033[37m", // 白色
-		"\033[90m", // 灰色
-	}
-	
-	for i, name := range nodeNames {
-		color := colors[i%len(colors)]
-		logger.nodePrefix[name] = color
-	}
-	
-	return logger
+	return &SimpleProgressLogger{}
 }
 
 // Log 记录节点日志
 func (l *SimpleProgressLogger) Log(nodeName, message string) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	
-	color := l.nodePrefix[nodeName]
-	reset := "\033[0m"
-	timestamp := time.Now().Format("15:04:05")
-	
-	fmt.Printf("%s[%s] %-20s%s | %s\n", color, timestamp, nodeName, reset, message)
+	events.Publish(events.Event{Type: events.NodeStep, Node: nodeName, Status: events.StatusRunning, Message: message})
 }
 
 // Success 记录成功
 func (l *SimpleProgressLogger) Success(nodeName, message string) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	
-	green := "\033[32m"
-	reset := "\033[0m"
-	timestamp := time.Now().Format("15:04:05")
-	
-	fmt.Printf("%s[%s] %-20s%s | ✓ %s\n", green, timestamp, nodeName, reset, message)
+	events.Publish(events.Event{Type: events.NodeStep, Node: nodeName, Status: events.StatusSucceeded, Message: message})
 }
 
 // Error 记录错误
 func (l *SimpleProgressLogger) Error(nodeName, message string) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	
-	red := "\033[31m"
-	reset := "\033[0m"
-	timestamp := time.Now().Format("15:04:05")
-	
-	fmt.Printf("%s[%s] %-20s%s | ✗ %s\n", red, timestamp, nodeName, reset, message)
+	events.Publish(events.Event{Type: events.NodeStep, Node: nodeName, Status: events.StatusFailed, Message: message})
 }
-
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"stormdragon/k8s-deployer/pkg/events"
+)
+
+// 节点日志的颜色（按节点第一次出现的顺序分配）
+var nodeColors = []string{
+	"\033[36m", // 青色
+	"\033[33m", // 黄色
+	"\033[32m", // 绿色
+	"\033[35m", // 紫色
+	"\033[34m", // 蓝色
+	"\033[31m", // 红色
+	"\033[37m", // 白色
+	"\033[90m", // 灰色
+}
+
+// interactiveOut 确认提示、进度条和 spinner 的输出
+// 以 JSON 输出到 stdout 时切换到 stderr，避免混入事件流
+var interactiveOut io.Writer = os.Stdout
+
+// SetInteractiveOutput 设置确认提示、进度条和 spinner 的输出
+func SetInteractiveOutput(w io.Writer) {
+	interactiveOut = w
+}
+
+// 默认输出到终端，CLI 根据 --output 等参数重新设置 sink
+func init() {
+	events.SetSinks(NewConsoleSink(false))
+}
+
+// ConsoleSink 以彩色文本在终端输出事件（默认输出）
+type ConsoleSink struct {
+	mu         sync.Mutex
+	verbose    bool
+	nodeColors map[string]string
+	// 子步骤 "  → msg" 已输出但还没有输出结果（✓/✗）
+	subStepOpen bool
+	// 子步骤输出期间插入了其他输出，结果需要和子步骤消息一起重新输出
+	subStepBroken bool
+}
+
+// NewConsoleSink 创建终端输出，verbose 为 true 时同时显示每条命令的执行结果
+func NewConsoleSink(verbose bool) *ConsoleSink {
+	return &ConsoleSink{verbose: verbose, nodeColors: make(map[string]string)}
+}
+
+// Handle 输出事件
+// 阶段事件只用于 JSON 和日志文件，终端中不显示
+func (s *ConsoleSink) Handle(e events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case e.Type == events.PhaseStarted, e.Type == events.PhaseFinished:
+		return
+	case e.Type == events.CommandResult && !s.verbose:
+		return
+	}
+	if s.subStepOpen && e.Type != events.SubStep {
+		fmt.Println()
+		s.subStepOpen, s.subStepBroken = false, true
+	}
+
+	switch e.Type {
+	case events.Header:
+		printHeader(e.Message)
+	case events.Step:
+		ColorBold.Printf("\n[%d/%d] %s\n", e.Current, e.Total, e.Message)
+	case events.SubStep:
+		if e.Status != events.StatusStarted && s.subStepBroken {
+			fmt.Printf("  → %s", e.Message)
+		}
+		switch e.Status {
+		case events.StatusSucceeded:
+			ColorSuccess.Println(" ✓")
+		case events.StatusFailed:
+			ColorError.Println(" ✗")
+		default:
+			fmt.Printf("  → %s", e.Message)
+		}
+		s.subStepOpen, s.subStepBroken = e.Status == events.StatusStarted, false
+	case events.NodeStep:
+		s.printNodeStep(e)
+	case events.Info:
+		ColorInfo.Printf("[信息] %s\n", e.Message)
+	case events.Success:
+		ColorSuccess.Printf("✓ %s\n", e.Message)
+	case events.Warning:
+		ColorWarning.Printf("[警告] %s\n", e.Message)
+	case events.Error:
+		ColorError.Fprintf(os.Stderr, "✗ 错误: %s\n", e.Message)
+	case events.Table:
+		table := newTableWriter(os.Stdout, e.Columns)
+		table.AppendBulk(e.Rows)
+		table.Render()
+	case events.Text:
+		fmt.Println(e.Message)
+	case events.CommandResult:
+		printCommandResult(e)
+	}
+}
+
+// printCommandResult 以灰色输出命令执行结果（--verbose）
+func printCommandResult(e events.Event) {
+	gray, reset := "\033[90m", "\033[0m"
+	if e.Status == events.StatusFailed {
+		gray = "\033[31m"
+	}
+	fmt.Printf("%s    [%s] $ %s (%.1fs)%s\n", gray, e.Node, e.Command, e.Duration, reset)
+}
+
+// printNodeStep 输出并发操作中的节点日志，每个节点使用固定的颜色
+func (s *ConsoleSink) printNodeStep(e events.Event) {
+	reset := "\033[0m"
+	timestamp := e.Time.Format("15:04:05")
+
+	switch e.Status {
+	case events.StatusSucceeded:
+		fmt.Printf("%s[%s] %-20s%s | ✓ %s\n", "\033[32m", timestamp, e.Node, reset, e.Message)
+	case events.StatusFailed:
+		fmt.Printf("%s[%s] %-20s%s | ✗ %s\n", "\033[31m", timestamp, e.Node, reset, e.Message)
+	default:
+		color, ok := s.nodeColors[e.Node]
+		if !ok {
+			color = nodeColors[len(s.nodeColors)%len(nodeColors)]
+			s.nodeColors[e.Node] = color
+		}
+		fmt.Printf("%s[%s] %-20s%s | %s\n", color, timestamp, e.Node, reset, e.Message)
+	}
+}
+
+// printHeader 打印大标题
+func printHeader(text string) {
+	width := 60
+	fmt.Println()
+	fmt.Println(strings.Repeat("=", width))
+	padding := (width - len(text)) / 2
+	fmt.Printf("%s%s\n", strings.Repeat(" ", padding), text)
+	fmt.Println(strings.Repeat("=", width))
+	fmt.Println()
+}
@@ -2,10 +2,12 @@ package ui
 
 import (
 	"fmt"
-	"os"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
+
+	"stormdragon/k8s-deployer/pkg/events"
 )
 
 var (
@@ -17,22 +19,25 @@ var (
 	ColorBold    = color.New(color.Bold)
 )
 
+var (
+	// 当前子步骤的消息，用于子步骤完成/失败事件
+	subStepMu  sync.Mutex
+	subStepMsg string
+)
+
 // Info 打印信息消息
 func Info(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	ColorInfo.Printf("[信息] %s\n", msg)
+	events.Publish(events.Event{Type: events.Info, Message: fmt.Sprintf(format, args...)})
 }
 
 // Success 打印成功消息
 func Success(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	ColorSuccess.Printf("✓ %s\n", msg)
+	events.Publish(events.Event{Type: events.Success, Message: fmt.Sprintf(format, args...)})
 }
 
 // Warning 打印警告消息
 func Warning(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	ColorWarning.Printf("[警告] %s\n", msg)
+	events.Publish(events.Event{Type: events.Warning, Message: fmt.Sprintf(format, args...)})
 }
 
 // Warn 打印警告消息（Warning 的别名）
@@ -42,8 +47,7 @@ func Warn(format string, args ...interface{}) {
 
 // Error 打印错误消息
 func Error(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	ColorError.Fprintf(os.Stderr, "✗ 错误: %s\n", msg)
+	events.Publish(events.Event{Type: events.Error, Message: fmt.Sprintf(format, args...)})
 }
 
 // Confirm 询问用户确认（WaitForConfirmation 的别名）
@@ -53,53 +57,67 @@ func Confirm(message string) bool {
 
 // Step 打印步骤信息
 func Step(current, total int, format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	ColorBold.Printf("\n[%d/%d] %s\n", current, total, msg)
+	events.Publish(events.Event{
+		Type:    events.Step,
+		Current: current,
+		Total:   total,
+		Message: fmt.Sprintf(format, args...),
+	})
 }
 
 // SubStep 打印子步骤信息
 func SubStep(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Printf("  → %s", msg)
+	subStepMu.Lock()
+	subStepMsg = msg
+	subStepMu.Unlock()
+	events.Publish(events.Event{Type: events.SubStep, Status: events.StatusStarted, Message: msg})
 }
 
 // SubStepDone 子步骤完成
 func SubStepDone() {
-	ColorSuccess.Println(" ✓")
+	finishSubStep(events.StatusSucceeded)
 }
 
 // SubStepFailed 子步骤失败
 func SubStepFailed() {
-	ColorError.Println(" ✗")
+	finishSubStep(events.StatusFailed)
+}
+
+func finishSubStep(status string) {
+	subStepMu.Lock()
+	msg := subStepMsg
+	subStepMsg = ""
+	subStepMu.Unlock()
+	events.Publish(events.Event{Type: events.SubStep, Status: status, Message: msg})
 }
 
 // Title 打印标题
 func Title(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Println()
-	ColorBold.Println(msg)
-	fmt.Println(strings.Repeat("=", len(msg)))
+	Text("")
+	Text("%s", msg)
+	Text("%s", strings.Repeat("=", len(msg)))
+}
+
+// Text 原样打印一行文本（命令输出、摘要等）
+func Text(format string, args ...interface{}) {
+	events.Publish(events.Event{Type: events.Text, Message: fmt.Sprintf(format, args...)})
 }
 
 // Divider 打印分隔线
 func Divider() {
-	fmt.Println(strings.Repeat("-", 60))
+	Text("%s", strings.Repeat("-", 60))
 }
 
 // Header 打印大标题
 func Header(text string) {
-	width := 60
-	fmt.Println()
-	fmt.Println(strings.Repeat("=", width))
-	padding := (width - len(text)) / 2
-	fmt.Printf("%s%s\n", strings.Repeat(" ", padding), text)
-	fmt.Println(strings.Repeat("=", width))
-	fmt.Println()
+	events.Publish(events.Event{Type: events.Header, Message: text})
 }
 
 // WaitForConfirmation 等待用户确认（默认为是）
 func WaitForConfirmation(message string) bool {
-	fmt.Printf("%s [Y/n]: ", message)
+	fmt.Fprintf(interactiveOut, "%s [Y/n]: ", message)
 	var response string
 	fmt.Scanln(&response)
 	// 空输入（直接回车）默认为 yes
@@ -111,8 +129,8 @@ func WaitForConfirmation(message string) bool {
 
 // WaitForDangerousConfirmation 等待用户确认危险操作（必须明确输入yes）
 func WaitForDangerousConfirmation(message string) bool {
-	ColorWarning.Printf("⚠️  %s\n", message)
-	ColorWarning.Printf("请输入 'yes' 确认操作: ")
+	ColorWarning.Fprintf(interactiveOut, "⚠️  %s\n", message)
+	ColorWarning.Fprintf(interactiveOut, "请输入 'yes' 确认操作: ")
 	var response string
 	fmt.Scanln(&response)
 	return response == "yes"
@@ -121,13 +139,12 @@ func WaitForDangerousConfirmation(message string) bool {
 // PrintClusterInfo 打印集群信息
 func PrintClusterInfo(name, version string, masters, workers, gpuNodes int) {
 	Header("集群信息")
-	fmt.Printf("  名称: %s\n", name)
-	fmt.Printf("  版本: %s\n", version)
-	fmt.Printf("  Master 节点: %d\n", masters)
-	fmt.Printf("  Worker 节点: %d\n", workers)
+	Text("  名称: %s", name)
+	Text("  版本: %s", version)
+	Text("  Master 节点: %d", masters)
+	Text("  Worker 节点: %d", workers)
 	if gpuNodes > 0 {
-		fmt.Printf("  GPU 节点: %d\n", gpuNodes)
+		Text("  GPU 节点: %d", gpuNodes)
 	}
 	Divider()
 }
-
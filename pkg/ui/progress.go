@@ -10,7 +10,7 @@ import (
 
 // NewSpinner 创建一个新的 spinner
 func NewSpinner(message string) *spinner.Spinner {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(interactiveOut))
 	s.Suffix = " " + message
 	return s
 }
@@ -33,6 +33,7 @@ func StartSpinner(message string) func(bool) {
 // NewProgressBar 创建新的进度条
 func NewProgressBar(max int, description string) *progressbar.ProgressBar {
 	return progressbar.NewOptions(max,
+		progressbar.OptionSetWriter(interactiveOut),
 		progressbar.OptionSetDescription(description),
 		progressbar.OptionSetWidth(50),
 		progressbar.OptionShowCount(),
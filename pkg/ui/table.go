@@ -1,14 +1,37 @@
 package ui
 
 import (
-	"os"
+	"io"
 
 	"github.com/olekukonko/tablewriter"
+
+	"stormdragon/k8s-deployer/pkg/events"
 )
 
+// Table 表格，Render 时作为 table 事件输出
+type Table struct {
+	headers []string
+	rows    [][]string
+}
+
 // NewTable 创建一个新的表格
-func NewTable(headers []string) *tablewriter.Table {
-	table := tablewriter.NewWriter(os.Stdout)
+func NewTable(headers []string) *Table {
+	return &Table{headers: headers}
+}
+
+// Append 添加一行
+func (t *Table) Append(row []string) {
+	t.rows = append(t.rows, row)
+}
+
+// Render 输出表格
+func (t *Table) Render() {
+	events.Publish(events.Event{Type: events.Table, Columns: t.headers, Rows: t.rows})
+}
+
+// newTableWriter 创建终端表格
+func newTableWriter(w io.Writer, headers []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
 	table.SetHeader(headers)
 	table.SetBorder(true)
 	table.SetRowLine(false)
@@ -51,4 +74,3 @@ func PrintNodeTable(nodes [][]string) {
 	}
 	table.Render()
 }
-
@@ -1,6 +1,6 @@
 #!/bin/bash
 # 下载所有部署所需的软件包
-# 使用方法: ./download-all.sh --k8s-version v1.34.2
+# 使用方法: ./download-all.sh --k8s-version v1.34.2 [--arch arm64]
 
 set -e
 
@@ -10,6 +10,7 @@ CONTAINERD_VERSION="2.2.0"
 CNI_VERSION="v1.8.0"  # 最新稳定版
 HELM_VERSION="v4.0.0"  # Helm 4.0 正式版 - 支持 WASM 插件、Server-side apply 等
 RUNC_VERSION="v1.3.3"  # 修复高危安全漏洞 CVE-2025-31133, CVE-2025-52565, CVE-2025-52881
+ARCH="amd64"           # 节点架构：amd64 / arm64（混合架构集群每个架构运行一次）
 
 # 解析参数
 while [[ $# -gt 0 ]]; do
@@ -22,6 +23,10 @@ while [[ $# -gt 0 ]]; do
             CONTAINERD_VERSION="$2"
             shift 2
             ;;
+        --arch)
+            ARCH="$2"
+            shift 2
+            ;;
         *)
             echo "未知参数: $1"
             exit 1
@@ -29,22 +34,37 @@ while [[ $# -gt 0 ]]; do
     esac
 done
 
+case "$ARCH" in
+    amd64|arm64) ;;
+    *)
+        echo "不支持的架构: $ARCH（支持 amd64、arm64）"
+        exit 1
+        ;;
+esac
+
 # 项目根目录
 PROJECT_ROOT="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
 PACKAGE_DIR="$PROJECT_ROOT/packages"
 
+# Kubernetes 组件目录（amd64 保持原有目录，其他架构放在架构子目录）
+K8S_DIR="kubernetes/${K8S_VERSION}"
+if [ "$ARCH" != "amd64" ]; then
+    K8S_DIR="$K8S_DIR/$ARCH"
+fi
+
 echo "============================================"
 echo "  下载部署包"
 echo "============================================"
 echo ""
 echo "Kubernetes 版本: $K8S_VERSION"
+echo "架构: $ARCH"
 echo "containerd 版本: $CONTAINERD_VERSION"
 echo "CNI 版本: $CNI_VERSION"
 echo "Helm 版本: $HELM_VERSION"
 echo ""
 
 # 创建目录
-mkdir -p "$PACKAGE_DIR"/{containerd,kubernetes/$K8S_VERSION,helm,cilium,gpu,system}
+mkdir -p "$PACKAGE_DIR"/{containerd,$K8S_DIR,helm,cilium,gpu,system}
 
 # 下载函数
 download_file() {
@@ -67,30 +87,30 @@ download_file() {
 # 1. 下载 containerd
 echo "1. 下载 containerd..."
 download_file \
-    "https://github.com/containerd/containerd/releases/download/v${CONTAINERD_VERSION}/containerd-${CONTAINERD_VERSION}-linux-amd64.tar.gz" \
-    "$PACKAGE_DIR/containerd/containerd-${CONTAINERD_VERSION}-linux-amd64.tar.gz"
+    "https://github.com/containerd/containerd/releases/download/v${CONTAINERD_VERSION}/containerd-${CONTAINERD_VERSION}-linux-${ARCH}.tar.gz" \
+    "$PACKAGE_DIR/containerd/containerd-${CONTAINERD_VERSION}-linux-${ARCH}.tar.gz"
 
 # 2. 下载 runc
 echo "2. 下载 runc..."
 download_file \
-    "https://github.com/opencontainers/runc/releases/download/${RUNC_VERSION}/runc.amd64" \
-    "$PACKAGE_DIR/containerd/runc.amd64"
+    "https://github.com/opencontainers/runc/releases/download/${RUNC_VERSION}/runc.${ARCH}" \
+    "$PACKAGE_DIR/containerd/runc.${ARCH}"
 
 # 3. 下载 CNI plugins
 echo "3. 下载 CNI plugins..."
 download_file \
-    "https://github.com/containernetworking/plugins/releases/download/${CNI_VERSION}/cni-plugins-linux-amd64-${CNI_VERSION}.tgz" \
-    "$PACKAGE_DIR/containerd/cni-plugins-linux-amd64-${CNI_VERSION}.tgz"
+    "https://github.com/containernetworking/plugins/releases/download/${CNI_VERSION}/cni-plugins-linux-${ARCH}-${CNI_VERSION}.tgz" \
+    "$PACKAGE_DIR/containerd/cni-plugins-linux-${ARCH}-${CNI_VERSION}.tgz"
 
 # 4. 下载 Kubernetes 组件
 echo "4. 下载 Kubernetes 组件 ($K8S_VERSION)..."
-K8S_BASE_URL="https://dl.k8s.io/release/${K8S_VERSION}/bin/linux/amd64"
+K8S_BASE_URL="https://dl.k8s.io/release/${K8S_VERSION}/bin/linux/${ARCH}"
 
 for component in kubectl kubeadm kubelet; do
     download_file \
         "${K8S_BASE_URL}/${component}" \
-        "$PACKAGE_DIR/kubernetes/${K8S_VERSION}/${component}"
-    chmod +x "$PACKAGE_DIR/kubernetes/${K8S_VERSION}/${component}"
+        "$PACKAGE_DIR/${K8S_DIR}/${component}"
+    chmod +x "$PACKAGE_DIR/${K8S_DIR}/${component}"
 done
 
 # 下载 kubelet.service
@@ -100,13 +120,13 @@ download_file \
 
 # 5. 下载 Helm
 echo "5. 下载 Helm..."
-HELM_ARCHIVE="$PACKAGE_DIR/helm/helm-${HELM_VERSION}-linux-amd64.tar.gz"
+HELM_ARCHIVE="$PACKAGE_DIR/helm/helm-${HELM_VERSION}-linux-${ARCH}.tar.gz"
 download_file \
-    "https://get.helm.sh/helm-${HELM_VERSION}-linux-amd64.tar.gz" \
+    "https://get.helm.sh/helm-${HELM_VERSION}-linux-${ARCH}.tar.gz" \
     "$HELM_ARCHIVE"
 
 # 解压 Helm（如果还没解压）
-if [ ! -f "$PACKAGE_DIR/helm/linux-amd64/helm" ]; then
+if [ ! -f "$PACKAGE_DIR/helm/linux-${ARCH}/helm" ]; then
     echo "  → 解压 Helm..."
     tar -xzf "$HELM_ARCHIVE" -C "$PACKAGE_DIR/helm/"
     echo "  ✓ Helm 解压完成"
@@ -119,13 +139,71 @@ download_file \
     "https://helm.cilium.io/cilium-${CILIUM_CHART_VERSION}.tgz" \
     "$PACKAGE_DIR/cilium/cilium-${CILIUM_CHART_VERSION}.tgz"
 
-# 7. 下载 Cilium CLI (可选，用于调试)
-echo "7. 下载 Cilium CLI..."
-CILIUM_CLI_VERSION=$(curl -s https://raw.githubusercontent.com/cilium/cilium-cli/main/stable.txt)
+# 7. 下载 MetalLB Helm Chart
+echo "7. 下载 MetalLB Helm Chart..."
+METALLB_CHART_VERSION="0.15.2"
+mkdir -p "$PACKAGE_DIR/metallb"
 download_file \
-    "https://github.com/cilium/cilium-cli/releases/download/${CILIUM_CLI_VERSION}/cilium-linux-amd64.tar.gz" \
-    "$PACKAGE_DIR/cilium/cilium-linux-amd64.tar.gz"
+    "https://github.com/metallb/metallb/releases/download/metallb-chart-${METALLB_CHART_VERSION}/metallb-${METALLB_CHART_VERSION}.tgz" \
+    "$PACKAGE_DIR/metallb/metallb-${METALLB_CHART_VERSION}.tgz"
 
+# 8. 下载 Cilium CLI (可选，用于调试)
+echo "8. 下载 Cilium CLI..."
+CILIUM_CLI_VERSION=$(curl -s https://raw.githubusercontent.com/cilium/cilium-cli/main/stable.txt)
+download_file \
+    "https://github.com/cilium/cilium-cli/releases/download/${CILIUM_CLI_VERSION}/cilium-linux-${ARCH}.tar.gz" \
+    "$PACKAGE_DIR/cilium/cilium-linux-${ARCH}.tar.gz"
+
+# 9. 清单中没有的 Kubernetes 版本或架构，追加到 packages/manifest.yaml
+MANIFEST="$PACKAGE_DIR/manifest.yaml"
+add_manifest_entry() {
+    local name=$1 version=$2 path=$3 extra=$4
+    if grep -q "path: ${path}\$" "$MANIFEST"; then
+        return 0
+    fi
+    cat >> "$MANIFEST" <<EOF
+
+  - name: ${name}
+    version: ${version}
+    arch: ${ARCH}
+    path: ${path}
+    sha256: ""
+EOF
+    if [ -n "$extra" ]; then
+        echo "$extra" >> "$MANIFEST"
+    fi
+    echo "  + ${name} ${version} (${ARCH})"
+}
+# kubeadm_images 用下载的 kubeadm 生成 images 列表（kubeadm config images list）
+# 下载的 kubeadm 与本机架构不同等无法运行的情况返回失败
+kubeadm_images() {
+    local list image ref
+    list=$("$PACKAGE_DIR/${K8S_DIR}/kubeadm" config images list --kubernetes-version "$K8S_VERSION" 2>/dev/null) || return 1
+    [ -n "$list" ] || return 1
+    echo "    images:"
+    for image in $list; do
+        ref=${image##*/}
+        printf '      - name: %s\n        tag: "%s"\n        source: %s\n' "${ref%%:*}" "${ref#*:}" "$image"
+    done
+}
+if [ -f "$MANIFEST" ]; then
+    echo "9. 更新离线包清单..."
+    add_manifest_entry containerd "$CONTAINERD_VERSION" "containerd/containerd-${CONTAINERD_VERSION}-linux-${ARCH}.tar.gz"
+    add_manifest_entry runc "$RUNC_VERSION" "containerd/runc.${ARCH}"
+    add_manifest_entry cni-plugins "$CNI_VERSION" "containerd/cni-plugins-linux-${ARCH}-${CNI_VERSION}.tgz"
+    add_manifest_entry helm "$HELM_VERSION" "helm/linux-${ARCH}/helm"
+    add_manifest_entry kubectl "$K8S_VERSION" "${K8S_DIR}/kubectl"
+    if ! grep -q "path: ${K8S_DIR}/kubeadm\$" "$MANIFEST"; then
+        if KUBEADM_IMAGES=$(kubeadm_images); then
+            add_manifest_entry kubeadm "$K8S_VERSION" "${K8S_DIR}/kubeadm" "$KUBEADM_IMAGES"
+        else
+            add_manifest_entry kubeadm "$K8S_VERSION" "${K8S_DIR}/kubeadm"
+            echo "  ⚠ 无法在本机运行 ${ARCH} 的 kubeadm，请用 kubeadm config images list --kubernetes-version ${K8S_VERSION} 补充 kubeadm 条目的 images"
+        fi
+    fi
+    add_manifest_entry kubelet "$K8S_VERSION" "${K8S_DIR}/kubelet"
+    echo "  ✓ 完成"
+fi
 echo ""
 echo "============================================"
 echo "  ✓ 所有包下载完成"
@@ -134,7 +212,7 @@ echo ""
 echo "包存储位置: $PACKAGE_DIR"
 echo ""
 echo "下一步:"
-echo "  1. 验证包: ./scripts/verify-packages.sh"
+echo "  1. 验证包并记录 sha256: k8s-deployer packages verify --write-checksums"
 echo "  2. 下载 GPU 包 (可选): ./scripts/download-gpu.sh"
 echo "  3. 打包传输: tar czf k8s-packages.tar.gz packages/"
 echo ""
@@ -131,9 +131,12 @@ echo "  1. 下载 NVIDIA 驱动（如需要）:"
 echo "     cd $PACKAGE_DIR && ./download-driver-in-docker.sh"
 echo ""
 echo "  2. 验证所有包:"
-echo "     ../scripts/verify-packages.sh"
+echo "     k8s-deployer packages verify"
 echo ""
 echo "  3. 部署集群时会自动使用这些包"
 echo ""
+echo "RHEL/Rocky/openEuler 节点使用 .rpm 包："
+echo "  驱动放在 $PACKAGE_DIR，nvidia-container-toolkit 放在 $PACKAGE_DIR/nvidia-container-toolkit"
+echo ""
 
 
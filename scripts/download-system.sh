@@ -0,0 +1,74 @@
+#!/bin/bash
+# 下载节点需要的系统软件包（keepalived、haproxy、chrony 等），生成离线软件源
+# 使用方法: ./download-system.sh <发行版>
+#   ubuntu-22.04 | ubuntu-24.04 | debian-12 | el9 | openeuler-22.03 | openeuler-24.03
+#
+# 在对应发行版的 Docker 容器中下载，输出到 packages/system/<发行版>，
+# 部署时按节点的 /etc/os-release 选择目录上传并配置为软件源
+
+set -e
+
+PROJECT_ROOT="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+TARGET="$1"
+
+# 需要的软件包，可通过环境变量追加（如 GPU 节点的驱动编译依赖）
+PACKAGES="keepalived haproxy chrony ${EXTRA_PACKAGES}"
+
+case "$TARGET" in
+    ubuntu-22.04|ubuntu-24.04|debian-12)
+        FAMILY=debian
+        IMAGE="${TARGET%-*}:${TARGET#*-}"
+        ;;
+    el9)
+        FAMILY=rhel
+        IMAGE="rockylinux:9"
+        ;;
+    openeuler-22.03|openeuler-24.03)
+        FAMILY=rhel
+        IMAGE="openeuler/openeuler:${TARGET#*-}-lts"
+        ;;
+    *)
+        echo "使用方法: $0 <ubuntu-22.04|ubuntu-24.04|debian-12|el9|openeuler-22.03|openeuler-24.03>"
+        exit 1
+        ;;
+esac
+
+OUTPUT_DIR="$PROJECT_ROOT/packages/system/$TARGET"
+mkdir -p "$OUTPUT_DIR"
+
+echo "============================================"
+echo "  下载系统软件包: $TARGET"
+echo "============================================"
+echo ""
+echo "镜像: $IMAGE"
+echo "软件包: $PACKAGES"
+echo ""
+
+if [ "$FAMILY" = "debian" ]; then
+    docker run --rm -v "$OUTPUT_DIR:/output" "$IMAGE" bash -c "
+        set -e
+        export DEBIAN_FRONTEND=noninteractive
+        apt-get update -qq
+        apt-get install -y -qq dpkg-dev > /dev/null
+        apt-get install -y --download-only -o Dir::Cache::archives=/output $PACKAGES
+        rm -rf /output/partial /output/lock
+        cd /output && dpkg-scanpackages . /dev/null 2>/dev/null | gzip -9 > Packages.gz
+    "
+else
+    docker run --rm -v "$OUTPUT_DIR:/output" "$IMAGE" bash -c "
+        set -e
+        dnf install -y -q 'dnf-command(download)' createrepo_c > /dev/null
+        dnf download --resolve --destdir /output $PACKAGES
+        createrepo_c /output
+    "
+fi
+
+echo ""
+echo "============================================"
+echo "  ✓ 离线软件源已生成: packages/system/$TARGET"
+echo "============================================"
+echo ""
+ls "$OUTPUT_DIR" | head -20
+echo ""
+echo "部署时会自动上传到节点的 /var/cache/k8s-deployer/repo 并只使用该软件源安装软件包"
+echo ""